@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the app server, uwsgi or gunicorn, which runs DefectDojo behind nginx
+
+// defaultBind is where the app server listens if AppServer.Bind isn't set
+const defaultBind = "127.0.0.1:8000"
+
+// appServerType returns the configured app server, defaulting to uwsgi
+func appServerType(i *config.InstallConfig) string {
+	if i.AppServer.Type == "" {
+		return "uwsgi"
+	}
+	return i.AppServer.Type
+}
+
+// appWorkers returns the configured worker count or 2 per CPU plus 1 if it isn't set
+func appWorkers(i *config.InstallConfig) int {
+	if i.AppServer.Workers > 0 {
+		return i.AppServer.Workers
+	}
+	return 2*runtime.NumCPU() + 1
+}
+
+// appBind returns the host:port or unix socket path the app server listens on
+func appBind(i *config.InstallConfig) string {
+	if i.AppServer.Bind == "" {
+		return defaultBind
+	}
+	return i.AppServer.Bind
+}
+
+// appUpstream returns the address nginx proxies to for the app server's bind
+// A wildcard listen address is reached on localhost since nginx runs on the same host
+func appUpstream(i *config.InstallConfig) string {
+	b := appBind(i)
+	if strings.HasPrefix(b, "/") {
+		return "unix:" + b
+	}
+	host, port, err := net.SplitHostPort(b)
+	if err != nil {
+		return b
+	}
+	switch host {
+	case "", "0.0.0.0", "::":
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// appExecStart returns the command line which starts the configured app server
+func appExecStart(i *config.InstallConfig) string {
+	bin := filepath.Join(venvPath(i), "bin", appServerType(i))
+	workers := strconv.Itoa(appWorkers(i))
+	b := appBind(i)
+	if appServerType(i) == "gunicorn" {
+		if strings.HasPrefix(b, "/") {
+			b = "unix:" + b
+		}
+		return fmt.Sprintf("%s --workers %s --bind %s dojo.wsgi:application", bin, workers, b)
+	}
+	return fmt.Sprintf("%s --master --processes %s --http-socket %s --module dojo.wsgi:application", bin, workers, b)
+}
+
+// appUnit returns the systemd unit running the app server for the install
+func appUnit(i *config.InstallConfig) systemdUnit {
+	return systemdUnit{
+		Name:        "defectdojo-app",
+		Description: "DefectDojo " + appServerType(i) + " app server",
+		User:        i.OS.User,
+		Group:       i.OS.Group,
+		WorkDir:     filepath.Join(i.Root, i.Source),
+		ExecStart:   appExecStart(i),
+	}
+}
+
+// appServerPackages returns the Python packages the app server needs beyond DefectDojo's requirements
+// which already include uwsgi
+func appServerPackages(i *config.InstallConfig) []string {
+	if appServerType(i) == "gunicorn" {
+		return []string{"gunicorn"}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAppExecStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     string
+		workers int
+		bind    string
+		want    string
+	}{
+		{"uwsgi default bind", "", 4, "", "/opt/dojo/bin/uwsgi --master --processes 4 --http-socket 127.0.0.1:8000 --module dojo.wsgi:application"},
+		{"uwsgi socket", "uwsgi", 1, "/run/dojo/app.sock", "/opt/dojo/bin/uwsgi --master --processes 1 --http-socket /run/dojo/app.sock --module dojo.wsgi:application"},
+		{"gunicorn tcp", "gunicorn", 9, "0.0.0.0:9000", "/opt/dojo/bin/gunicorn --workers 9 --bind 0.0.0.0:9000 dojo.wsgi:application"},
+		{"gunicorn socket", "gunicorn", 2, "/run/dojo/app.sock", "/opt/dojo/bin/gunicorn --workers 2 --bind unix:/run/dojo/app.sock dojo.wsgi:application"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := scheduleInstall()
+			i.AppServer.Type = tt.typ
+			i.AppServer.Workers = tt.workers
+			i.AppServer.Bind = tt.bind
+			if got := appExecStart(i); got != tt.want {
+				t.Errorf("Expecting %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAppWorkersDefault(t *testing.T) {
+	i := scheduleInstall()
+	if n := appWorkers(i); n < 3 || n%2 != 1 {
+		t.Errorf("Expecting 2 workers per CPU plus 1 by default, got %d", n)
+	}
+}
+
+func TestNginxUpstreamFollowsBind(t *testing.T) {
+	for bind, want := range map[string]string{
+		"":                   "server 127.0.0.1:8000;",
+		"0.0.0.0:9000":       "server 127.0.0.1:9000;",
+		"10.0.0.5:8001":      "server 10.0.0.5:8001;",
+		"/run/dojo/app.sock": "server unix:/run/dojo/app.sock;",
+	} {
+		i := scheduleInstall()
+		i.AppServer.Bind = bind
+		out := &bytes.Buffer{}
+		if err := renderNginx(out, i); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expecting %q for bind %q, got:\n%s", want, bind, out)
+		}
+	}
+}
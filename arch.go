@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Handles detecting the CPU architecture for picking downloads and package indexes
+
+// archLabels maps Go's GOARCH names to the labels used by Debian/Ubuntu packages and most download sites
+var archLabels = map[string]string{
+	"amd64":    "amd64",
+	"386":      "i386",
+	"arm":      "armhf",
+	"arm64":    "arm64",
+	"ppc64le":  "ppc64el",
+	"s390x":    "s390x",
+	"mips64le": "mips64el",
+}
+
+// supportedArchs are the architectures DefectDojo is known to install and run on
+var supportedArchs = []string{"amd64", "arm64"}
+
+// HostArch returns the normalized architecture of the host godojo is running on
+func HostArch() string {
+	return normalizeArch(runtime.GOARCH)
+}
+
+// normalizeArch maps a GOARCH value to its normalized label, unknown values are returned unchanged
+func normalizeArch(goarch string) string {
+	if l, ok := archLabels[goarch]; ok {
+		return l
+	}
+	return goarch
+}
+
+// checkArch warns when installing on an architecture DefectDojo doesn't officially support
+func checkArch(arch string) {
+	if contains(supportedArchs, arch) {
+		traceMsg(fmt.Sprintf("Host architecture is %s", arch))
+		return
+	}
+	Warning.Printf("Architecture %s is not officially supported by DefectDojo, the install may fail", arch)
+	statusMsg("arch.unsupported", arch)
+}
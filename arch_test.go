@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestNormalizeArch(t *testing.T) {
+	tests := map[string]string{
+		"amd64":   "amd64",
+		"386":     "i386",
+		"arm":     "armhf",
+		"arm64":   "arm64",
+		"ppc64le": "ppc64el",
+		"riscv64": "riscv64",
+	}
+	for in, want := range tests {
+		if got := normalizeArch(in); got != want {
+			t.Errorf("normalizeArch(%q) = %q, expecting %q", in, got, want)
+		}
+	}
+}
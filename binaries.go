@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Handles checking the external programs the install steps run are installed before any step starts
+
+// binaryHints suggests how to install a missing program, programs without a hint get a generic one
+var binaryHints = map[string]string{
+	"apt-get":   "godojo installs on apt based distros like Ubuntu",
+	"apt-key":   "apt-get install apt",
+	"bash":      "apt-get install bash",
+	"chown":     "apt-get install coreutils",
+	"cp":        "apt-get install coreutils",
+	"curl":      "apt-get install curl",
+	"expect":    "apt-get install expect",
+	"git":       "apt-get install git",
+	"groupadd":  "apt-get install passwd",
+	"python3":   "apt-get install python3",
+	"service":   "apt-get install init-system-helpers or run with --container",
+	"systemctl": "install systemd, set Scheduler to cron or run with --container",
+	"useradd":   "apt-get install passwd",
+	"yarn":      "see https://classic.yarnpkg.com/en/docs/install",
+}
+
+// RequireBinaries returns an error listing every one of names not found in $PATH with a hint on installing it
+func RequireBinaries(names ...string) error {
+	missing := []string{}
+	for _, n := range names {
+		_, err := lookPath(n)
+		if err != nil {
+			hint, ok := binaryHints[n]
+			if !ok {
+				hint = "install it with the OS package manager"
+			}
+			missing = append(missing, fmt.Sprintf("%s (%s)", n, hint))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("required programs weren't found in $PATH: %s", strings.Join(missing, ", "))
+}
+
+// stepBinaries returns the programs steps need which aren't provided by an earlier step, skipped steps are ignored
+func stepBinaries(steps []installStep) []string {
+	provided := map[string]bool{}
+	seen := map[string]bool{}
+	names := []string{}
+	for _, s := range steps {
+		if s.skip {
+			continue
+		}
+		for _, n := range s.needs {
+			if provided[n] || seen[n] {
+				continue
+			}
+			seen[n] = true
+			names = append(names, n)
+		}
+		for _, n := range s.provides {
+			provided[n] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeLookPath makes only the programs in installed appear to be in $PATH until the test ends
+func fakeLookPath(t *testing.T, installed ...string) {
+	saved := lookPath
+	t.Cleanup(func() { lookPath = saved })
+	lookPath = func(name string) (string, error) {
+		for _, n := range installed {
+			if n == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("executable file not found in $PATH")
+	}
+}
+
+func TestRequireBinaries(t *testing.T) {
+	fakeLookPath(t, "bash", "curl")
+	if err := RequireBinaries("bash", "curl"); err != nil {
+		t.Errorf("Unexpected error with every program installed: %v", err)
+	}
+	err := RequireBinaries("bash", "yarn", "expect")
+	if err == nil {
+		t.Fatal("Expecting an error for the missing programs")
+	}
+	for _, want := range []string{"yarn (see https://classic.yarnpkg.com", "expect (apt-get install expect)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expecting %q in the error, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "bash") {
+		t.Errorf("Expecting only missing programs in the error, got %v", err)
+	}
+}
+
+func TestStepBinaries(t *testing.T) {
+	steps := []installStep{
+		{name: "bootstrap", needs: []string{"apt-get"}, provides: []string{"python3"}},
+		{name: "prep-os", needs: []string{"python3", "chown"}},
+		{name: "frontend", needs: []string{"yarn"}, skip: true},
+		{name: "django", needs: []string{"chown", "expect"}},
+	}
+	got := strings.Join(stepBinaries(steps), ",")
+	if got != "apt-get,chown,expect" {
+		t.Errorf("Expecting apt-get,chown,expect, got %s", got)
+	}
+}
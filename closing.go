@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the message shown when an install finishes so the operator knows how to reach DefectDojo
+
+// dojoURL returns the URL to reach the DefectDojo UI leaving out the port if it's the scheme's default
+func dojoURL(i *config.InstallConfig) string {
+	scheme := "http"
+	if i.TLS.SelfSigned {
+		scheme = "https"
+	}
+	port := listenPort(i)
+	host := tlsHostname(i)
+	if (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
+		return scheme + "://" + host + "/"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + "/"
+}
+
+// listenPort returns the port nginx listens on for DefectDojo, defaulting to 80 or 443 with TLS
+func listenPort(i *config.InstallConfig) int {
+	if i.Port != 0 {
+		return i.Port
+	}
+	if i.TLS.SelfSigned {
+		return 443
+	}
+	return 80
+}
+
+// closingMsg writes what an operator needs after a successful install - where to log in, as who, the logs
+// and any optional steps in failed which --keep-going continued past
+func closingMsg(w io.Writer, i *config.InstallConfig, logPath string, failed []string) {
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "==============================================================================")
+	fmt.Fprintf(w, "  %s\n", colorize(colorGreen, "DefectDojo "+manifest.Version+" is installed"))
+	fmt.Fprintln(w, "==============================================================================")
+	fmt.Fprintln(w, "")
+	fmt.Fprintf(w, "  Log in at:   %s\n", dojoURL(i))
+	fmt.Fprintf(w, "  Admin user:  %s\n", i.Admin.User)
+	fmt.Fprintln(w, "  Password:    the admin password set in Install.Admin.Pass or shown earlier in the install")
+	if i.TLS.SelfSigned {
+		fmt.Fprintln(w, "  The certificate is self-signed, expect a browser warning")
+	}
+	fmt.Fprintf(w, "  Install log: %s\n", logPath)
+	if len(failed) > 0 {
+		fmt.Fprintln(w, "")
+		fmt.Fprintf(w, "  %s These optional steps FAILED and need fixing by hand: %s\n", colorize(colorYellow, "WARNING:"), strings.Join(failed, ", "))
+	}
+	fmt.Fprintln(w, "")
+}
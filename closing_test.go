@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestClosingMsg(t *testing.T) {
+	i := config.InstallConfig{}
+	i.TLS.Hostname = "dojo.example.com"
+	i.Admin.User = "admin"
+
+	tests := []struct {
+		tls  bool
+		port int
+		want string
+	}{
+		{false, 0, "http://dojo.example.com/"},
+		{true, 0, "https://dojo.example.com/"},
+		{true, 8443, "https://dojo.example.com:8443/"},
+		{false, 8080, "http://dojo.example.com:8080/"},
+	}
+	for _, tc := range tests {
+		i.TLS.SelfSigned = tc.tls
+		i.Port = tc.port
+		out := &bytes.Buffer{}
+		closingMsg(out, &i, "logs/dojo-install_1.log", nil)
+		if !strings.Contains(out.String(), "Log in at:   "+tc.want+"\n") {
+			t.Errorf("Expecting URL %s, got:\n%s", tc.want, out)
+		}
+		if !strings.Contains(out.String(), "Admin user:  admin") || !strings.Contains(out.String(), "logs/dojo-install_1.log") {
+			t.Errorf("Expecting the admin user and log file, got:\n%s", out)
+		}
+	}
+}
+
+func TestClosingMsgFailedSteps(t *testing.T) {
+	i := config.InstallConfig{}
+	i.Admin.User = "admin"
+	out := &bytes.Buffer{}
+	closingMsg(out, &i, "logs/dojo-install_1.log", []string{"frontend", "nginx"})
+	if !strings.Contains(out.String(), "optional steps FAILED and need fixing by hand: frontend, nginx") {
+		t.Errorf("Expecting the failed optional steps listed, got:\n%s", out)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// Handles running OS commands with their output streamed into the install log
+
+// cmdTailLines is how many of the last lines of output a failed command's error holds
+const cmdTailLines = 50
+
+// RunCmd runs name with args, logging stdout at the info level and stderr at the warning level as it's written
+// Secrets are redacted from the logged command and output. With DryRun set the command is only logged.
+// A command which can't start or exits non-zero returns a *dojoerr.CmdError holding the tail of its output
+func RunCmd(ctx context.Context, name string, args ...string) error {
+	cmdLine := Redactatron(strings.Join(append([]string{name}, args...), " "), Redact)
+	if DryRun {
+		Info.Println("DRY-RUN: would run " + cmdLine)
+		return nil
+	}
+	Info.Println("Running " + cmdLine)
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return &dojoerr.CmdError{Cmd: cmdLine, ExitCode: -1, Err: err}
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return &dojoerr.CmdError{Cmd: cmdLine, ExitCode: -1, Err: err}
+	}
+	err = cmd.Start()
+	if err != nil {
+		Error.Printf("Unable to start %s: %+v", cmdLine, err)
+		return &dojoerr.CmdError{Cmd: cmdLine, ExitCode: -1, Err: err}
+	}
+
+	// Output must be fully read before Wait closes the pipes
+	var wg sync.WaitGroup
+	tail := &tailBuffer{max: cmdTailLines}
+	wg.Add(2)
+	go logLines(&wg, stdout, Info, tail)
+	go logLines(&wg, stderr, Warning, tail)
+	wg.Wait()
+
+	err = cmd.Wait()
+	if err != nil {
+		code := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		Error.Printf("Command %s exited with code %d", cmdLine, code)
+		return &dojoerr.CmdError{Cmd: cmdLine, ExitCode: code, Err: err, Tail: tail.lines()}
+	}
+	traceMsg(fmt.Sprintf("Command %s exited with code 0", cmdLine))
+	return nil
+}
+
+// logLines writes each line read from r to l with secrets redacted, keeping the latest in tail
+func logLines(wg *sync.WaitGroup, r io.Reader, l *log.Logger, tail *tailBuffer) {
+	defer wg.Done()
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := Redactatron(s.Text(), Redact)
+		l.Println("  > " + line)
+		tail.add(line)
+	}
+}
+
+// tailBuffer - keeps the last max lines added to it, safe to add to from stdout and stderr at once
+type tailBuffer struct {
+	mu    sync.Mutex
+	max   int
+	buf   []string
+	start int
+}
+
+// add appends line, dropping the oldest line once max lines are held
+func (t *tailBuffer) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.buf) < t.max {
+		t.buf = append(t.buf, line)
+		return
+	}
+	t.buf[t.start] = line
+	t.start = (t.start + 1) % t.max
+}
+
+// lines returns the lines held oldest first
+func (t *tailBuffer) lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append(append([]string{}, t.buf[t.start:]...), t.buf[:t.start]...)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// captureLogs sends the info and warning loggers to a buffer for the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	savedI, savedW, savedE := Info, Warning, Error
+	t.Cleanup(func() { Info, Warning, Error = savedI, savedW, savedE })
+	Info = log.New(buf, "INFO: ", 0)
+	Warning = log.New(buf, "WARNING: ", 0)
+	Error = log.New(buf, "ERROR: ", 0)
+	return buf
+}
+
+func TestRunCmdSuccess(t *testing.T) {
+	buf := captureLogs(t)
+	err := RunCmd(context.Background(), "sh", "-c", "echo out; echo err >&2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"INFO:   > out", "WARNING:   > err", "Running sh -c"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expecting %q in the log, got:\n%s", want, buf)
+		}
+	}
+}
+
+func TestRunCmdExitCode(t *testing.T) {
+	captureLogs(t)
+	err := RunCmd(context.Background(), "sh", "-c", "exit 3")
+	var cErr *dojoerr.CmdError
+	if !errors.As(err, &cErr) || cErr.ExitCode != 3 {
+		t.Errorf("Expecting a CmdError with exit code 3, got %v", err)
+	}
+}
+
+func TestRunCmdDryRun(t *testing.T) {
+	buf := captureLogs(t)
+	DryRun = true
+	defer func() { DryRun = false }()
+	// Would fail if actually run
+	err := RunCmd(context.Background(), "false")
+	if err != nil {
+		t.Errorf("Expecting dry-run to not run the command, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "DRY-RUN: would run false") {
+		t.Errorf("Expecting the command to be logged, got:\n%s", buf)
+	}
+}
+
+func TestRunCmdRedacts(t *testing.T) {
+	buf := captureLogs(t)
+	saved, savedR := sensStr, Redact
+	defer func() { sensStr, Redact = saved, savedR }()
+	sensStr[0] = "hunter2hunter2"
+	Redact = true
+	err := RunCmd(context.Background(), "echo", "--password", "hunter2hunter2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "hunter2hunter2") {
+		t.Errorf("Expecting the secret to be redacted, got:\n%s", buf)
+	}
+}
+
+func TestRunCmdTail(t *testing.T) {
+	captureLogs(t)
+	err := RunCmd(context.Background(), "sh", "-c", "for i in $(seq 1 60); do echo line $i; done; exit 1")
+	var cErr *dojoerr.CmdError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("Expecting a CmdError, got %v", err)
+	}
+	if len(cErr.Tail) != cmdTailLines || cErr.Tail[0] != "line 11" || cErr.Tail[cmdTailLines-1] != "line 60" {
+		t.Errorf("Expecting the last %d lines, line 11 to line 60, got %q", cmdTailLines, cErr.Tail)
+	}
+	if !strings.Contains(err.Error(), "last output:\n  line 11\n  line 12") || strings.Contains(err.Error(), "line 10\n") {
+		t.Errorf("Expecting the error to end with the tail of the output, got:\n%s", err)
+	}
+
+	// Successful commands return no error and so no output
+	err = RunCmd(context.Background(), "sh", "-c", "echo fine")
+	if err != nil {
+		t.Errorf("Expecting no error from a successful command, got %v", err)
+	}
+}
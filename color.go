@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+
+	"github.com/mtesauro/godojo/config"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Handles colored console output following the NO_COLOR (https://no-color.org) and CLICOLOR conventions
+// Only the console is ever colored, the install log gets the plain text
+
+// ANSI codes used on the console
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBold   = "\x1b[1m"
+	colorReset  = "\x1b[0m"
+)
+
+var (
+	// Resolved by setColor once the config is loaded, off until then
+	colorOn bool
+	// stdoutTerminal reports if stdout is a terminal - a var so tests can pretend either way
+	stdoutTerminal = func() bool { return terminal.IsTerminal(int(os.Stdout.Fd())) }
+)
+
+// resolveColor returns true if console output should be colored, the first to decide wins of --no-color,
+// NO_COLOR set to anything, --color always or never, CLICOLOR_FORCE set to anything but 0, CLICOLOR set
+// to 0 and finally if stdout is a terminal
+func resolveColor(i *config.InstallConfig, lookupEnv func(string) (string, bool), tty bool) bool {
+	if i.NoColor {
+		return false
+	}
+	if _, ok := lookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	switch i.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if v, ok := lookupEnv("CLICOLOR_FORCE"); ok && v != "" && v != "0" {
+		return true
+	}
+	if v, ok := lookupEnv("CLICOLOR"); ok && v == "0" {
+		return false
+	}
+	return tty
+}
+
+// setColor resolves if console output is colored for config i from the ENV and stdout
+func setColor(i *config.InstallConfig) {
+	colorOn = resolveColor(i, os.LookupEnv, stdoutTerminal())
+}
+
+// shouldColor returns true if console output is colored, everything writing color to the console checks it
+func shouldColor() bool {
+	return colorOn
+}
+
+// colorize returns s in the ANSI color code if console output is colored, otherwise s unchanged
+func colorize(code string, s string) string {
+	if !shouldColor() {
+		return s
+	}
+	return code + s + colorReset
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestResolveColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		noColor bool
+		color   string
+		env     map[string]string
+		tty     bool
+		want    bool
+	}{
+		{"terminal", false, "", nil, true, true},
+		{"not a terminal", false, "", nil, false, false},
+		{"auto on a terminal", false, "auto", nil, true, true},
+		{"--no-color beats --color always", true, "always", nil, true, false},
+		{"--no-color beats CLICOLOR_FORCE", true, "", map[string]string{"CLICOLOR_FORCE": "1"}, true, false},
+		{"NO_COLOR set", false, "", map[string]string{"NO_COLOR": "1"}, true, false},
+		{"NO_COLOR empty still disables", false, "", map[string]string{"NO_COLOR": ""}, true, false},
+		{"NO_COLOR beats --color always", false, "always", map[string]string{"NO_COLOR": "1"}, true, false},
+		{"--color always off a terminal", false, "always", nil, false, true},
+		{"--color always beats CLICOLOR 0", false, "always", map[string]string{"CLICOLOR": "0"}, false, true},
+		{"--color never on a terminal", false, "never", nil, true, false},
+		{"--color never beats CLICOLOR_FORCE", false, "never", map[string]string{"CLICOLOR_FORCE": "1"}, true, false},
+		{"CLICOLOR_FORCE off a terminal", false, "", map[string]string{"CLICOLOR_FORCE": "1"}, false, true},
+		{"CLICOLOR_FORCE 0 is ignored", false, "", map[string]string{"CLICOLOR_FORCE": "0"}, false, false},
+		{"CLICOLOR_FORCE beats CLICOLOR 0", false, "", map[string]string{"CLICOLOR_FORCE": "1", "CLICOLOR": "0"}, false, true},
+		{"CLICOLOR 0 on a terminal", false, "", map[string]string{"CLICOLOR": "0"}, true, false},
+		{"CLICOLOR 1 off a terminal", false, "", map[string]string{"CLICOLOR": "1"}, false, false},
+		{"CLICOLOR 1 on a terminal", false, "", map[string]string{"CLICOLOR": "1"}, true, true},
+	}
+	for _, tt := range tests {
+		lookupEnv := func(k string) (string, bool) {
+			v, ok := tt.env[k]
+			return v, ok
+		}
+		got := resolveColor(&config.InstallConfig{NoColor: tt.noColor, Color: tt.color}, lookupEnv, tt.tty)
+		if got != tt.want {
+			t.Errorf("%s: expecting color %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestColorFlags(t *testing.T) {
+	for args, want := range map[string]string{"--color": "always", "--force-color": "always", "--color=never": "never"} {
+		fs := installFlags()
+		if err := fs.Parse([]string{args}); err != nil {
+			t.Fatalf("Unable to parse %s: %v", args, err)
+		}
+		if got := fs.Lookup("color").Value.String(); got != want {
+			t.Errorf("Expecting %s to set --color to %s, got %s", args, want, got)
+		}
+	}
+}
+
+func TestColorNeverInLog(t *testing.T) {
+	saved := colorOn
+	defer func() { colorOn = saved }()
+	var log bytes.Buffer
+	logSetup(&log, nil)
+	defer logSetup(ioutil.Discard, nil)
+
+	colorOn = false
+	if got := colorize(colorRed, "ERROR:"); got != "ERROR:" {
+		t.Errorf("Expecting no color codes when color is off, got %q", got)
+	}
+	colorOn = true
+	if got := colorize(colorRed, "ERROR:"); got != colorRed+"ERROR:"+colorReset {
+		t.Errorf("Expecting the text in red, got %q", got)
+	}
+	sectionMsg("python.section")
+	errorMsg("creds.read")
+	if strings.Contains(log.String(), "\x1b[") || !strings.Contains(log.String(), "Unable to read file") {
+		t.Errorf("Expecting the log without color codes, got %q", log.String())
+	}
+}
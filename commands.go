@@ -0,0 +1,19 @@
+package main
+
+import "context"
+
+// Subcommands which run instead of an install e.g. godojo config
+
+// commands maps each subcommand to the function that runs it with main's context, each returns the exit code
+var commands = map[string]func(ctx context.Context, args []string) int{
+	"config":         printConfigCmd,
+	"decrypt-config": decryptConfigCmd,
+	"doctor":         doctorCmd,
+	"download":       downloadCmd,
+	"logs":           logsCmd,
+	"reinstall":      reinstallCmd,
+	"render":         renderCmd,
+	"step":           stepCmd,
+	"validate":       validateCmd,
+	"verify":         verifyCmd,
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles checking that the requested DefectDojo version can run on the detected OS
+
+// compatRule - a DefectDojo version range that doesn't work (or isn't known to work) on an OS release
+type compatRule struct {
+	minVersion string   // First DefectDojo version the rule applies to
+	distro     string   // Linux distro as reported by OS detection e.g. ubuntu
+	releases   []string // Releases of that distro that the rule applies to
+	fatal      bool     // If true, the install can't succeed so return an error, otherwise warn
+	reason     string   // Why the pair doesn't work
+}
+
+// compatMatrix lists the known bad DefectDojo version + OS pairs - add new rules here as releases change
+// requirements, anything not listed is assumed to be compatible
+var compatMatrix = []compatRule{
+	{
+		minVersion: "1.5.0",
+		distro:     "ubuntu",
+		releases:   []string{"14.04", "16.04"},
+		fatal:      true,
+		reason:     "DefectDojo 1.5.0 and later require Python 3.6+ which this Ubuntu release doesn't provide",
+	},
+	{
+		minVersion: "1.5.0",
+		distro:     "debian",
+		releases:   []string{"jessie", "8", "stretch", "9"},
+		fatal:      true,
+		reason:     "DefectDojo 1.5.0 and later require Python 3.6+ which this Debian release doesn't provide",
+	},
+	{
+		minVersion: "1.5.0",
+		distro:     "ubuntu",
+		releases:   []string{"18.10", "19.04"},
+		fatal:      false,
+		reason:     "this Ubuntu release is end of life and hasn't been tested with godojo",
+	},
+}
+
+// CheckCompatibility returns an error if the DefectDojo version is known not to work on the provided OS
+// Pairs that are merely untested produce a warning in the logs and on the console
+func CheckCompatibility(version string, tOS targetOS) error {
+	for _, r := range compatMatrix {
+		if r.distro != tOS.distro || !contains(r.releases, tOS.release) {
+			continue
+		}
+		if compareVersions(version, r.minVersion) < 0 {
+			continue
+		}
+		msg := fmt.Sprintf("DefectDojo %s on %s %s: %s", version, tOS.distro, tOS.release, r.reason)
+		if r.fatal {
+			return fmt.Errorf("incompatible install target, %s", msg)
+		}
+		statusMsg("warning", msg)
+		Warning.Println(msg)
+	}
+	return nil
+}
+
+// checkCompat wraps CheckCompatibility to honor the --ignore-compat override
+func checkCompat(i *config.InstallConfig, tOS targetOS) error {
+	// Source installs don't have a meaningful version to compare
+	if i.SourceInstall {
+		traceMsg("Skipping the compatibility check for a source install")
+		return nil
+	}
+	err := CheckCompatibility(i.Version, tOS)
+	if err != nil && i.IgnoreCompat {
+		statusMsg("compat.ignored", err)
+		Warning.Println(err)
+		return nil
+	}
+	return err
+}
+
+// compareVersions compares dotted version strings like 1.5.3.1 returning -1, 0 or 1
+// A leading 'v' is ignored and non-numeric parts compare as 0
+func compareVersions(a string, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for k := 0; k < len(as) || k < len(bs); k++ {
+		var x, y int
+		if k < len(as) {
+			x, _ = strconv.Atoi(as[k])
+		}
+		if k < len(bs) {
+			y, _ = strconv.Atoi(bs[k])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// contains returns true if s is in the list l
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
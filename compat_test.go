@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	bionic := targetOS{id: "ubuntu:18.04", os: "linux", distro: "ubuntu", release: "18.04"}
+	xenial := targetOS{id: "ubuntu:16.04", os: "linux", distro: "ubuntu", release: "16.04"}
+
+	if err := CheckCompatibility("1.5.3.1", bionic); err != nil {
+		t.Errorf("Expecting 1.5.3.1 on Ubuntu 18.04 to be compatible, got %v", err)
+	}
+	if err := CheckCompatibility("1.5.3.1", xenial); err == nil {
+		t.Errorf("Expecting 1.5.3.1 on Ubuntu 16.04 to be incompatible")
+	}
+	if err := CheckCompatibility("1.4.0", xenial); err != nil {
+		t.Errorf("Expecting 1.4.0 on Ubuntu 16.04 to be compatible, got %v", err)
+	}
+}
+
+func TestCheckCompatIgnore(t *testing.T) {
+	xenial := targetOS{id: "ubuntu:16.04", os: "linux", distro: "ubuntu", release: "16.04"}
+	i := config.InstallConfig{Version: "1.5.3.1"}
+
+	if err := checkCompat(&i, xenial); err == nil {
+		t.Errorf("Expecting an error without --ignore-compat")
+	}
+	i.IgnoreCompat = true
+	if err := checkCompat(&i, xenial); err != nil {
+		t.Errorf("Expecting --ignore-compat to bypass the check, got %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.5.3.1", "1.5.0", 1},
+		{"v1.5.0", "1.5", 0},
+		{"1.4.9", "1.5.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%s, %s) = %d, expecting %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // DojoConfig - "mother" struct to hold all the config options
 type DojoConfig struct {
 	Install  InstallConfig
@@ -9,41 +11,127 @@ type DojoConfig struct {
 // InstallConfig - struct to hold the install time options
 type InstallConfig struct {
 	// Installer settings
-	Version       string         // Holds the version of Dojo to check out from the repo
-	SourceInstall bool           // If true, do a source install instead of a versioned release
-	SourceBranch  string         // Branch to checkout for a source install, if SourceCommit isn't "", SourceBranch will be ignored
-	SourceCommit  string         // head or full commit hash to install a specific commit, SourceBranch will be ignored if this isn't ""
-	Quiet         bool           // If true, suppress all output except for very early errors - logs will still be written in the log directory
-	Trace         bool           // If true, log at the trace level
-	Redact        bool           // If true, redact sensitive information from being logged.  Defaults to true
-	Prompt        bool           // Prompt at run time for install config.  If true, user will be prompted
-	Mac           bool           // The install set or type: Single Server, Dev, Stand-alone
-	Root          string         // Install root defaults to /opt/dojo
-	Source        string         // Directory to put the Dojo souce, child directory of Root
-	Files         string         // Directory for locally generated files like uploads, static, media, etc
-	App           string         // Directory where the Dojo Django app lives inside of Source above
-	Sampledata    bool           // Install the sample data if true, defaults to false
-	DB            DBTarget       // struct for DB configuration values
-	OS            OSTarget       // struct for DB configuration values
-	Settings      SettingsTarget // struct for DB configuration values
-	Admin         AdminTarget    // struct for DB configuration values
-	PullSource    bool           // If false, installer won't download source code - primarily for debugging
+	Version               string          // Holds the version of Dojo to check out from the repo
+	SourceInstall         bool            // If true, do a source install instead of a versioned release
+	SourceBranch          string          // Branch to checkout for a source install, if SourceCommit isn't "", SourceBranch will be ignored
+	SourceCommit          string          // head or full commit hash to install a specific commit, SourceBranch will be ignored if this isn't ""
+	SourcePR              int             // GitHub pull request number to install from its refs/pull/<n>/head ref, SourceCommit and SourceBranch are ignored if this isn't 0
+	Quiet                 bool            // If true, suppress all output except for very early errors - logs will still be written in the log directory
+	Output                string          // quiet, progress or verbose console output, empty uses quiet if Quiet is true and verbose if not
+	Trace                 bool            // If true, log at the trace level
+	Redact                bool            // If true, redact sensitive information from being logged.  Defaults to true
+	Prompt                bool            // Prompt at run time for install config.  If true, user will be prompted
+	Mac                   bool            // The install set or type: Single Server, Dev, Stand-alone
+	Root                  string          // Install root defaults to /opt/dojo
+	Source                string          // Name of the directory in Root holding the Dojo source, a single path component that stays the same across versions
+	Files                 string          // Directory for locally generated files like uploads, static, media, etc
+	App                   string          // Directory where the Dojo Django app lives inside of Source above
+	Sampledata            bool            // Install the sample data if true, defaults to false
+	DB                    DBTarget        // struct for DB configuration values
+	OS                    OSTarget        // struct for DB configuration values
+	Settings              SettingsTarget  // struct for DB configuration values
+	Admin                 AdminTarget     // struct for DB configuration values
+	PullSource            bool            // If false, installer won't download source code - primarily for debugging
+	GitHubToken           string          // Optional GitHub API token to avoid rate limiting, can also be set with DD_GITHUB_TOKEN
+	ReleaseCacheFile      string          // If set, GitHub releases API responses are saved here and reused by later runs for ReleaseCacheTTL
+	ReleaseCacheTTL       time.Duration   // How long a response in ReleaseCacheFile is reused, defaults to 10m
+	MaxRetryAfter         time.Duration   // Longest to wait in total when GitHub's secondary rate limit asks to retry later with Retry-After, defaults to 2m, 0 never waits
+	IgnoreCompat          bool            // If true, install even if the DefectDojo version is known not to work on the OS
+	Syslog                bool            // If true, send log output to the local syslog as well as the log file
+	HTTPTrace             bool            // If true and Trace is on, log wire-level details of HTTP downloads
+	WriteRuntimeConfig    bool            // If true (the default), write the resolved config with secrets redacted to runtime-install-config.yml
+	ResultFile            string          // If set, write the outcome of the install as JSON to this path for automation
+	MetricsFile           string          // If set, write the install's outcome and step durations to this .prom file for node_exporter's textfile collector
+	PostInstallHook       string          // Command or script run with sh once the install succeeds, given DOJO_VERSION, DOJO_SOURCE, DOJO_URL etc in its ENV
+	PostInstallHookFatal  bool            // If true, a failed PostInstallHook fails the install instead of only warning
+	Telemetry             bool            // If true, post an anonymized outcome of the install to TelemetryURL, off by default
+	TelemetryURL          string          // Endpoint the Telemetry JSON is posted to
+	RuntimeConfigPath     string          // Where to write the runtime config, defaults to runtime-install-config.yml in the log directory
+	VenvPath              string          // Directory for DefectDojo's Python virtualenv, defaults to Root
+	ForceVenv             bool            // If true, always recreate the virtualenv instead of reusing a valid one
+	Umask                 string          // Octal umask like 0027 (the default) set at startup for every file the install and the commands it runs create, secrets keep their explicit 0600
+	SourcePerms           string          // Octal mode like 0750 for the downloaded source tree, read bits add execute on directories and executables, empty keeps the tarball's modes
+	TempDir               string          // Directory the release is downloaded and extracted in before moving it into Root, defaults to Root/.godojo-tmp
+	MinFreeInodes         int             // Free inodes the filesystem holding Root needs before the source is extracted, defaults to 100000, 0 skips the check
+	ExistingSource        string          // What to do if the source directory exists - error (the default), overwrite or backup
+	MinPython             string          // Oldest Python version the install accepts e.g. 3.6, defaults to DefectDojo's minimum
+	RequirementsFile      string          // pip requirements file relative to the source directory, defaults to requirements.txt
+	PipExtras             []string        // Extra Python packages to pip install along with the requirements file
+	OSPackages            PackageLists    // OS packages added to the built-in ones by distro like ubuntu, a leading - drops a built-in package
+	LoadFixtures          []string        // Extra Django fixtures loaded with manage.py loaddata after collectstatic e.g. initial_surveys, empty skips loaddata
+	SkipRootCheck         bool            // If true, warn instead of quitting when not run as root - also --skip-root-check
+	Container             string          // Container mode - auto (the default) detects it, true or false forces it
+	MaxDownloadKBps       int             // Cap on the release download speed in kilobytes per second, 0 is unlimited
+	ConnectTimeout        time.Duration   // Longest to wait connecting to a download host, 0 is unlimited
+	TLSHandshakeTimeout   time.Duration   // Longest to wait for a download host's TLS handshake, 0 is unlimited
+	CACertFile            string          // PEM file of CAs trusted for downloads as well as the system's e.g. a TLS-inspecting proxy's CA
+	InsecureSkipVerify    bool            // If true, don't verify the TLS certificates of download hosts - for development only, never in production
+	RepoOwner             string          // Owner of the GitHub repo releases are downloaded and cloned from, defaults to DefectDojo - set it and RepoName to install a fork
+	RepoName              string          // Name of the GitHub repo releases are downloaded and cloned from, defaults to django-DefectDojo
+	CloneURL              string          // Git URL source installs clone, https or SSH like git@git.example.com:dojo/django-DefectDojo.git, empty is the GitHub repo
+	SSHKey                string          // Private key used to clone an SSH CloneURL
+	SSHKeyPass            string          // Passphrase of SSHKey if it's encrypted, can also be set with DD_SSH_KEY_PASS
+	KnownHosts            string          // known_hosts file the host key of an SSH CloneURL is checked against, empty is ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts
+	SSHInsecureHostKey    bool            // If true, accept any host key for an SSH CloneURL - for development only, never in production
+	ResponseHeaderTimeout time.Duration   // Longest to wait for a download's response headers after sending the request, 0 is unlimited
+	AssetPattern          string          // Glob like defectdojo-*.tar.gz matching the release asset to download instead of the source archive
+	ArchiveType           string          // tar.gz or zip, the release archive to download, empty is tar.gz with the type of a downloaded asset or mirror file detected
+	ReleaseMirrors        []string        // Base URLs like the GitHub release archive URL tried in order if the release download fails with a connection error or 5xx
+	ChecksumURL           string          // Optional URL of a sha256sum file the release tarball is verified against
+	SignatureURL          string          // Optional URL of a detached signature saved next to the release tarball
+	AllowWeakPasswords    bool            // If true, allow empty or weak DB and admin passwords - for development installs only
+	AssumeYes             bool            // If true, answer yes to every confirmation without asking - also --yes or -y
+	NoBanner              bool            // If true, skip the ASCII art banner while keeping the status output
+	Color                 string          // always, never or auto (the default) to color console output on a terminal unless NO_COLOR or CLICOLOR say otherwise
+	NoColor               bool            // If true, never color console output whatever Color, CLICOLOR_FORCE or the terminal say - also --no-color
+	InstallTimeout        time.Duration   // Longest the install may run before it's stopped e.g. 45m, 0 is unlimited
+	HealthTimeout         time.Duration   // Longest to wait for DefectDojo to answer once its services are started, defaults to 2m, 0 skips the health check
+	HealthInterval        time.Duration   // How long the health check waits between requests, defaults to 2s
+	HashSource            bool            // If true, record the SHA-256 of each extracted file in Root/manifest.sha256 for godojo verify
+	Lang                  string          // Language of console messages, en (the default) or es - the install log is always in English
+	KeepGoing             bool            // If true, continue past failures of optional steps like the frontend build and nginx config - also --keep-going
+	DryRun                bool            // If true, log the OS commands the install would run instead of running them
+	AllowedHosts          []string        // Host names and IPs DefectDojo answers to, defaults to localhost and 127.0.0.1, the hostname is always added
+	Port                  int             // Port nginx listens on for DefectDojo, defaults to 80 or 443 with TLS
+	TLS                   TLSTarget       // struct for TLS configuration values
+	ConfigPassphrase      string          // If set, encrypt secrets in the runtime config with this instead of redacting them, best set with DD_CONFIG_PASSPHRASE
+	SkipFrontend          bool            // If true, don't install Node.js or build the frontend assets - for API-only deployments
+	Scheduler             string          // How DefectDojo's periodic tasks are run - celery-beat, cron or none
+	AppServer             AppServerTarget // struct for the app server nginx proxies to
+}
+
+// OutputMode returns the console output mode, Output if it's set or else quiet or verbose from Quiet
+// so configs from before Output work unchanged
+func (i *InstallConfig) OutputMode() string {
+	if i.Output != "" {
+		return i.Output
+	}
+	if i.Quiet {
+		return "quiet"
+	}
+	return "verbose"
 }
 
 // DBTarget - struct to hold Install.DB options
 type DBTarget struct {
-	Engine string
-	Local  bool
-	Exists bool
-	Ruser  string
-	Rpass  string
-	Name   string
-	User   string
-	Pass   string
-	Host   string
-	Port   int
-	Drop   bool
-}
+	Engine    string
+	Local     bool
+	Exists    bool
+	Ruser     string
+	Rpass     string
+	RpassFile string // File holding Rpass, used instead of the value of Rpass if set
+	Name      string
+	User      string
+	Pass      string
+	PassFile  string // File holding Pass, used instead of the value of Pass if set
+	Host      string
+	Port      int
+	Drop      bool
+	Wait      time.Duration // How long to wait for the database to accept connections before migrating, 0 checks once
+}
+
+// PackageLists - OS package names keyed by distro like ubuntu, default holds those for distros not listed
+type PackageLists map[string][]string
 
 // OSTarget - struct to hold Install.OS options
 type OSTarget struct {
@@ -59,11 +147,27 @@ type SettingsTarget struct {
 	Env  string
 }
 
+// TLSTarget - struct to hold Install.TLS options
+type TLSTarget struct {
+	SelfSigned bool   // If true, generate a self-signed certificate for HTTPS - not for production
+	Hostname   string // Hostname the certificate is for, defaults to the OS hostname
+	Cert       string // Path to write the certificate to, defaults to Root/tls/dojo.crt
+	Key        string // Path to write the private key to, defaults to Root/tls/dojo.key
+}
+
+// AppServerTarget - struct to hold Install.AppServer options
+type AppServerTarget struct {
+	Type    string // App server running DefectDojo - uwsgi (the default) or gunicorn
+	Workers int    // Worker processes the app server runs, 0 uses 2 per CPU plus 1
+	Bind    string // host:port or the path of a unix socket the app server listens on, defaults to 127.0.0.1:8000
+}
+
 // AdminTarget - struct to hold Install.Admin options
 type AdminTarget struct {
-	User  string
-	Pass  string
-	Email string
+	User     string
+	Pass     string
+	PassFile string // File holding Pass, used instead of the value of Pass if set
+	Email    string
 }
 
 // SettingsConfig - struct to hold the config values for settings.py
@@ -396,7 +500,8 @@ type DatabaseSt struct {
 
 // SecretSt - struct for DD_SECRET_KEY
 type SecretSt struct {
-	Key string
+	Key     string
+	KeyFile string // File holding Key, used instead of the value of Key if set
 }
 
 // CredentialSt - struct for DD_CREDENTIAL_AES_256_KEY
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// ExpandPath expands ~ to the current user's home directory and $VAR or ${VAR} to their values in p
+// then makes it absolute, like a shell would before the path is used. An empty p stays empty
+func ExpandPath(p string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	p = os.ExpandEnv(p)
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(u.HomeDir, p[1:])
+	}
+	return filepath.Abs(p)
+}
+
+// ExpandPaths expands every path in the Install config and secret files with ExpandPath so ~/dojo or $HOME/dojo work
+// the same as in a shell
+func (d *DojoConfig) ExpandPaths() error {
+	i := &d.Install
+	paths := map[string]*string{
+		"Install.Root":              &i.Root,
+		"Install.VenvPath":          &i.VenvPath,
+		"Install.TempDir":           &i.TempDir,
+		"Install.RuntimeConfigPath": &i.RuntimeConfigPath,
+		"Install.ResultFile":        &i.ResultFile,
+		"Install.MetricsFile":       &i.MetricsFile,
+		"Install.CACertFile":        &i.CACertFile,
+		"Install.TLS.Cert":          &i.TLS.Cert,
+		"Install.TLS.Key":           &i.TLS.Key,
+		"Install.SSHKey":            &i.SSHKey,
+		"Install.KnownHosts":        &i.KnownHosts,
+		"Install.ReleaseCacheFile":  &i.ReleaseCacheFile,
+		"Install.DB.RpassFile":      &i.DB.RpassFile,
+		"Install.DB.PassFile":       &i.DB.PassFile,
+		"Install.Admin.PassFile":    &i.Admin.PassFile,
+		"Settings.Secret.KeyFile":   &d.Settings.Secret.KeyFile,
+	}
+	for field, p := range paths {
+		abs, err := ExpandPath(*p)
+		if err != nil {
+			return &dojoerr.ConfigError{Field: field, Msg: "unable to expand " + *p + ": " + err.Error()}
+		}
+		*p = abs
+	}
+	return nil
+}
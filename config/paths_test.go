@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandPaths(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skipf("No current user to expand ~ with: %v", err)
+	}
+	wd, _ := os.Getwd()
+	t.Setenv("DOJO_BASE", "/srv/apps")
+
+	d := DojoConfig{}
+	d.Install.Root = "~/dojo"
+	d.Install.VenvPath = "$DOJO_BASE/venv"
+	d.Install.TempDir = "${DOJO_BASE}/tmp/../staging"
+	d.Install.ResultFile = "result.json"
+	d.Install.MetricsFile = "$DOJO_BASE/textfile/godojo.prom"
+	d.Install.TLS.Cert = "~"
+	err = d.ExpandPaths()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := map[string][2]string{
+		"Root":        {d.Install.Root, filepath.Join(u.HomeDir, "dojo")},
+		"VenvPath":    {d.Install.VenvPath, "/srv/apps/venv"},
+		"TempDir":     {d.Install.TempDir, "/srv/apps/staging"},
+		"ResultFile":  {d.Install.ResultFile, filepath.Join(wd, "result.json")},
+		"MetricsFile": {d.Install.MetricsFile, "/srv/apps/textfile/godojo.prom"},
+		"TLS.Cert":    {d.Install.TLS.Cert, u.HomeDir},
+		"TLS.Key":     {d.Install.TLS.Key, ""},
+	}
+	for field, tt := range tests {
+		if tt[0] != tt[1] {
+			t.Errorf("Expecting %s to expand to %q, got %q", field, tt[1], tt[0])
+		}
+	}
+}
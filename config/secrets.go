@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// Secrets can be kept out of dojoConfig.yml either with a *File field holding the path of a file with the secret
+// e.g. Install.DB.PassFile or with a secret://<resolver>/<ref> value handled by one of SecretResolvers
+
+// secretScheme prefixes a config value which names a secret instead of holding it
+const secretScheme = "secret://"
+
+// SecretResolver - returns the secret ref names for a secret://<resolver>/<ref> value
+type SecretResolver func(ref string) (string, error)
+
+// SecretResolvers maps the resolver in a secret:// value to the SecretResolver for it, a secret manager
+// like Vault can be supported by adding its resolver here
+var SecretResolvers = map[string]SecretResolver{
+	// secret://env/DD_DB_PASS reads the ENV variable DD_DB_PASS
+	"env": func(ref string) (string, error) {
+		val, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("the ENV variable %s isn't set", ref)
+		}
+		return val, nil
+	},
+	// secret://file/run/secrets/db_pass reads /run/secrets/db_pass
+	"file": func(ref string) (string, error) {
+		return readSecretFile("/" + ref)
+	},
+}
+
+// ResolveSecrets replaces secrets given with a *File field or a secret:// value with the secret they name
+// Only c is changed so the clear values never make it back to viper or the runtime config written from it
+func ResolveSecrets(d *DojoConfig) error {
+	i := &d.Install
+	files := []struct {
+		field string
+		path  string
+		dst   *string
+	}{
+		{"Install.DB.RpassFile", i.DB.RpassFile, &i.DB.Rpass},
+		{"Install.DB.PassFile", i.DB.PassFile, &i.DB.Pass},
+		{"Install.Admin.PassFile", i.Admin.PassFile, &i.Admin.Pass},
+		{"Settings.Secret.KeyFile", d.Settings.Secret.KeyFile, &d.Settings.Secret.Key},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		val, err := readSecretFile(f.path)
+		if err != nil {
+			return &dojoerr.ConfigError{Field: f.field, Msg: err.Error()}
+		}
+		*f.dst = val
+	}
+
+	secrets := map[string]*string{
+		"Install.DB.Rpass":                          &i.DB.Rpass,
+		"Install.DB.Pass":                           &i.DB.Pass,
+		"Install.OS.Pass":                           &i.OS.Pass,
+		"Install.Admin.Pass":                        &i.Admin.Pass,
+		"Install.GitHubToken":                       &i.GitHubToken,
+		"Install.SSHKeyPass":                        &i.SSHKeyPass,
+		"Settings.Celery.Broker.Password":           &d.Settings.Celery.Broker.Password,
+		"Settings.Database.Password":                &d.Settings.Database.Password,
+		"Settings.Secret.Key":                       &d.Settings.Secret.Key,
+		"Settings.Credential.AES.B256.Key":          &d.Settings.Credential.AES.B256.Key,
+		"Settings.Social.Auth.Google.OAUTH2.Key":    &d.Settings.Social.Auth.Google.OAUTH2.Key,
+		"Settings.Social.Auth.Google.OAUTH2.Secret": &d.Settings.Social.Auth.Google.OAUTH2.Secret,
+		"Settings.Social.Auth.Okta.OAUTH2.Key":      &d.Settings.Social.Auth.Okta.OAUTH2.Key,
+		"Settings.Social.Auth.Okta.OAUTH2.Secret":   &d.Settings.Social.Auth.Okta.OAUTH2.Secret,
+	}
+	for field, s := range secrets {
+		if !strings.HasPrefix(*s, secretScheme) {
+			continue
+		}
+		val, err := resolveSecret(*s)
+		if err != nil {
+			return &dojoerr.ConfigError{Field: field, Msg: err.Error()}
+		}
+		*s = val
+	}
+	return nil
+}
+
+// resolveSecret returns the secret named by a secret://<resolver>/<ref> value
+func resolveSecret(s string) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(s, secretScheme), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("%s should be secret://<resolver>/<ref>", s)
+	}
+	resolve, ok := SecretResolvers[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown secret resolver %s in %s", parts[0], s)
+	}
+	val, err := resolve(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s: %w", s, err)
+	}
+	return val, nil
+}
+
+// readSecretFile returns the contents of the file at p without the trailing newline most editors add
+func readSecretFile(p string) (string, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the secret file: %w", err)
+	}
+	val := strings.TrimRight(string(b), "\r\n")
+	if val == "" {
+		return "", fmt.Errorf("the secret file %s is empty", p)
+	}
+	return val, nil
+}
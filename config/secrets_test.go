@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestResolveSecretsFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	d := DojoConfig{}
+	d.Install.DB.Pass = "ignored"
+	d.Install.DB.PassFile = write("db_pass", "vee0Thoanae1daePooz0ieka\n")
+	d.Install.Admin.PassFile = write("admin_pass", "Ohseek4aiveeM3ai")
+	d.Settings.Secret.Key = "."
+	d.Settings.Secret.KeyFile = write("secret_key", "uu6ahHei3ohquoh1\r\n")
+	if err := ResolveSecrets(&d); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := map[string][2]string{
+		"DB.Pass":    {d.Install.DB.Pass, "vee0Thoanae1daePooz0ieka"},
+		"Admin.Pass": {d.Install.Admin.Pass, "Ohseek4aiveeM3ai"},
+		"Secret.Key": {d.Settings.Secret.Key, "uu6ahHei3ohquoh1"},
+	}
+	for name, tt := range tests {
+		if tt[0] != tt[1] {
+			t.Errorf("%s: expecting %q from its file, got %q", name, tt[1], tt[0])
+		}
+	}
+
+	// A missing or empty file names the field rather than installing with no password
+	for _, p := range []string{filepath.Join(dir, "missing"), write("empty", "\n")} {
+		d := DojoConfig{}
+		d.Install.DB.PassFile = p
+		err := ResolveSecrets(&d)
+		var ce *dojoerr.ConfigError
+		if !errors.As(err, &ce) || ce.Field != "Install.DB.PassFile" {
+			t.Errorf("Expecting a ConfigError for Install.DB.PassFile with %s, got %v", p, err)
+		}
+	}
+}
+
+func TestResolveSecretURIs(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(p, []byte("ghp_Aeph6ooJ\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOJO_TEST_DB_PASS", "vee0Thoanae1daePooz0ieka")
+	d := DojoConfig{}
+	d.Install.DB.Pass = "secret://env/DOJO_TEST_DB_PASS"
+	d.Install.GitHubToken = "secret://file" + p
+	d.Install.Admin.Pass = "Ohseek4aiveeM3ai"
+	if err := ResolveSecrets(&d); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if d.Install.DB.Pass != "vee0Thoanae1daePooz0ieka" || d.Install.GitHubToken != "ghp_Aeph6ooJ" {
+		t.Errorf("Expecting the secrets resolved, got %q and %q", d.Install.DB.Pass, d.Install.GitHubToken)
+	}
+	if d.Install.Admin.Pass != "Ohseek4aiveeM3ai" {
+		t.Errorf("Expecting a plain value to be left alone, got %q", d.Install.Admin.Pass)
+	}
+
+	tests := []string{"secret://env/DOJO_TEST_UNSET", "secret://vault/kv/dojo", "secret://env"}
+	for _, s := range tests {
+		d := DojoConfig{}
+		d.Settings.Secret.Key = s
+		err := ResolveSecrets(&d)
+		var ce *dojoerr.ConfigError
+		if !errors.As(err, &ce) || ce.Field != "Settings.Secret.Key" {
+			t.Errorf("Expecting a ConfigError for Settings.Secret.Key with %s, got %v", s, err)
+		}
+	}
+
+	// Resolvers are pluggable for secret managers
+	SecretResolvers["test"] = func(ref string) (string, error) { return strings.ToUpper(ref), nil }
+	defer delete(SecretResolvers, "test")
+	d = DojoConfig{}
+	d.Install.OS.Pass = "secret://test/wahlie"
+	if err := ResolveSecrets(&d); err != nil || d.Install.OS.Pass != "WAHLIE" {
+		t.Errorf("Expecting the added resolver to be used, got %q, %v", d.Install.OS.Pass, err)
+	}
+}
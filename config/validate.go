@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// Strength - how strong a configured password is
+type Strength int
+
+// Password strengths returned by PasswordStrength
+const (
+	Empty Strength = iota
+	Weak
+	Strong
+)
+
+// minPython matches a major.minor Python version
+var minPython = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+// octalPerms matches a file mode in octal like 750 or 0750
+var octalPerms = regexp.MustCompile(`^0?[0-7]{3}$`)
+
+// hostName matches a DNS host name, a leading . allows any subdomain like Django's ALLOWED_HOSTS
+var hostName = regexp.MustCompile(`^\.?[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*\.?$`)
+
+// plausibleHost returns true if h is a host name, an IP address, or * for any host
+func plausibleHost(h string) bool {
+	if h == "*" {
+		return true
+	}
+	if net.ParseIP(strings.Trim(h, "[]")) != nil {
+		return true
+	}
+	return len(h) <= 253 && hostName.MatchString(h)
+}
+
+// releaseVersion matches a DefectDojo release version like 1.5.3.1 or v2.3.1
+var releaseVersion = regexp.MustCompile(`^\s*[vV]?[0-9]+(\.[0-9]+)*\s*$`)
+
+// githubName matches a GitHub user, organization or repository name, leaving out anything that changes the URL path
+var githubName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// osPackage matches an OS package name like libssl-dev or g++, optionally prefixed with - to drop a built-in one
+var osPackage = regexp.MustCompile(`^-?[A-Za-z0-9][A-Za-z0-9+._:~-]*$`)
+
+// fixture matches a Django fixture name or path for loaddata, which mustn't start with - and be taken for an option
+var fixture = regexp.MustCompile(`^[A-Za-z0-9_./][A-Za-z0-9_./-]*$`)
+
+// httpURL returns true if u is an absolute http or https URL
+func httpURL(u string) bool {
+	p, err := url.Parse(u)
+	return err == nil && (p.Scheme == "http" || p.Scheme == "https") && p.Host != ""
+}
+
+// scpURL matches the user@host:path form of SSH URLs git accepts
+var scpURL = regexp.MustCompile(`^[A-Za-z0-9._-]+@[^/:]+:`)
+
+// IsSSHURL returns true if u is a git SSH URL like git@git.example.com:dojo/django-DefectDojo.git or ssh://git@git.example.com/dojo.git
+func IsSSHURL(u string) bool {
+	return strings.HasPrefix(u, "ssh://") || scpURL.MatchString(u)
+}
+
+// singleComponent returns true if name is one path component, not empty, . or .. and without separators
+func singleComponent(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\`) && filepath.Clean(name) == name
+}
+
+// minPassLen is the shortest password not considered weak
+const minPassLen = 12
+
+// commonPasswords are passwords weak no matter their length e.g. defaults from docs and examples
+var commonPasswords = []string{
+	"admin", "password", "changeme", "defectdojo", "dojo", "secret",
+	"123456", "12345678", "qwerty", "letmein", "root", "toor",
+}
+
+// PasswordStrength classifies p as empty, weak or strong
+// Weak passwords are short, common, or use only one kind of character e.g. all lower case letters
+func PasswordStrength(p string) Strength {
+	if p == "" {
+		return Empty
+	}
+	for _, c := range commonPasswords {
+		if strings.EqualFold(p, c) {
+			return Weak
+		}
+	}
+	if len(p) < minPassLen {
+		return Weak
+	}
+	var lower, upper, digit, other bool
+	for _, r := range p {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	kinds := 0
+	for _, k := range []bool{lower, upper, digit, other} {
+		if k {
+			kinds++
+		}
+	}
+	if kinds < 2 {
+		return Weak
+	}
+	return Strong
+}
+
+// Validate checks the config before an install starts returning warnings for risky but workable
+// values and a dojoerr.ConfigErrors holding every value the install can't continue with
+func (d *DojoConfig) Validate() ([]string, error) {
+	warns := []string{}
+	errs := dojoerr.ConfigErrors{}
+	i := &d.Install
+
+	// SQLite has no DB password, every other engine needs one
+	warns, errs = checkPassword("Install.DB.Pass", i.DB.Pass, i.DB.Engine != "SQLite", i.AllowWeakPasswords, warns, errs)
+	warns, errs = checkPassword("Install.Admin.Pass", i.Admin.Pass, true, i.AllowWeakPasswords, warns, errs)
+
+	// settings.py's DD_DATABASE_URL is built from the DB fields, catch what it can't be built from before installing
+	if _, err := DatabaseURL(i); err != nil {
+		var cErr *dojoerr.ConfigError
+		if errors.As(err, &cErr) {
+			errs = append(errs, cErr)
+		}
+	}
+
+	if i.MinPython != "" && !minPython.MatchString(i.MinPython) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.MinPython", Msg: "must be a major.minor version like 3.6"})
+	}
+
+	for _, h := range i.AllowedHosts {
+		if !plausibleHost(h) {
+			errs = append(errs, &dojoerr.ConfigError{Field: "Install.AllowedHosts", Msg: h + " isn't a valid host name or IP address"})
+		}
+	}
+
+	if i.InsecureSkipVerify {
+		warns = append(warns, "Install.InsecureSkipVerify is set, TLS certificates of downloads AREN'T verified so they can be tampered with - never use this in production, set CACertFile for a proxy's CA instead")
+	}
+
+	if IsSSHURL(i.CloneURL) {
+		if i.SSHKey == "" {
+			errs = append(errs, &dojoerr.ConfigError{Field: "Install.SSHKey", Msg: "a private key is required to clone the SSH CloneURL " + i.CloneURL})
+		}
+		if i.SSHInsecureHostKey {
+			warns = append(warns, "Install.SSHInsecureHostKey is set, the host key of "+i.CloneURL+" ISN'T checked so the source can be tampered with - never use this in production, add the host to KnownHosts instead")
+		}
+	}
+
+	// Source is joined to Root all over the install, a path here could put the source outside Root
+	if !singleComponent(i.Source) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.Source", Msg: "must be a directory name like django-DefectDojo without / or .."})
+	}
+
+	if i.Umask != "" && !octalPerms.MatchString(i.Umask) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.Umask", Msg: "must be an octal umask like 0027"})
+	}
+
+	if i.SourcePerms != "" && !octalPerms.MatchString(i.SourcePerms) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.SourcePerms", Msg: "must be an octal mode like 0750"})
+	}
+
+	if _, err := path.Match(i.AssetPattern, ""); err != nil {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.AssetPattern", Msg: "isn't a valid glob: " + err.Error()})
+	}
+
+	if i.Telemetry && !httpURL(i.TelemetryURL) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.TelemetryURL", Msg: "must be an http or https URL when Telemetry is on"})
+	}
+	for field, u := range map[string]string{"Install.ChecksumURL": i.ChecksumURL, "Install.SignatureURL": i.SignatureURL} {
+		if u != "" && !httpURL(u) {
+			errs = append(errs, &dojoerr.ConfigError{Field: field, Msg: u + " isn't an http or https URL"})
+		}
+	}
+	for _, m := range i.ReleaseMirrors {
+		if !httpURL(m) {
+			errs = append(errs, &dojoerr.ConfigError{Field: "Install.ReleaseMirrors", Msg: m + " isn't an http or https URL"})
+		}
+	}
+	if i.RepoOwner != "" && !githubName.MatchString(i.RepoOwner) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.RepoOwner", Msg: i.RepoOwner + " isn't a GitHub user or organization name"})
+	}
+	if i.RepoName != "" && (!githubName.MatchString(i.RepoName) || i.RepoName == "." || i.RepoName == "..") {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.RepoName", Msg: i.RepoName + " isn't a GitHub repository name"})
+	}
+	if i.CloneURL != "" && !IsSSHURL(i.CloneURL) && !httpURL(i.CloneURL) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.CloneURL", Msg: i.CloneURL + " isn't an http, https or SSH git URL"})
+	}
+
+	distros := make([]string, 0, len(i.OSPackages))
+	for d := range i.OSPackages {
+		distros = append(distros, d)
+	}
+	sort.Strings(distros)
+	for _, d := range distros {
+		for _, p := range i.OSPackages[d] {
+			if !osPackage.MatchString(p) {
+				errs = append(errs, &dojoerr.ConfigError{Field: "Install.OSPackages." + d, Msg: "\"" + p + "\" isn't an OS package name"})
+			}
+		}
+	}
+	for _, f := range i.LoadFixtures {
+		if !fixture.MatchString(f) {
+			errs = append(errs, &dojoerr.ConfigError{Field: "Install.LoadFixtures", Msg: "\"" + f + "\" isn't a Django fixture name"})
+		}
+	}
+	if i.MinFreeInodes < 0 {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.MinFreeInodes", Msg: "must be 0 or more, not " + strconv.Itoa(i.MinFreeInodes)})
+	}
+	if i.SourcePR < 0 {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.SourcePR", Msg: "must be a pull request number, not " + strconv.Itoa(i.SourcePR)})
+	}
+	if i.HealthTimeout > 0 && i.HealthInterval <= 0 {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.HealthInterval", Msg: "must be more than 0 while HealthTimeout is set, not " + i.HealthInterval.String()})
+	}
+	warns, errs = checkInstallMode(i, warns, errs)
+
+	// An empty Version is reported by the check for values left at their defaults
+	if !i.SourceInstall && i.Version != "" && !releaseVersion.MatchString(i.Version) {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.Version", Msg: i.Version + " isn't a release version like 1.5.3.1"})
+	}
+
+	switch i.ArchiveType {
+	case "", "tar.gz", "zip":
+	default:
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.ArchiveType", Msg: "must be tar.gz or zip, not " + i.ArchiveType})
+	}
+
+	switch i.Color {
+	case "", "auto", "always", "never":
+	default:
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.Color", Msg: "must be auto, always or never, not " + i.Color})
+	}
+	switch i.Output {
+	case "", "quiet", "progress", "verbose":
+	default:
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.Output", Msg: "must be quiet, progress or verbose, not " + i.Output})
+	}
+	if i.Quiet && i.Output != "" && i.Output != "quiet" {
+		warns = append(warns, "Install.Quiet is ignored as Install.Output is set to "+i.Output)
+	}
+
+	switch i.ExistingSource {
+	case "", "error", "overwrite", "backup":
+	default:
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.ExistingSource", Msg: "must be error, overwrite or backup, not " + i.ExistingSource})
+	}
+
+	errs = checkAppServer(&i.AppServer, errs)
+
+	if len(errs) > 0 {
+		return warns, errs
+	}
+	return warns, nil
+}
+
+// checkPassword adds a warning or error for the password in field
+// The password itself is never included so it can't end up in the logs
+func checkPassword(field string, p string, required bool, allowWeak bool, warns []string, errs dojoerr.ConfigErrors) ([]string, dojoerr.ConfigErrors) {
+	switch PasswordStrength(p) {
+	case Empty:
+		if !required {
+			return warns, errs
+		}
+		if allowWeak {
+			return append(warns, field+" is empty, allowed by AllowWeakPasswords"), errs
+		}
+		return warns, append(errs, &dojoerr.ConfigError{Field: field, Msg: "a password is required"})
+	case Weak:
+		if allowWeak {
+			return warns, errs
+		}
+		return append(warns, field+" is weak - use at least 12 characters mixing letters, digits and symbols"), errs
+	}
+	return warns, errs
+}
+
+// checkInstallMode adds a warning for each value set which the install ignores as it's only used by the other of
+// a release or source install, and an error if a source install has nothing to check out
+func checkInstallMode(i *InstallConfig, warns []string, errs dojoerr.ConfigErrors) ([]string, dojoerr.ConfigErrors) {
+	if i.SourceInstall {
+		if i.SourcePR == 0 && i.SourceCommit == "" && i.SourceBranch == "" {
+			errs = append(errs, &dojoerr.ConfigError{Field: "Install.SourceBranch", Msg: "a source install needs a SourceBranch, SourceCommit or SourcePR to check out"})
+		}
+		if i.SourcePR > 0 && i.SourceCommit != "" {
+			warns = append(warns, "Install.SourceCommit is ignored as Install.SourcePR is set, pull request "+strconv.Itoa(i.SourcePR)+" will be installed")
+		}
+		ignored := []string{}
+		for field, set := range map[string]bool{
+			"Install.Version":        i.Version != "",
+			"Install.AssetPattern":   i.AssetPattern != "",
+			"Install.ArchiveType":    i.ArchiveType != "",
+			"Install.ChecksumURL":    i.ChecksumURL != "",
+			"Install.SignatureURL":   i.SignatureURL != "",
+			"Install.ReleaseMirrors": len(i.ReleaseMirrors) > 0,
+			"Install.HashSource":     i.HashSource,
+		} {
+			if set {
+				ignored = append(ignored, field)
+			}
+		}
+		return ignoredWarning(ignored, "Install.SourceInstall is true", warns), errs
+	}
+
+	ignored := []string{}
+	for field, set := range map[string]bool{
+		"Install.SourceBranch": i.SourceBranch != "",
+		"Install.SourceCommit": i.SourceCommit != "",
+		"Install.SourcePR":     i.SourcePR > 0,
+		"Install.CloneURL":     i.CloneURL != "",
+	} {
+		if set {
+			ignored = append(ignored, field)
+		}
+	}
+	return ignoredWarning(ignored, "Install.SourceInstall is false", warns), errs
+}
+
+// ignoredWarning adds one warning naming the ignored fields, sorted so it reads the same every run
+func ignoredWarning(ignored []string, why string, warns []string) []string {
+	if len(ignored) == 0 {
+		return warns
+	}
+	sort.Strings(ignored)
+	return append(warns, "Ignoring "+strings.Join(ignored, ", ")+" as "+why+" - check for a copy-paste mistake")
+}
+
+// checkAppServer adds an error for each AppServer value the app server can't be started with
+func checkAppServer(a *AppServerTarget, errs dojoerr.ConfigErrors) dojoerr.ConfigErrors {
+	switch a.Type {
+	case "", "uwsgi", "gunicorn":
+	default:
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.AppServer.Type", Msg: "must be uwsgi or gunicorn, not " + a.Type})
+	}
+	if a.Workers < 0 {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.AppServer.Workers", Msg: "can't be negative, use 0 for the default"})
+	}
+	if a.Bind == "" || strings.HasPrefix(a.Bind, "/") {
+		return errs
+	}
+	host, port, err := net.SplitHostPort(a.Bind)
+	if err == nil {
+		var n int
+		n, err = strconv.Atoi(port)
+		if err == nil && (n < 1 || n > 65535 || (host != "" && !plausibleHost(host))) {
+			err = strconv.ErrRange
+		}
+	}
+	if err != nil {
+		errs = append(errs, &dojoerr.ConfigError{Field: "Install.AppServer.Bind", Msg: a.Bind + " must be host:port or the path of a unix socket starting with /"})
+	}
+	return errs
+}
@@ -0,0 +1,491 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestPasswordStrength(t *testing.T) {
+	tests := map[string]Strength{
+		"":                         Empty,
+		"admin":                    Weak,
+		"DefectDojo":               Weak,
+		"Sh0rt!":                   Weak,
+		"alllowercaseletters":      Weak,
+		"vee0Thoanae1daePooz0ieka": Strong,
+		"correct horse battery":    Strong,
+	}
+	for p, want := range tests {
+		if got := PasswordStrength(p); got != want {
+			t.Errorf("PasswordStrength(%q) = %d, expecting %d", p, got, want)
+		}
+	}
+}
+
+func TestValidatePasswords(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "MySQL"
+	d.Install.DB.Name = "dojodb"
+	d.Install.DB.User = "dojo"
+	d.Install.Admin.Pass = "admin"
+	warns, err := d.Validate()
+
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.DB.Pass" {
+		t.Errorf("Expecting an error for the empty DB password, got %v", err)
+	}
+	if len(warns) != 1 || !strings.Contains(warns[0], "Install.Admin.Pass") {
+		t.Errorf("Expecting a weak admin password warning, got %q", warns)
+	}
+	if strings.Contains(warns[0], "admin") {
+		t.Errorf("Expecting the password to be kept out of warnings, got %q", warns)
+	}
+
+	// SQLite doesn't need a DB password
+	d.Install.DB.Engine = "SQLite"
+	_, err = d.Validate()
+	if err != nil {
+		t.Errorf("Expecting no error for SQLite without a DB password, got %v", err)
+	}
+
+	// The escape hatch turns errors into warnings and silences weak warnings
+	d.Install.DB.Engine = "MySQL"
+	d.Install.AllowWeakPasswords = true
+	warns, err = d.Validate()
+	if err != nil || len(warns) != 1 {
+		t.Errorf("Expecting only the empty DB password warning with AllowWeakPasswords, got %q, %v", warns, err)
+	}
+}
+
+func TestValidateDatabaseURL(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "PostgreSQL"
+	d.Install.DB.Name = "dojodb"
+	d.Install.DB.Pass = "Correct-Horse-42"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.DB.User" {
+		t.Errorf("Expecting an error for the missing DB user, got %v", err)
+	}
+	d.Install.DB.User = "dojo"
+	if _, err = d.Validate(); err != nil {
+		t.Errorf("Expecting the DB fields to be valid, got %v", err)
+	}
+}
+
+func TestValidateMinPython(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	for _, v := range []string{"", "3.6", "3.10"} {
+		d.Install.MinPython = v
+		if _, err := d.Validate(); err != nil {
+			t.Errorf("Expecting MinPython %q to be valid, got %v", v, err)
+		}
+	}
+	d.Install.MinPython = "three"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.MinPython" {
+		t.Errorf("Expecting an error for MinPython three, got %v", err)
+	}
+}
+
+func TestValidateAllowedHosts(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.AllowedHosts = []string{"localhost", "127.0.0.1", "::1", "[::1]", "dojo.example.com", ".example.com", "*"}
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting valid hosts to pass, got %v", err)
+	}
+	for _, bad := range []string{"http://dojo.example.com", "dojo example", "dojo.example.com:8080", "-dojo.com", "dojo..com", ""} {
+		d.Install.AllowedHosts = []string{bad}
+		_, err := d.Validate()
+		var cErr *dojoerr.ConfigError
+		if !errors.As(err, &cErr) || cErr.Field != "Install.AllowedHosts" {
+			t.Errorf("Expecting %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestValidateAppServer(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	for _, ok := range []string{"", "127.0.0.1:8000", ":8000", "dojo.example.com:8000", "[::1]:8000", "/run/dojo/app.sock"} {
+		d.Install.AppServer.Bind = ok
+		if _, err := d.Validate(); err != nil {
+			t.Errorf("Expecting bind %q to pass, got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"8000", "localhost", "localhost:0", "localhost:70000", "dojo example:80", "unix:/run/app.sock"} {
+		d.Install.AppServer.Bind = bad
+		_, err := d.Validate()
+		var cErr *dojoerr.ConfigError
+		if !errors.As(err, &cErr) || cErr.Field != "Install.AppServer.Bind" {
+			t.Errorf("Expecting bind %q to be rejected, got %v", bad, err)
+		}
+	}
+	d.Install.AppServer.Bind = ""
+	d.Install.AppServer.Type = "mod_wsgi"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.AppServer.Type" {
+		t.Errorf("Expecting an error for Type mod_wsgi, got %v", err)
+	}
+}
+
+func TestValidateExistingSource(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.ExistingSource = "backup"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting backup to pass, got %v", err)
+	}
+	d.Install.ExistingSource = "merge"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.ExistingSource" {
+		t.Errorf("Expecting an error for ExistingSource merge, got %v", err)
+	}
+}
+
+func TestValidateSourcePerms(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	for _, ok := range []string{"", "750", "0750"} {
+		d.Install.SourcePerms = ok
+		if _, err := d.Validate(); err != nil {
+			t.Errorf("Expecting SourcePerms %q to pass, got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"rwxr-x---", "0790", "75", "00750"} {
+		d.Install.SourcePerms = bad
+		_, err := d.Validate()
+		var cErr *dojoerr.ConfigError
+		if !errors.As(err, &cErr) || cErr.Field != "Install.SourcePerms" {
+			t.Errorf("Expecting SourcePerms %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.Source = "django-DefectDojo"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting django-DefectDojo to pass, got %v", err)
+	}
+	for _, bad := range []string{"../evil", "a/b", "/opt/dojo", "..", ".", "", `a\b`, "dojo/"} {
+		d.Install.Source = bad
+		_, err := d.Validate()
+		var cErr *dojoerr.ConfigError
+		if !errors.As(err, &cErr) || cErr.Field != "Install.Source" {
+			t.Errorf("Expecting Source %q to be rejected, got %v", bad, err)
+		}
+	}
+}
+
+func TestValidateAssetPattern(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.AssetPattern = "defectdojo-*.tar.gz"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting a glob to pass, got %v", err)
+	}
+	d.Install.AssetPattern = "defectdojo-[.tar.gz"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.AssetPattern" {
+		t.Errorf("Expecting an error for a malformed glob, got %v", err)
+	}
+}
+
+func TestValidateArchiveType(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.ArchiveType = "zip"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting ArchiveType zip to pass, got %v", err)
+	}
+	d.Install.ArchiveType = "rar"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.ArchiveType" {
+		t.Errorf("Expecting an error for ArchiveType rar, got %v", err)
+	}
+}
+
+func TestValidateUmask(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.Umask = "0027"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting Umask 0027 to pass, got %v", err)
+	}
+	d.Install.Umask = "0028"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.Umask" {
+		t.Errorf("Expecting Umask 0028 to be rejected, got %v", err)
+	}
+}
+
+func TestValidateTelemetryURL(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.TelemetryURL = "not a url"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting TelemetryURL to be ignored with Telemetry off, got %v", err)
+	}
+	d.Install.Telemetry = true
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.TelemetryURL" {
+		t.Errorf("Expecting an error for Telemetry without a URL, got %v", err)
+	}
+	d.Install.TelemetryURL = "https://telemetry.example.com/godojo"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting an https TelemetryURL to pass, got %v", err)
+	}
+}
+
+func TestValidateSSHCloneURL(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.SourceInstall = true
+	d.Install.SourceBranch = "dev"
+	d.Install.CloneURL = "git@git.example.com:dojo/django-DefectDojo.git"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.SSHKey" {
+		t.Errorf("Expecting an error for an SSH CloneURL without SSHKey, got %v", err)
+	}
+	d.Install.SSHKey = "/root/.ssh/id_ed25519"
+	d.Install.SSHInsecureHostKey = true
+	warns, err := d.Validate()
+	if err != nil || len(warns) != 1 {
+		t.Errorf("Expecting a warning for SSHInsecureHostKey, got %v, %v", warns, err)
+	}
+	d.Install.CloneURL = "https://git.example.com/dojo/django-DefectDojo.git"
+	d.Install.SSHKey = ""
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting an https CloneURL to need no key, got %v", err)
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   func(i *InstallConfig)
+		field string
+	}{
+		{"version", func(i *InstallConfig) { i.Version = "latest" }, "Install.Version"},
+		{"checksum URL", func(i *InstallConfig) { i.ChecksumURL = "mirror.example.com/1.5.3.1.sha256" }, "Install.ChecksumURL"},
+		{"signature URL", func(i *InstallConfig) { i.SignatureURL = "ftp://mirror.example.com/1.5.3.1.asc" }, "Install.SignatureURL"},
+		{"mirror", func(i *InstallConfig) { i.ReleaseMirrors = []string{"https://mirror.example.com/", "/srv/mirror"} }, "Install.ReleaseMirrors"},
+		{"clone URL", func(i *InstallConfig) { i.CloneURL = "git.example.com/dojo.git" }, "Install.CloneURL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DojoConfig{}
+			d.Install.Source = "django-DefectDojo"
+			d.Install.DB.Engine = "SQLite"
+			d.Install.Admin.Pass = "Correct-Horse-42"
+			d.Install.Version = "v2.3.1"
+			if _, err := d.Validate(); err != nil {
+				t.Fatalf("Expecting the base config to pass, got %v", err)
+			}
+			tt.set(&d.Install)
+			_, err := d.Validate()
+			var cErr *dojoerr.ConfigError
+			if !errors.As(err, &cErr) || cErr.Field != tt.field {
+				t.Errorf("Expecting an error for %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestValidateSourcePR(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.SourceInstall = true
+	d.Install.SourcePR = -1
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.SourcePR" {
+		t.Errorf("Expecting an error for a negative SourcePR, got %v", err)
+	}
+	d.Install.SourcePR = 1234
+	d.Install.SourceCommit = "22294ab6c69468057bce79386768869b2788de5d"
+	warns, err := d.Validate()
+	if err != nil || len(warns) != 1 {
+		t.Errorf("Expecting a warning that SourceCommit is ignored, got %v, %v", warns, err)
+	}
+}
+
+func TestValidateInstallMode(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(i *InstallConfig)
+		want string
+	}{
+		{"clean release", func(i *InstallConfig) { i.Version = "1.5.3.1" }, ""},
+		{"clean source", func(i *InstallConfig) { i.SourceInstall = true; i.SourceBranch = "dev" }, ""},
+		{"version", func(i *InstallConfig) { i.SourceInstall = true; i.SourceBranch = "dev"; i.Version = "1.5.3.1" },
+			"Ignoring Install.Version as Install.SourceInstall is true"},
+		{"release settings", func(i *InstallConfig) {
+			i.SourceInstall = true
+			i.SourceBranch = "dev"
+			i.AssetPattern = "defectdojo-*.tar.gz"
+			i.ArchiveType = "zip"
+			i.ChecksumURL = "https://mirror.example.com/1.5.3.1.sha256"
+			i.SignatureURL = "https://mirror.example.com/1.5.3.1.asc"
+			i.ReleaseMirrors = []string{"https://mirror.example.com/"}
+			i.HashSource = true
+		}, "Ignoring Install.ArchiveType, Install.AssetPattern, Install.ChecksumURL, Install.HashSource, Install.ReleaseMirrors, Install.SignatureURL as Install.SourceInstall is true"},
+		{"branch", func(i *InstallConfig) { i.Version = "1.5.3.1"; i.SourceBranch = "dev" },
+			"Ignoring Install.SourceBranch as Install.SourceInstall is false"},
+		{"source settings", func(i *InstallConfig) {
+			i.Version = "1.5.3.1"
+			i.SourceCommit = "22294ab6c69468057bce79386768869b2788de5d"
+			i.SourcePR = 1234
+			i.CloneURL = "https://git.example.com/dojo.git"
+		}, "Ignoring Install.CloneURL, Install.SourceCommit, Install.SourcePR as Install.SourceInstall is false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DojoConfig{}
+			d.Install.Source = "django-DefectDojo"
+			d.Install.DB.Engine = "SQLite"
+			d.Install.Admin.Pass = "Correct-Horse-42"
+			tt.set(&d.Install)
+			warns, err := d.Validate()
+			if err != nil {
+				t.Fatalf("Expecting only a warning, got %v", err)
+			}
+			if tt.want == "" && len(warns) != 0 {
+				t.Errorf("Expecting no warnings for a clean config, got %v", warns)
+			}
+			if tt.want != "" && (len(warns) != 1 || !strings.HasPrefix(warns[0], tt.want)) {
+				t.Errorf("Expecting the warning %q, got %v", tt.want, warns)
+			}
+		})
+	}
+}
+
+func TestValidateSourceWithoutRef(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.SourceInstall = true
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.SourceBranch" {
+		t.Errorf("Expecting an error for a source install without a branch, commit or pull request, got %v", err)
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.Output = "progress"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting progress output to be valid, got %v", err)
+	}
+	d.Install.Quiet = true
+	warns, _ := d.Validate()
+	if !strings.Contains(strings.Join(warns, "\n"), "Install.Quiet is ignored") {
+		t.Errorf("Expecting a warning that Quiet is ignored, got %q", warns)
+	}
+	d.Install.Output = "silent"
+	_, err := d.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Install.Output") {
+		t.Errorf("Expecting an error for Output silent, got %v", err)
+	}
+	if mode := (&InstallConfig{Quiet: true}).OutputMode(); mode != "quiet" {
+		t.Errorf("Expecting Quiet to map to quiet output, got %s", mode)
+	}
+}
+
+func TestValidateRepo(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.RepoOwner = "acme"
+	d.Install.RepoName = "django-DefectDojo.fork"
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting a fork's owner and name to be valid, got %v", err)
+	}
+	d.Install.RepoName = "../other"
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.RepoName" {
+		t.Errorf("Expecting an error for a RepoName that's a path, got %v", err)
+	}
+}
+
+func TestValidateOSPackages(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.OSPackages = PackageLists{"ubuntu": {"libxml2-dev", "g++", "-expect"}, "default": {"python3.8"}}
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting the package names to be valid, got %v", err)
+	}
+	d.Install.OSPackages["ubuntu"] = append(d.Install.OSPackages["ubuntu"], "gcc; rm -rf /")
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.OSPackages.ubuntu" {
+		t.Errorf("Expecting an error for a malformed package name, got %v", err)
+	}
+}
+
+func TestValidateLoadFixtures(t *testing.T) {
+	d := DojoConfig{}
+	d.Install.Source = "django-DefectDojo"
+	d.Install.DB.Engine = "SQLite"
+	d.Install.Admin.Pass = "Correct-Horse-42"
+	d.Install.LoadFixtures = []string{"initial_surveys", "dojo/fixtures/extra.json"}
+	if _, err := d.Validate(); err != nil {
+		t.Errorf("Expecting the fixtures to be valid, got %v", err)
+	}
+	d.Install.LoadFixtures = append(d.Install.LoadFixtures, "--database=other")
+	_, err := d.Validate()
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.LoadFixtures" {
+		t.Errorf("Expecting an error for a fixture that looks like an option, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mtesauro/godojo/dojoerr"
+	"github.com/spf13/viper"
+)
+
+// Handles merging a config fetched from a URL over the local config file
+
+// configURLTimeout is the longest fetching the config from --config-url may take
+const configURLTimeout = time.Minute
+
+// urlConfig holds the values read from --config-url so 'godojo config --show-sources' can report them
+var urlConfig *viper.Viper
+
+// mergeConfigURL fetches the config at cfgURL and merges it over the config already read into v
+func mergeConfigURL(ctx context.Context, c httpDoer, v *viper.Viper, cfgURL string, auth string) error {
+	body, cfgType, err := fetchConfigURL(ctx, c, cfgURL, auth)
+	if err != nil {
+		return fmt.Errorf("Unable to fetch the godojo config from %s: %w", cfgURL, err)
+	}
+
+	// Parse on its own first so bad content gets a clear error and the source of each value is known
+	remote := viper.New()
+	remote.SetConfigType(cfgType)
+	err = remote.ReadConfig(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Unable to parse the godojo config from %s as %s: %w", cfgURL, cfgType, err)
+	}
+	urlConfig = remote
+
+	v.SetConfigType(cfgType)
+	if v.ConfigFileUsed() == "" {
+		err = v.ReadConfig(bytes.NewReader(body))
+	} else {
+		err = v.MergeConfig(bytes.NewReader(body))
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to merge the godojo config from %s: %w", cfgURL, err)
+	}
+	return nil
+}
+
+// fetchConfigURL downloads the config at cfgURL returning its body and format
+// The format comes from the URL's extension, then the Content-Type, and defaults to yaml
+func fetchConfigURL(ctx context.Context, c httpDoer, cfgURL string, auth string) ([]byte, string, error) {
+	req, err := newRequest(ctx, http.MethodGet, cfgURL)
+	if err != nil {
+		return nil, "", &dojoerr.DownloadError{URL: cfgURL, Err: err}
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, "", &dojoerr.DownloadError{URL: cfgURL, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", &dojoerr.DownloadError{URL: cfgURL, StatusCode: resp.StatusCode}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", &dojoerr.DownloadError{URL: cfgURL, StatusCode: resp.StatusCode, Err: err}
+	}
+	return body, configURLType(cfgURL, resp.Header.Get("Content-Type")), nil
+}
+
+// configURLType picks the config format for a config URL from its extension or content type
+func configURLType(cfgURL string, contentType string) string {
+	if u, err := url.Parse(cfgURL); err == nil {
+		ext := strings.TrimPrefix(path.Ext(u.Path), ".")
+		if contains(configFormats, ext) {
+			return ext
+		}
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mt, "json"):
+		return "json"
+	case strings.HasSuffix(mt, "toml"):
+		return "toml"
+	}
+	return "yaml"
+}
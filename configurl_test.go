@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	"github.com/spf13/viper"
+)
+
+func TestLoadConfigURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fleet" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Install": {"Version": "1.6.0", "DB": {"Name": "fleetdb"}}}`))
+	}))
+	defer srv.Close()
+
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		fs := installFlags()
+		fs.Parse([]string{"--config-url", srv.URL + "/dojo", "--config-url-auth", "Bearer fleet"})
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), fs, &c)
+		if err != nil {
+			t.Fatalf("Unable to load config from URL: %v", err)
+		}
+		if c.Install.Version != "1.6.0" || c.Install.DB.Name != "fleetdb" {
+			t.Errorf("Expecting the URL config to override the file, got %+v", c.Install)
+		}
+		// Values only in the file are kept
+		if c.Install.DB.User != "dojodbusr" {
+			t.Errorf("Expecting file values to be kept, got DB user %q", c.Install.DB.User)
+		}
+	})
+}
+
+func TestLoadConfigURLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.yml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("Install: [unclosed"))
+	}))
+	defer srv.Close()
+
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		fs := installFlags()
+		fs.Parse([]string{"--config-url", srv.URL + "/missing.yml"})
+		err := loadConfig(viper.New(), fs, &config.DojoConfig{})
+		var de *dojoerr.DownloadError
+		if !errors.As(err, &de) || de.StatusCode != http.StatusNotFound {
+			t.Errorf("Expecting a 404 DownloadError, got %v", err)
+		}
+
+		fs = installFlags()
+		fs.Parse([]string{"--config-url", srv.URL + "/bad.yml"})
+		err = loadConfig(viper.New(), fs, &config.DojoConfig{})
+		if err == nil || !strings.Contains(err.Error(), "Unable to parse") {
+			t.Errorf("Expecting a parse error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfigURLUsesCACertFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Install:\n  Version: \"1.6.0\"\n"))
+	}))
+	defer srv.Close()
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, ca, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+	body := "Install:\n  Version: \"1.5.3.1\"\n  CACertFile: \"" + ca + "\"\n"
+
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		fs := installFlags()
+		fs.Parse([]string{"--config-url", srv.URL + "/dojo.yml"})
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), fs, &c)
+		if err != nil || c.Install.Version != "1.6.0" {
+			t.Errorf("Expecting the config fetched trusting CACertFile, got %v, version %q", err, c.Install.Version)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles detecting when godojo is running inside a container
+
+// cgroupHints are substrings of /proc/1/cgroup which show PID 1 is in a container
+var cgroupHints = []string{"docker", "kubepods", "containerd", "lxc", "libpod"}
+
+// InContainer returns true if godojo appears to be running inside a container
+func InContainer() bool {
+	return inContainer("/", os.Getenv)
+}
+
+// inContainer checks for container marker files under root and the container ENV variable
+func inContainer(root string, getenv func(string) string) bool {
+	// Set by systemd-nspawn, podman and others
+	if getenv("container") != "" {
+		return true
+	}
+	// Docker and podman marker files
+	for _, f := range []string{".dockerenv", "run/.containerenv"} {
+		_, err := os.Stat(filepath.Join(root, f))
+		if err == nil {
+			return true
+		}
+	}
+	cg, err := ioutil.ReadFile(filepath.Join(root, "proc", "1", "cgroup"))
+	if err != nil {
+		return false
+	}
+	for _, h := range cgroupHints {
+		if strings.Contains(string(cg), h) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerMode returns if container mode is on, either forced by config or detected
+func containerMode(i *config.InstallConfig) bool {
+	on, err := strconv.ParseBool(i.Container)
+	if err != nil {
+		// "auto" or anything else falls back to detection
+		return InContainer()
+	}
+	return on
+}
+
+// serviceTools returns names, the programs used to manage services, or nothing in container mode where they're skipped
+func serviceTools(names ...string) []string {
+	if ContainerMode {
+		return nil
+	}
+	return names
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// noEnv is a getenv that never finds a variable
+func noEnv(string) string { return "" }
+
+func TestInContainer(t *testing.T) {
+	// Nothing present
+	root := t.TempDir()
+	if inContainer(root, noEnv) {
+		t.Errorf("Expecting no container for an empty root")
+	}
+
+	// Host cgroup file
+	os.MkdirAll(filepath.Join(root, "proc", "1"), 0755)
+	cg := filepath.Join(root, "proc", "1", "cgroup")
+	ioutil.WriteFile(cg, []byte("12:cpuset:/\n1:name=systemd:/init.scope\n"), 0644)
+	if inContainer(root, noEnv) {
+		t.Errorf("Expecting no container for a host cgroup file")
+	}
+
+	// Docker cgroup file
+	ioutil.WriteFile(cg, []byte("12:cpuset:/docker/4f1b2c\n1:name=systemd:/docker/4f1b2c\n"), 0644)
+	if !inContainer(root, noEnv) {
+		t.Errorf("Expecting a container for a docker cgroup file")
+	}
+
+	// .dockerenv marker
+	root = t.TempDir()
+	ioutil.WriteFile(filepath.Join(root, ".dockerenv"), nil, 0644)
+	if !inContainer(root, noEnv) {
+		t.Errorf("Expecting a container when /.dockerenv exists")
+	}
+
+	// container ENV variable
+	env := func(k string) string {
+		if k == "container" {
+			return "podman"
+		}
+		return ""
+	}
+	if !inContainer(t.TempDir(), env) {
+		t.Errorf("Expecting a container when the container ENV variable is set")
+	}
+}
+
+func TestContainerModeForced(t *testing.T) {
+	if !containerMode(&config.InstallConfig{Container: "true"}) {
+		t.Errorf("Expecting container mode when forced on")
+	}
+	if containerMode(&config.InstallConfig{Container: "false"}) {
+		t.Errorf("Expecting no container mode when forced off")
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 	"github.com/mtesauro/godojo/config"
 )
@@ -132,14 +133,13 @@ func prepMariaDB(dbTar *config.DBTarget, os string) error {
 	// https://github.com/go-sql-driver/mysql/#dsn-data-source-name
 	// [username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]
 	conn := dbTar.User + ":" + dbTar.Pass + "@" + dbTar.Host + ":" + strconv.Itoa(dbTar.Port)
-	fmt.Println("DB conn is ", conn)
 	dbMySQL, err := sql.Open("mysql", conn)
 	if err != nil {
 		return err
 	}
-	fmt.Println(dbMySQL)
+	traceMsg("Opened a MariaDB connection to " + dbTar.Host + ":" + strconv.Itoa(dbTar.Port) + " as " + dbTar.User)
 
-	return nil
+	return dbMySQL.Close()
 }
 
 func prepMySQL(dbTar *config.DBTarget, os string) error {
@@ -164,8 +164,7 @@ func prepMySQL(dbTar *config.DBTarget, os string) error {
 	// User the connction string above to open a DB connection
 	dbMySQL, err := sql.Open("mysql", conn)
 	if err != nil {
-		traceMsg("Unable to run sql.Open against MySQL, connection string was:")
-		traceMsg(conn)
+		traceMsg("Unable to run sql.Open against MySQL at " + dbTar.Host + ":" + strconv.Itoa(dbTar.Port))
 		return err
 	}
 
@@ -182,7 +181,6 @@ func prepMySQL(dbTar *config.DBTarget, os string) error {
 
 	// Drop existing DefectDojo database if it exists and configuration says to
 	if dbTar.Drop {
-		fmt.Println("INSIDE DROP IF")
 		// Query MySQL to see if the configured database name exists already
 		sql := "SELECT count(SCHEMA_NAME) FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = '" + dbTar.Name + "';"
 		rows, err := dbMySQL.QueryContext(ctx, sql)
@@ -207,10 +205,17 @@ func prepMySQL(dbTar *config.DBTarget, os string) error {
 			_, err := dbMySQL.ExecContext(ctx, sql)
 			if err != nil {
 				traceMsg("Attempt to drop existing database failed")
-				fmt.Println("DOH!")
 				return err
 			}
 		}
+
+		// The DefectDojo user goes with its database so CREATE USER below doesn't fail on a reinstall
+		sql = "DROP USER IF EXISTS '" + dbTar.User + "'@'" + dbTar.Host + "';"
+		_, err = dbMySQL.ExecContext(ctx, sql)
+		if err != nil {
+			traceMsg("Attempt to drop the existing database user failed")
+			return err
+		}
 	}
 
 	// Create the DefectDojo database if it doesn't already exist
@@ -265,13 +270,96 @@ func prepPostgreSQL(dbTar *config.DBTarget, os string) error {
 	// Open a connection to the configured PostgreSQL database
 	// https://godoc.org/github.com/lib/pq
 	conn := "user=" + dbTar.User + " password=" + dbTar.Pass + " host=" + dbTar.Host + " port=" + strconv.Itoa(dbTar.Port)
-	fmt.Println("DB conn is ", conn)
 
 	dbPostgreSQL, err := sql.Open("postgres", conn)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(dbPostgreSQL)
+	traceMsg("Opened a PostgreSQL connection to " + dbTar.Host + ":" + strconv.Itoa(dbTar.Port) + " as " + dbTar.User)
+	return dbPostgreSQL.Close()
+}
+
+// dbReachable returns nil if DefectDojo's database can be logged into with its configured user
+// SQLite is a file Django creates so there's nothing to connect to
+func dbReachable(ctx context.Context, dbTar *config.DBTarget) error {
+	var driver, conn string
+	switch dbTar.Engine {
+	case "SQLite":
+		return nil
+	case "MySQL", "MariaDB":
+		mc := mysql.NewConfig()
+		mc.User, mc.Passwd, mc.DBName = dbTar.User, dbTar.Pass, dbTar.Name
+		mc.Net, mc.Addr = "tcp", dbTar.Host+":"+strconv.Itoa(dbTar.Port)
+		if strings.HasPrefix(dbTar.Host, "/") {
+			mc.Net, mc.Addr = "unix", dbTar.Host
+		}
+		driver, conn = "mysql", mc.FormatDSN()
+	case "PostgreSQL":
+		driver = "postgres"
+		conn = "user=" + pqQuote(dbTar.User) + " password=" + pqQuote(dbTar.Pass) + " host=" + pqQuote(dbTar.Host) +
+			" port=" + strconv.Itoa(dbTar.Port) + " dbname=" + pqQuote(dbTar.Name)
+	default:
+		return fmt.Errorf("unsupported database engine %s", dbTar.Engine)
+	}
+	db, err := sql.Open(driver, conn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// Give the DB 3 seconds to respond like the rest of the DB prep
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	err = db.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the %s database %s as %s: %w", dbTar.Engine, dbTar.Name, dbTar.User, err)
+	}
 	return nil
 }
+
+// pqQuote quotes v for a lib/pq key=value connection string
+func pqQuote(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// defaultDBWait is how long waitForDB waits for the database if DB.Wait isn't in the config
+const defaultDBWait = 60 * time.Second
+
+// dbRetryDelay is how long waitForDB first waits between attempts, doubling up to dbMaxRetryDelay
+var (
+	dbRetryDelay    = time.Second
+	dbMaxRetryDelay = 10 * time.Second
+)
+
+// waitForDB returns once pingDB can reach the install's database, trying again with backoff until timeout
+// has passed so a database still starting e.g. a container started with godojo doesn't fail the migrations
+// A timeout of 0 tries only once
+func waitForDB(ctx context.Context, i *config.InstallConfig, timeout time.Duration) error {
+	if DryRun {
+		// The database wasn't installed or prepared so there's nothing to wait for
+		return nil
+	}
+	deadline := time.Now().Add(timeout)
+	delay := dbRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := pingDB(ctx, &i.DB)
+		if err == nil {
+			traceMsg(fmt.Sprintf("Database is accepting connections after %d attempts", attempt))
+			return nil
+		}
+		Info.Printf("Database attempt %d failed: %+v", attempt, err)
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("Database wasn't reachable after %d attempts over %s: %w", attempt, timeout, err)
+		}
+		statusMsg("db.waiting", delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > dbMaxRetryDelay {
+			delay = dbMaxRetryDelay
+		}
+	}
+}
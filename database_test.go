@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// fakePing makes pingDB fail the first failures times it's called then succeed, returning the call count
+func fakePing(t *testing.T, failures int) *int {
+	savedPing, savedDelay := pingDB, dbRetryDelay
+	t.Cleanup(func() { pingDB, dbRetryDelay = savedPing, savedDelay })
+	dbRetryDelay = time.Millisecond
+	calls := 0
+	pingDB = func(ctx context.Context, db *config.DBTarget) error {
+		calls++
+		if calls <= failures {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	return &calls
+}
+
+func TestWaitForDB(t *testing.T) {
+	calls := fakePing(t, 3)
+	err := waitForDB(context.Background(), &config.InstallConfig{}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expecting the database to be reached once it starts, got %v", err)
+	}
+	if *calls != 4 {
+		t.Errorf("Expecting 3 failed attempts then a successful one, got %d attempts", *calls)
+	}
+}
+
+func TestWaitForDBTimeout(t *testing.T) {
+	calls := fakePing(t, 1000)
+	start := time.Now()
+	err := waitForDB(context.Background(), &config.InstallConfig{}, 50*time.Millisecond)
+	if err == nil || time.Since(start) > 2*time.Second {
+		t.Fatalf("Expecting an error soon after the timeout, got %v after %s", err, time.Since(start))
+	}
+	if *calls < 2 {
+		t.Errorf("Expecting several attempts before giving up, got %d", *calls)
+	}
+
+	calls = fakePing(t, 1000)
+	err = waitForDB(context.Background(), &config.InstallConfig{}, 0)
+	if err == nil || *calls != 1 {
+		t.Errorf("Expecting a single attempt with no wait, got %d attempts, %v", *calls, err)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+// CheckDiskSpace can't check free space on this platform so always succeeds
+func CheckDiskSpace(path string, minBytes uint64, minInodes uint64) error {
+	return nil
+}
+
+// crossDevice can't tell a rename across filesystems on this platform so always reports it isn't one
+func crossDevice(err error) bool {
+	return false
+}
+
+// dirNotEmpty can't tell a rename onto a directory in use on this platform so always reports it isn't one
+func dirNotEmpty(err error) bool {
+	return false
+}
+
+// checkWritable can't check permissions on this platform so always succeeds
+func checkWritable(path string) error {
+	return nil
+}
+
+// sameDevice can't compare filesystems on this platform so always reports they're the same
+func sameDevice(a string, b string) (bool, error) {
+	return true, nil
+}
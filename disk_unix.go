@@ -0,0 +1,68 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// Reads filesystem stats, tests replace it to simulate a filesystem short on space or inodes
+var statfs = syscall.Statfs
+
+// CheckDiskSpace returns an error if the filesystem holding path has less than minBytes or minInodes free
+// Filesystems reporting no inodes at all allocate them as needed so only their bytes are checked
+func CheckDiskSpace(path string, minBytes uint64, minInodes uint64) error {
+	p := existingParent(path)
+	var st syscall.Statfs_t
+	err := statfs(p, &st)
+	if err != nil {
+		return fmt.Errorf("Unable to check free disk space at %s: %w", p, err)
+	}
+	free := uint64(st.Bavail) * uint64(st.Bsize)
+	if free < minBytes {
+		return fmt.Errorf("only %s free at %s, at least %s is needed", humanBytes(free), p, humanBytes(minBytes))
+	}
+	if st.Files > 0 && uint64(st.Ffree) < minInodes {
+		return fmt.Errorf("insufficient inodes at %s, only %d free and at least %d are needed for the source and virtualenv",
+			p, uint64(st.Ffree), minInodes)
+	}
+	return nil
+}
+
+// crossDevice returns true if err is from renaming across filesystems which needs a copy instead
+func crossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// dirNotEmpty returns true if err is from renaming onto a directory that has something in it
+func dirNotEmpty(err error) bool {
+	return errors.Is(err, syscall.ENOTEMPTY) || errors.Is(err, syscall.EEXIST)
+}
+
+// checkWritable returns an error if path, or the directory it would be created in, isn't writable
+func checkWritable(path string) error {
+	p := existingParent(path)
+	// W_OK, asks without creating anything
+	err := syscall.Access(p, 0x2)
+	if err != nil {
+		return fmt.Errorf("%s isn't writable: %w", p, err)
+	}
+	return nil
+}
+
+// sameDevice returns true if a and b, or the directories they would be created in, are on the same filesystem
+func sameDevice(a string, b string) (bool, error) {
+	var sa, sb syscall.Stat_t
+	err := syscall.Stat(existingParent(a), &sa)
+	if err != nil {
+		return false, err
+	}
+	err = syscall.Stat(existingParent(b), &sb)
+	if err != nil {
+		return false, err
+	}
+	return sa.Dev == sb.Dev, nil
+}
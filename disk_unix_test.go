@@ -0,0 +1,105 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCheckDiskSpaceInodes(t *testing.T) {
+	saved := statfs
+	defer func() { statfs = saved }()
+	fake := syscall.Statfs_t{Bsize: 4096, Bavail: 1 << 30, Files: 1 << 20, Ffree: 10}
+	statfs = func(path string, st *syscall.Statfs_t) error {
+		*st = fake
+		return nil
+	}
+
+	err := CheckDiskSpace(t.TempDir(), minDiskBytes, defaultMinFreeInodes)
+	if err == nil || !strings.Contains(err.Error(), "insufficient inodes") {
+		t.Errorf("Expecting an insufficient inodes error with ample bytes and 10 inodes free, got %v", err)
+	}
+	if err := CheckDiskSpace(t.TempDir(), minDiskBytes, 0); err != nil {
+		t.Errorf("Expecting no error when the inode check is off, got %v", err)
+	}
+	fake.Files, fake.Ffree = 0, 0
+	if err := CheckDiskSpace(t.TempDir(), minDiskBytes, defaultMinFreeInodes); err != nil {
+		t.Errorf("Expecting no error for a filesystem allocating inodes as needed, got %v", err)
+	}
+}
+
+func TestMoveIntoCrossDevice(t *testing.T) {
+	saved := rename
+	defer func() { rename = saved }()
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "stage", "django-DefectDojo")
+	writeFile(t, filepath.Join(src, "dojo", "settings", "base.py"), "# settings\n")
+	writeFile(t, filepath.Join(src, "manage.py"), "# manage\n")
+	os.Chmod(filepath.Join(src, "manage.py"), 0755)
+	os.Symlink("manage.py", filepath.Join(src, "run.py"))
+	dst := filepath.Join(dir, "django-DefectDojo")
+
+	if err := moveInto(src, dst); err != nil {
+		t.Fatalf("Expecting a copy when the rename crosses filesystems, got %v", err)
+	}
+	if fi, err := os.Stat(filepath.Join(dst, "manage.py")); err != nil || fi.Mode().Perm() != 0755 {
+		t.Errorf("Expecting manage.py copied keeping its mode, got %v, %v", fi, err)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "run.py")); err != nil || link != "manage.py" {
+		t.Errorf("Expecting the symlink copied, got %q, %v", link, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "dojo", "settings", "base.py")); err != nil {
+		t.Errorf("Expecting nested files copied, got %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expecting %s removed after the copy, got %v", src, err)
+	}
+
+	// An existing destination is never copied over or removed
+	writeFile(t, filepath.Join(src, "manage.py"), "# new\n")
+	err := moveInto(src, dst)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expecting an error for an existing destination, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "dojo", "settings", "base.py")); err != nil {
+		t.Errorf("Expecting the existing destination left alone, got %v", err)
+	}
+}
+
+func TestMoveIntoCrossDeviceTarball(t *testing.T) {
+	saved := rename
+	defer func() { rename = saved }()
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+
+	// A reinstall with TempDir on another filesystem finds the tarball from the last install in Root
+	dir := t.TempDir()
+	src := filepath.Join(dir, "stage", "dojo-v1.5.3.1.tar.gz")
+	dst := filepath.Join(dir, "dojo-v1.5.3.1.tar.gz")
+	writeFile(t, src, "new release")
+	writeFile(t, dst, "old release")
+
+	if err := moveInto(src, dst); err != nil {
+		t.Fatalf("Expecting the tarball replaced, got %v", err)
+	}
+	if b, err := ioutil.ReadFile(dst); err != nil || string(b) != "new release" {
+		t.Errorf("Expecting the new tarball in place, got %q, %v", b, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expecting %s removed after the copy, got %v", src, err)
+	}
+	if _, err := os.Stat(dst + ".godojo-tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expecting no temp file left, got %v", err)
+	}
+}
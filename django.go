@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Runs the Django management commands godojo needs after the migrations, collectstatic and loaddata
+
+// manageCmd returns the command running manage.py with args from the DefectDojo source using the virtualenv's
+// Python so settings.py finds its .env.prod. The source is passed to sh as $0 rather than quoted into the script
+func manageCmd(i *config.InstallConfig, args ...string) []string {
+	c := []string{"sh", "-c", `cd "$0" && exec "$@"`, filepath.Join(i.Root, i.Source),
+		filepath.Join(venvPath(i), "bin", "python3"), "manage.py"}
+	return append(c, args...)
+}
+
+// shellSafe matches an argument bash takes as is without quoting
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// shellQuote returns s quoted for bash unless it's safe as is
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// venvShell returns a line for bash -c like those in osCmds running prog with args from the DefectDojo source
+// with the virtualenv's bin first on the PATH as sourcing its activate script would
+func venvShell(i *config.InstallConfig, prog string, args ...string) string {
+	venv := venvPath(i)
+	line := []string{"cd", shellQuote(filepath.Join(i.Root, i.Source)), "&&",
+		"VIRTUAL_ENV=" + shellQuote(venv), "PATH=" + shellQuote(filepath.Join(venv, "bin")) + `:"$PATH"`, shellQuote(prog)}
+	for _, a := range args {
+		line = append(line, shellQuote(a))
+	}
+	return strings.Join(line, " ")
+}
+
+// manageShell returns manageCmd as a line for bash -c like those in osCmds
+func manageShell(i *config.InstallConfig, args ...string) string {
+	return venvShell(i, filepath.Join(venvPath(i), "bin", "python3"), append([]string{"manage.py"}, args...)...)
+}
+
+// collectStaticCmds returns the commands gathering DefectDojo's static files into static/ for nginx to serve,
+// handing them to the DefectDojo user as they're written after the django step's chown. collectstatic runs
+// under the install's Umask like every command godojo runs so static/ and the directories above it are then
+// opened up to nginx's user, which isn't in the DefectDojo group
+func collectStaticCmds(i *config.InstallConfig) [][]string {
+	static := filepath.Join(i.Root, i.Source, "static")
+	return [][]string{
+		manageCmd(i, "collectstatic", "--noinput"),
+		{"chown", "-R", i.OS.User + ":" + i.OS.Group, static},
+		{"chmod", "-R", "o+rX", static},
+		{"chmod", "o+x", i.Root, filepath.Join(i.Root, i.Source)},
+	}
+}
+
+// loadDataCmd returns the command loading LoadFixtures or nil if there are none to load
+func loadDataCmd(i *config.InstallConfig) []string {
+	if len(i.LoadFixtures) == 0 {
+		return nil
+	}
+	return manageCmd(i, append([]string{"loaddata"}, i.LoadFixtures...)...)
+}
+
+// runCollectStatic runs collectstatic with its output streamed to the install log
+func runCollectStatic(ctx context.Context, i *config.InstallConfig) error {
+	for _, c := range collectStaticCmds(i) {
+		err := runCmd(ctx, c[0], c[1:]...)
+		if err != nil {
+			return fmt.Errorf("Unable to collect DefectDojo's static files: %w", err)
+		}
+	}
+	statusMsg("static.done")
+	return nil
+}
+
+// loadInitialData loads the LoadFixtures with loaddata, doing nothing if none are configured
+func loadInitialData(ctx context.Context, i *config.InstallConfig) error {
+	c := loadDataCmd(i)
+	if c == nil {
+		statusMsg("fixtures.none")
+		return nil
+	}
+	err := runCmd(ctx, c[0], c[1:]...)
+	if err != nil {
+		return fmt.Errorf("Unable to load the fixtures %v: %w", i.LoadFixtures, err)
+	}
+	statusMsg("fixtures.done", len(i.LoadFixtures))
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func djangoFixture() *config.InstallConfig {
+	i := &config.InstallConfig{Root: "/opt/dojo", Source: "django-DefectDojo"}
+	i.OS.User = "dojo"
+	i.OS.Group = "dojo"
+	return i
+}
+
+func TestCollectStaticCmds(t *testing.T) {
+	got := collectStaticCmds(djangoFixture())
+	want := [][]string{
+		{"sh", "-c", `cd "$0" && exec "$@"`, "/opt/dojo/django-DefectDojo", "/opt/dojo/bin/python3", "manage.py", "collectstatic", "--noinput"},
+		{"chown", "-R", "dojo:dojo", "/opt/dojo/django-DefectDojo/static"},
+		{"chmod", "-R", "o+rX", "/opt/dojo/django-DefectDojo/static"},
+		{"chmod", "o+x", "/opt/dojo", "/opt/dojo/django-DefectDojo"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expecting %v, got %v", want, got)
+	}
+}
+
+func TestLoadDataCmd(t *testing.T) {
+	i := djangoFixture()
+	i.VenvPath = "/opt/venvs/dojo"
+	i.LoadFixtures = []string{"initial_surveys", "extra"}
+	got := loadDataCmd(i)
+	want := []string{"sh", "-c", `cd "$0" && exec "$@"`, "/opt/dojo/django-DefectDojo", "/opt/venvs/dojo/bin/python3", "manage.py", "loaddata", "initial_surveys", "extra"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expecting %v, got %v", want, got)
+	}
+}
+
+func TestLoadInitialDataSkipsWithoutFixtures(t *testing.T) {
+	ran := recordCmds(t)
+	if err := loadInitialData(context.Background(), djangoFixture()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("Expecting nothing run without LoadFixtures, got %v", *ran)
+	}
+	c := config.DojoConfig{}
+	for _, s := range installSteps(&c, targetOS{}) {
+		if s.name == "load-fixtures" && !s.skip {
+			t.Error("Expecting the load-fixtures step to be skipped without LoadFixtures")
+		}
+	}
+}
+
+func TestLoadInitialData(t *testing.T) {
+	ran := recordCmds(t)
+	i := djangoFixture()
+	i.LoadFixtures = []string{"initial_surveys"}
+	if err := loadInitialData(context.Background(), i); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ran) != 1 || !strings.HasSuffix((*ran)[0], "manage.py loaddata initial_surveys") {
+		t.Errorf("Expecting loaddata to be run once, got %v", *ran)
+	}
+}
+
+func TestManageShell(t *testing.T) {
+	i := djangoFixture()
+	i.Source = "dojo src"
+	i.VenvPath = "/opt/venvs/dojo"
+	got := manageShell(i, "migrate")
+	want := `cd '/opt/dojo/dojo src' && VIRTUAL_ENV=/opt/venvs/dojo PATH=/opt/venvs/dojo/bin:"$PATH" /opt/venvs/dojo/bin/python3 manage.py migrate`
+	if got != want {
+		t.Errorf("Expecting %s, got %s", want, got)
+	}
+
+	c := osCmds{}
+	ubuntuSetupDDjango("ubuntu:18.04", i, &c)
+	for _, l := range c.cmds {
+		if strings.Contains(l, "bin/activate") || strings.Contains(l, "django-DefectDojo") {
+			t.Errorf("Expecting the Django commands to use Source and VenvPath, got %s", l)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, s := range []string{"admin", "it's a \"pass\" $HOME", "--email=dojo@example.com", ""} {
+		out, err := exec.Command("bash", "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil || string(out) != s {
+			t.Errorf("Expecting bash to see %q, got %q, %v", s, out, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles 'godojo doctor' which runs the preflight checks without installing anything
+
+// minDiskBytes is the free space needed for DefectDojo's source, virtualenv and frontend assets
+const minDiskBytes = 2 << 30
+
+// defaultMinFreeInodes is the free inodes needed when MinFreeInodes isn't set, the source and
+// virtualenv add up to tens of thousands of small files
+const defaultMinFreeInodes = 100000
+
+// preflightCheck - a non-destructive check of a prerequisite for an install
+type preflightCheck struct {
+	name     string
+	critical bool // If true, a failure means the install can't succeed
+	run      func() error
+}
+
+// preflightChecks returns the checks for an install using config c
+func preflightChecks(c *config.DojoConfig) []preflightCheck {
+	i := &c.Install
+	checks := []preflightCheck{
+		{name: "Running as root or sudo is available", critical: true, run: checkRoot},
+		{name: "OS is supported", critical: true, run: func() error { return checkOS(i) }},
+		{name: "Python meets the minimum version", critical: true, run: func() error { return checkPython(i.MinPython) }},
+		{name: "git is installed", run: func() error { return checkBinary("git") }},
+		{name: "Programs needed by the install steps are installed", critical: true, run: func() error {
+			return RequireBinaries(stepBinaries(installSteps(c, targetOS{}))...)
+		}},
+		{name: "GitHub is reachable", critical: true, run: func() error { return checkGitHub(i) }},
+		{name: "Enough free disk space for Root", critical: true, run: func() error {
+			return CheckDiskSpace(i.Root, minDiskBytes, uint64(i.MinFreeInodes))
+		}},
+		{name: "Root is writable", critical: true, run: func() error { return checkWritable(i.Root) }},
+		{name: "TempDir is writable and on the same filesystem as Root", run: func() error {
+			warn, err := checkTempDir(i)
+			if err == nil && warn != "" {
+				return errors.New(warn)
+			}
+			return err
+		}},
+		{name: "Ports for the app server and nginx are free", run: func() error { return checkPorts(installPorts(i)) }},
+	}
+	switch i.DB.Engine {
+	case "MySQL", "MariaDB":
+		checks = append(checks, preflightCheck{name: "mysql client is installed", run: func() error { return checkBinary("mysql") }})
+	case "PostgreSQL":
+		checks = append(checks, preflightCheck{name: "psql client is installed", run: func() error { return checkBinary("psql") }})
+	}
+	return checks
+}
+
+// runDoctor runs checks writing PASS, WARN or FAIL for each to w and returns the exit code
+// Only failed critical checks make the exit code non-zero
+func runDoctor(w io.Writer, checks []preflightCheck) int {
+	failed, warned := 0, 0
+	for _, c := range checks {
+		err := c.run()
+		switch {
+		case err == nil:
+			fmt.Fprintf(w, "  PASS  %s\n", c.name)
+		case c.critical:
+			failed++
+			fmt.Fprintf(w, "  FAIL  %s: %+v\n", c.name, err)
+		default:
+			warned++
+			fmt.Fprintf(w, "  WARN  %s: %+v\n", c.name, err)
+		}
+	}
+	fmt.Fprintf(w, "\n%d checks, %d failed, %d warnings\n", len(checks), failed, warned)
+	if failed > 0 {
+		return exitFailure
+	}
+	return 0
+}
+
+// doctorCmd implements 'godojo doctor'
+func doctorCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	c := config.DojoConfig{}
+	err = loadConfig(viper.GetViper(), fs, &c)
+	if err != nil && err != errNoConfig {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	client, err := newHTTPClient(&c.Install)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	httpClient = client
+	// The checks share helpers with the install which log, keep them quiet
+	Quiet = true
+	logSetup(ioutil.Discard, nil)
+
+	fmt.Println("Running godojo preflight checks, nothing will be changed")
+	fmt.Println("")
+	return runDoctor(os.Stdout, preflightChecks(&c))
+}
+
+// checkRoot returns an error if not running as root and sudo isn't available
+func checkRoot() error {
+	u, err := currentUser()
+	if err == nil && u.Uid == "0" {
+		return nil
+	}
+	_, err = exec.LookPath("sudo")
+	if err != nil {
+		return fmt.Errorf("not running as root and sudo wasn't found")
+	}
+	return nil
+}
+
+// checkOS returns an error if the OS isn't a supported install target or is incompatible with the version
+func checkOS(i *config.InstallConfig) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("%s is not a supported installation platform", runtime.GOOS)
+	}
+	_, err := os.Stat("/etc/os-release")
+	if err != nil {
+		return fmt.Errorf("unable to find /etc/os-release to determine the Linux distro")
+	}
+	tOS := targetOS{os: runtime.GOOS}
+	tOS.distro, tOS.release, tOS.id = parseOSRelease("/etc/os-release")
+	if !contains(InstallTargets[tOS.distro], tOS.release) {
+		return fmt.Errorf("%s %s is not a supported install target", tOS.distro, tOS.release)
+	}
+	return checkCompat(i, tOS)
+}
+
+// checkBinary returns an error if name isn't in $PATH
+func checkBinary(name string) error {
+	_, err := lookPath(name)
+	if err != nil {
+		return fmt.Errorf("%s wasn't found in $PATH", name)
+	}
+	return nil
+}
+
+// checkGitHub returns an error if the configured repo in the GitHub API can't be reached, any HTTP response counts as reachable
+func checkGitHub(i *config.InstallConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := newRequest(ctx, http.MethodHead, apiURL(i))
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// existingParent returns path or its closest ancestor that exists
+func existingParent(path string) string {
+	p := filepath.Clean(path)
+	for {
+		_, err := os.Stat(p)
+		if err == nil || p == filepath.Dir(p) {
+			return p
+		}
+		p = filepath.Dir(p)
+	}
+}
+
+// humanBytes formats b like 1.5 GiB
+func humanBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
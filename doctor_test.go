@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// result returns a check func which returns err
+func result(err error) func() error {
+	return func() error { return err }
+}
+
+func TestRunDoctor(t *testing.T) {
+	out := &bytes.Buffer{}
+	code := runDoctor(out, []preflightCheck{
+		{name: "python3 is installed", critical: true, run: result(nil)},
+		{name: "git is installed", run: result(errors.New("git wasn't found"))},
+	})
+	if code != 0 {
+		t.Errorf("Expecting exit 0 when only non-critical checks fail, got %d", code)
+	}
+	for _, want := range []string{"PASS  python3 is installed", "WARN  git is installed: git wasn't found", "2 checks, 0 failed, 1 warnings"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expecting %q in:\n%s", want, out)
+		}
+	}
+
+	out.Reset()
+	code = runDoctor(out, []preflightCheck{
+		{name: "Root is writable", critical: true, run: result(errors.New("/opt isn't writable"))},
+		{name: "GitHub is reachable", critical: true, run: result(nil)},
+	})
+	if code != exitFailure {
+		t.Errorf("Expecting exit %d when a critical check fails, got %d", exitFailure, code)
+	}
+	if !strings.Contains(out.String(), "FAIL  Root is writable") || !strings.Contains(out.String(), "1 failed") {
+		t.Errorf("Expecting the critical failure reported, got:\n%s", out)
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := CheckDiskSpace(dir+"/not/created/yet", 1, 0); err != nil {
+		t.Errorf("Expecting 1 byte free for a missing path's parent, got %v", err)
+	}
+	if err := CheckDiskSpace(dir, 1<<62, 0); err == nil {
+		t.Errorf("Expecting an error when asking for more space than exists")
+	}
+}
@@ -0,0 +1,117 @@
+// Package dojoerr holds the typed errors returned by the godojo installer so
+// callers can react to a failure with errors.As instead of matching on text
+package dojoerr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DownloadError - returned when a download or clone fails or returns an unexpected HTTP status
+type DownloadError struct {
+	URL        string // URL that was being downloaded
+	StatusCode int    // HTTP status code returned, 0 if no response was received
+	Err        error  // Underlying error, if any
+}
+
+func (e *DownloadError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("download of %s failed with HTTP status %d", e.URL, e.StatusCode)
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("download of %s failed with HTTP status %d: %v", e.URL, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("download of %s failed: %v", e.URL, e.Err)
+}
+
+// Unwrap returns the underlying error so errors.Is and errors.As can inspect it
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// ExtractError - returned when an entry of a downloaded archive can't be extracted
+type ExtractError struct {
+	Entry string // Name of the archive entry that failed, empty if the archive itself is unreadable
+	Err   error  // Underlying error
+}
+
+func (e *ExtractError) Error() string {
+	if e.Entry == "" {
+		return fmt.Sprintf("unable to extract archive: %v", e.Err)
+	}
+	return fmt.Sprintf("unable to extract %s: %v", e.Entry, e.Err)
+}
+
+// Unwrap returns the underlying error so errors.Is and errors.As can inspect it
+func (e *ExtractError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError - returned when a configuration value is missing or nonsensical
+type ConfigError struct {
+	Field string // Config key that is wrong e.g. Install.SourceBranch
+	Msg   string // What is wrong with it
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration for %s: %s", e.Field, e.Msg)
+}
+
+// ConfigErrors - every problem found when validating a configuration
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns each ConfigError so errors.As can find them
+func (e ConfigErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = e[i]
+	}
+	return errs
+}
+
+// CmdError - returned when an OS command can't be started or exits non-zero
+type CmdError struct {
+	Cmd      string   // Command line that was run, with secrets redacted
+	ExitCode int      // Exit code of the command, -1 if it didn't run to completion
+	Err      error    // Underlying error
+	Tail     []string // Last lines of the command's combined output, with secrets redacted
+}
+
+func (e *CmdError) Error() string {
+	msg := fmt.Sprintf("command %s exited with code %d", e.Cmd, e.ExitCode)
+	if e.ExitCode < 0 {
+		msg = fmt.Sprintf("command %s failed: %v", e.Cmd, e.Err)
+	}
+	if len(e.Tail) == 0 {
+		return msg
+	}
+	return msg + "; last output:\n  " + strings.Join(e.Tail, "\n  ")
+}
+
+// Unwrap returns the underlying error so errors.Is and errors.As can inspect it
+func (e *CmdError) Unwrap() error {
+	return e.Err
+}
+
+// FileError - returned when a file the installer generates can't be written
+type FileError struct {
+	Path string // File that was being written
+	Err  error  // Underlying error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("unable to write %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error so errors.Is and errors.As can inspect it
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
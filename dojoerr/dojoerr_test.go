@@ -0,0 +1,64 @@
+package dojoerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDownloadErrorAs(t *testing.T) {
+	var err error = fmt.Errorf("release step failed: %w", &DownloadError{URL: "https://example.com/1.0.tar.gz", StatusCode: 404})
+
+	var dErr *DownloadError
+	if !errors.As(err, &dErr) {
+		t.Fatalf("Expecting errors.As to find a *DownloadError in %v", err)
+	}
+	if dErr.StatusCode != 404 {
+		t.Errorf("Expecting status code 404, got %d", dErr.StatusCode)
+	}
+	if !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expecting the error text to include the status code, got %s", err.Error())
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	dErr := &DownloadError{URL: "https://example.com", Err: io.ErrUnexpectedEOF}
+	if !errors.Is(dErr, io.ErrUnexpectedEOF) {
+		t.Errorf("Expecting DownloadError to unwrap to io.ErrUnexpectedEOF")
+	}
+	eErr := &ExtractError{Entry: "dojo/settings.py", Err: io.ErrUnexpectedEOF}
+	if !errors.Is(eErr, io.ErrUnexpectedEOF) {
+		t.Errorf("Expecting ExtractError to unwrap to io.ErrUnexpectedEOF")
+	}
+	fErr := &FileError{Path: "dojo/settings/.env.prod", Err: io.ErrShortWrite}
+	if !errors.Is(fErr, io.ErrShortWrite) {
+		t.Errorf("Expecting FileError to unwrap to io.ErrShortWrite")
+	}
+}
+
+func TestConfigErrorAs(t *testing.T) {
+	var err error = &ConfigError{Field: "Install.SourceBranch", Msg: "empty"}
+	var cErr *ConfigError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("Expecting errors.As to find a *ConfigError")
+	}
+	if cErr.Field != "Install.SourceBranch" {
+		t.Errorf("Expecting field Install.SourceBranch, got %s", cErr.Field)
+	}
+}
+
+func TestConfigErrorsAs(t *testing.T) {
+	var err error = ConfigErrors{
+		{Field: "Install.DB.Pass", Msg: "empty"},
+		{Field: "Install.Admin.Pass", Msg: "empty"},
+	}
+	var cErr *ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.DB.Pass" {
+		t.Errorf("Expecting errors.As to find the first ConfigError, got %v", cErr)
+	}
+	if strings.Count(err.Error(), "\n") != 1 {
+		t.Errorf("Expecting one line per error, got %q", err.Error())
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles 'godojo download' which only fetches and verifies the DefectDojo source e.g. to stage it
+// on a build host for copying into an air-gapped network
+
+// downloadCmd implements 'godojo download [flags]'
+func downloadCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	fs.Bool("no-tarball", false, "Remove the release tarball, its checksum and signature once the source is extracted")
+	fs.Usage = func() {
+		fmt.Println("Usage: godojo download [flags]")
+		fmt.Println("Downloads and verifies the configured DefectDojo source into Root without installing anything")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	c := config.DojoConfig{}
+	err = loadConfig(viper.GetViper(), fs, &c)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	err = applyConfig(&c)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	_, err = validateConfig(&c)
+	if err != nil {
+		fmt.Printf("%+v\n", Redactatron(err.Error(), Redact))
+		return exitConfig
+	}
+	// Nothing is installed so there's no install log, only the console output
+	logSetup(ioutil.Discard, nil)
+
+	noTarball, _ := fs.GetBool("no-tarball")
+	err = runDownload(ctx, &c.Install, !noTarball)
+	if err != nil {
+		errorMsg("error", err)
+		return exitCode(err)
+	}
+	statusMsg("download.done", filepath.Join(c.Install.Root, c.Install.Source))
+	return 0
+}
+
+// runDownload fetches, verifies and extracts the configured source into Root the same way an install
+// does then stops, removing a release's tarball afterwards unless keepTarball is set
+func runDownload(ctx context.Context, i *config.InstallConfig, keepTarball bool) error {
+	// The whole point is the download so PullSource false would do nothing
+	i.PullSource = true
+	err := getDojo(ctx, i)
+	if err != nil {
+		return err
+	}
+	if keepTarball || i.SourceInstall {
+		return nil
+	}
+	_, tarball := releasePaths(i)
+	for _, ext := range []string{"", ".sha256", ".asc"} {
+		err = os.Remove(tarball + ext)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestRunDownload(t *testing.T) {
+	serveRelease(t, fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+		{"django-DefectDojo-1.5.3.1/dojo/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/settings/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/settings/settings.dist.py", "# settings\n"},
+	}))
+	ran := recordCmds(t)
+
+	for _, keep := range []bool{true, false} {
+		i := config.InstallConfig{Version: "1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo"}
+		err := runDownload(context.Background(), &i, keep)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(i.Root, i.Source, "manage.py")); err != nil {
+			t.Errorf("Expecting the source tree in Root, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(i.Root, "dojo-v1.5.3.1.tar.gz")); (err == nil) != keep {
+			t.Errorf("Expecting the tarball to be kept only if asked for, keep %v got %v", keep, err)
+		}
+		// None of the later steps ran so there's no settings.py or virtualenv
+		for _, f := range []string{filepath.Join(i.Source, "dojo/settings/settings.py"), "venv"} {
+			if _, err := os.Stat(filepath.Join(i.Root, f)); err == nil {
+				t.Errorf("Expecting only the download, found %s", f)
+			}
+		}
+	}
+	if len(*ran) != 0 {
+		t.Errorf("Expecting no OS commands to be run, got %q", *ran)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// redirectDoer sends every request to a test server regardless of the requested host
+type redirectDoer struct {
+	ts   *httptest.Server
+	hits []string
+}
+
+func (d *redirectDoer) Do(req *http.Request) (*http.Response, error) {
+	d.hits = append(d.hits, req.URL.String())
+	u, _ := url.Parse(d.ts.URL)
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	return d.ts.Client().Do(req)
+}
+
+// fakeCloner creates a local repo containing a single file instead of cloning from GitHub
+type fakeCloner struct {
+	opts *git.CloneOptions
+}
+
+func (c *fakeCloner) PlainCloneContext(ctx context.Context, path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
+	c.opts = o
+	repo, err := git.PlainInit(path, isBare)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(path, "manage.py"), []byte("# fake\n"), 0644)
+	return repo, err
+}
+
+// fixtureTarball builds a gzipped tarball shaped like a GitHub release archive from name, body
+// pairs where names ending in / are directories
+func fixtureTarball(t *testing.T, entries [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		if strings.HasSuffix(e[0], "/") {
+			tw.WriteHeader(&tar.Header{Name: e[0], Typeflag: tar.TypeDir, Mode: 0755})
+			continue
+		}
+		tw.WriteHeader(&tar.Header{Name: e[0], Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(e[1]))})
+		tw.Write([]byte(e[1]))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestEndToEndRelease(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+
+	tb := fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+		{"django-DefectDojo-1.5.3.1/dojo/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/settings/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/settings/base.py", "# settings\n"},
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/DefectDojo/django-DefectDojo/archive/1.5.3.1.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(tb)
+	}))
+	defer ts.Close()
+	fake := &redirectDoer{ts: ts}
+	httpClient = fake
+
+	i := config.InstallConfig{Version: "1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true}
+	err := getDojo(context.Background(), &i)
+	if err != nil {
+		t.Fatalf("Unexpected error installing from a release: %v", err)
+	}
+	if len(fake.hits) != 1 || fake.hits[0] != "https://github.com/DefectDojo/django-DefectDojo/archive/1.5.3.1.tar.gz" {
+		t.Errorf("Expecting a single request for the release tarball, got %v", fake.hits)
+	}
+	for _, f := range []string{"manage.py", "dojo/settings/base.py"} {
+		if _, err := os.Stat(filepath.Join(i.Root, i.Source, f)); err != nil {
+			t.Errorf("Expecting %s in the source tree, got %v", f, err)
+		}
+	}
+}
+
+func TestEndToEndSource(t *testing.T) {
+	savedCloner := cloner
+	defer func() { cloner = savedCloner }()
+	fake := &fakeCloner{}
+	cloner = fake
+
+	i := config.InstallConfig{SourceInstall: true, SourceBranch: "dev", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true}
+	err := getDojo(context.Background(), &i)
+	if err != nil {
+		t.Fatalf("Unexpected error installing from source: %v", err)
+	}
+	if fake.opts == nil || fake.opts.URL != repoCloneURL(&i) || fake.opts.ReferenceName != "refs/heads/dev" {
+		t.Errorf("Expecting a clone of the dev branch from %s, got %+v", repoCloneURL(&i), fake.opts)
+	}
+	if _, err := os.Stat(filepath.Join(i.Root, i.Source, "manage.py")); err != nil {
+		t.Errorf("Expecting manage.py in the source tree, got %v", err)
+	}
+}
+
+// commitFile writes name to the worktree of repo and commits it returning the commit's hash
+func commitFile(t *testing.T, repo *git.Repository, dir string, name string) plumbing.Hash {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, name), "# "+name+"\n")
+	wk, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wk.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	h, err := wk.Commit("Add "+name, &git.CommitOptions{Author: &object.Signature{Name: "godojo", Email: "godojo@example.com", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestEndToEndSourcePR(t *testing.T) {
+	// A local origin whose master doesn't have pr.py but refs/pull/1/head does, like a GitHub pull request
+	origin := t.TempDir()
+	repo, err := git.PlainInit(origin, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := commitFile(t, repo, origin, "manage.py")
+	pr := commitFile(t, repo, origin, "pr.py")
+	for name, h := range map[plumbing.ReferenceName]plumbing.Hash{"refs/pull/1/head": pr, "refs/heads/master": base} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(name, h)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	i := config.InstallConfig{SourceInstall: true, SourcePR: 1, SourceBranch: "dev", CloneURL: origin,
+		Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true}
+	err = getDojo(context.Background(), &i)
+	if err != nil {
+		t.Fatalf("Unexpected error installing a pull request: %v", err)
+	}
+	for _, f := range []string{"manage.py", "pr.py"} {
+		if _, err := os.Stat(filepath.Join(i.Root, i.Source, f)); err != nil {
+			t.Errorf("Expecting %s from the pull request in the source tree, got %v", f, err)
+		}
+	}
+
+	i.SourcePR = 2
+	i.Root = t.TempDir()
+	err = getDojo(context.Background(), &i)
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.SourcePR" {
+		t.Errorf("Expecting a ConfigError for a pull request that doesn't exist, got %v", err)
+	}
+}
+
+// serveRelease answers every request with the tarball tb until the test ends
+func serveRelease(t *testing.T, tb []byte) {
+	savedClient := httpClient
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tb)
+	}))
+	t.Cleanup(func() {
+		httpClient = savedClient
+		ts.Close()
+	})
+	httpClient = &redirectDoer{ts: ts}
+}
+
+func TestReleaseExistingSource(t *testing.T) {
+	serveRelease(t, fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+	}))
+	for _, mode := range []string{"", "overwrite", "backup"} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			i := config.InstallConfig{Version: "1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true, ExistingSource: mode}
+			src := filepath.Join(i.Root, i.Source)
+			writeFile(t, filepath.Join(src, "stale.py"), "# left by an earlier install\n")
+
+			err := getDojo(context.Background(), &i)
+			_, staleErr := os.Stat(filepath.Join(src, "stale.py"))
+			switch mode {
+			case "":
+				var cErr *dojoerr.ConfigError
+				if !errors.As(err, &cErr) || cErr.Field != "Install.ExistingSource" {
+					t.Fatalf("Expecting an ExistingSource config error, got %v", err)
+				}
+				if staleErr != nil {
+					t.Errorf("Expecting the existing source left alone, got %v", staleErr)
+				}
+				return
+			case "overwrite":
+				if err != nil || staleErr == nil {
+					t.Errorf("Expecting the old source removed, got %v, stale file error %v", err, staleErr)
+				}
+			case "backup":
+				baks, _ := filepath.Glob(src + ".bak-*")
+				if err != nil || staleErr == nil || len(baks) != 1 {
+					t.Fatalf("Expecting the old source moved aside, got %v, backups %q", err, baks)
+				}
+				if _, err := os.Stat(filepath.Join(baks[0], "stale.py")); err != nil {
+					t.Errorf("Expecting the old source in %s, got %v", baks[0], err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(src, "manage.py")); err != nil {
+				t.Errorf("Expecting the release extracted into %s, got %v", src, err)
+			}
+		})
+	}
+}
+
+func TestReleaseMissingTopDir(t *testing.T) {
+	// Files at the top of the tarball are stripped away leaving no source tree
+	serveRelease(t, fixtureTarball(t, [][2]string{{"manage.py", "# manage\n"}}))
+	i := config.InstallConfig{Version: "v1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true}
+	err := getDojo(context.Background(), &i)
+	var eErr *dojoerr.ExtractError
+	if !errors.As(err, &eErr) || eErr.Entry != "django-DefectDojo-1.5.3.1/manage.py" {
+		t.Errorf("Expecting an ExtractError for the missing top directory, got %v", err)
+	}
+}
+
+// hostDoer sends each request to the test server standing in for its host, unknown hosts are refused
+type hostDoer struct {
+	hosts map[string]*httptest.Server
+	hits  []string
+}
+
+func (d *hostDoer) Do(req *http.Request) (*http.Response, error) {
+	d.hits = append(d.hits, req.URL.String())
+	ts, ok := d.hosts[req.URL.Host]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	u, _ := url.Parse(ts.URL)
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	return ts.Client().Do(req)
+}
+
+func TestReleaseMirrorFallback(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+
+	tb := fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+	})
+	sum := sha256.Sum256(tb)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	defer flaky.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dojo/1.5.3.1.tar.gz":
+			w.Write(tb)
+		case "/dojo/1.5.3.1.tar.gz.sha256":
+			w.Write([]byte(hex.EncodeToString(sum[:]) + "  1.5.3.1.tar.gz\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mirror.Close()
+	fake := &hostDoer{hosts: map[string]*httptest.Server{"github.com": flaky, "mirror-b.example": mirror}}
+	httpClient = fake
+
+	i := config.InstallConfig{Version: "1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true,
+		ReleaseMirrors: []string{"https://mirror-a.example/dojo", "https://mirror-b.example/dojo/"},
+		ChecksumURL:    "https://mirror-b.example/dojo/1.5.3.1.tar.gz.sha256"}
+	err := getDojo(context.Background(), &i)
+	if err != nil {
+		t.Fatalf("Unexpected error with a working mirror: %v", err)
+	}
+	for _, want := range []string{"https://github.com/DefectDojo/django-DefectDojo/archive/1.5.3.1.tar.gz", "https://mirror-a.example/dojo/1.5.3.1.tar.gz", "https://mirror-b.example/dojo/1.5.3.1.tar.gz"} {
+		if !contains(fake.hits, want) {
+			t.Errorf("Expecting a request for %s, got %v", want, fake.hits)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(i.Root, i.Source, "manage.py")); err != nil {
+		t.Errorf("Expecting the mirror's release extracted, got %v", err)
+	}
+}
+
+func TestReleaseNotFoundSkipsMirrors(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	fake := &hostDoer{hosts: map[string]*httptest.Server{"github.com": missing}}
+	httpClient = fake
+
+	i := config.InstallConfig{Version: "9.9.9", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true,
+		ReleaseMirrors: []string{"https://mirror-a.example/dojo"}}
+	err := getDojo(context.Background(), &i)
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || dErr.StatusCode != http.StatusNotFound || len(fake.hits) != 1 {
+		t.Errorf("Expecting the 404 without trying mirrors, got %v after %v", err, fake.hits)
+	}
+}
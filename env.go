@@ -4,10 +4,13 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
 )
 
 // Handles the template-based generation of env.prod for DefectDojo's settings.py
@@ -112,39 +115,52 @@ type envVals struct {
 	DD_PORT_SCAN_SOURCE_IP                string
 }
 
-func genAndWriteEnv(i *config.DojoConfig, dbURL string) {
-	// Generate randon values for the two keys below
-	secretKey := i.Settings.Secret.Key
-	if secretKey == "." {
-		// Handle the case that the key wasn't configured
-		s1 := make([]byte, 42)
-		_, err := rand.Read(s1)
-		if err != nil {
-			errorMsg("Error generating random data for encryption keys")
-			os.Exit(1)
-		}
-		secretKey = base64.StdEncoding.EncodeToString(s1)
+// defaultAllowedHosts are allowed when Install.AllowedHosts isn't set
+var defaultAllowedHosts = []string{"localhost", "127.0.0.1"}
+
+// allowedHosts returns the host names DefectDojo answers to - Install.AllowedHosts or the defaults,
+// any extra, the install's hostname and localhost for the health check - without blanks or duplicates
+func allowedHosts(i *config.InstallConfig, extra ...string) []string {
+	hosts := i.AllowedHosts
+	if len(hosts) == 0 {
+		hosts = defaultAllowedHosts
 	}
-	credentialKey := i.Settings.Credential.AES.B256.Key
-	if credentialKey == "." {
-		// Handle the case that the key wasn't configured
-		s2 := make([]byte, 42)
-		_, err := rand.Read(s2)
-		if err != nil {
-			errorMsg("Error generating random data for encryption keys")
-			os.Exit(1)
+	all := append(append(append([]string{}, hosts...), extra...), tlsHostname(i), "localhost")
+	seen := map[string]bool{}
+	out := []string{}
+	for _, h := range all {
+		h = strings.TrimSpace(h)
+		if h == "" || seen[strings.ToLower(h)] {
+			continue
 		}
-		credentialKey = base64.StdEncoding.EncodeToString(s2)
+		seen[strings.ToLower(h)] = true
+		out = append(out, h)
 	}
+	return out
+}
 
-	// Set the values from the configuration file
-	env := envVals{
+// envKey returns the configured key or a random one if it's the "." placeholder asking for one to be generated
+func envKey(configured string) (string, error) {
+	if configured != "." {
+		return configured, nil
+	}
+	b := make([]byte, 42)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// envValues returns the values of env.prod for config i, the database at dbURL and the given keys
+func envValues(i *config.DojoConfig, dbURL string, secretKey string, credentialKey string) envVals {
+	return envVals{
 		DD_DEBUG:                              i.Settings.Debug,
 		DD_DJANGO_ADMIN_ENABLED:               i.Settings.Django.Admin.Enabled,
 		DD_SECRET_KEY:                         secretKey,
 		DD_CREDENTIAL_AES_256_KEY:             credentialKey,
 		DD_DATABASE_URL:                       dbURL,
-		DD_ALLOWED_HOSTS:                      i.Settings.Allowed.Hosts,
+		DD_ALLOWED_HOSTS:                      strings.Join(allowedHosts(&i.Install, strings.Split(i.Settings.Allowed.Hosts, ",")...), ","),
 		DD_WHITENOISE:                         i.Settings.Whitenoise,
 		DD_TIME_ZONE:                          i.Settings.Time.Zone,
 		DD_TRACK_MIGRATIONS:                   i.Settings.Track.Migrations,
@@ -162,25 +178,52 @@ func genAndWriteEnv(i *config.DojoConfig, dbURL string) {
 		DD_PORT_SCAN_EXTERNAL_UNIT_EMAIL_LIST: i.Settings.Port.Scan.External.Unit.Email.List,
 		DD_PORT_SCAN_SOURCE_IP:                i.Settings.Port.Scan.Source.IP,
 	}
+}
 
-	// Create a template based on the text above
-	t := template.Must(template.New("envProd").Parse(envProd))
+// envTmpl is the env.prod file read by DefectDojo's settings.py
+var envTmpl = template.Must(template.New("envProd").Parse(envProd))
+
+// renderEnv writes env.prod with the values in env to w
+func renderEnv(w io.Writer, env envVals) error {
+	return envTmpl.Execute(w, env)
+}
 
-	// Open a file to write the contents of the parsed template
-	fmt.Printf("Location of env file is %+v\n", i.Install.Root, "/django-DefectDojo/dojo/settings/.env.prod")
-	f, err := os.Create(i.Install.Root + "/django-DefectDojo/dojo/settings/.env.prod")
+// genAndWriteEnv writes the .env.prod read by settings.py, a failure is returned as a *dojoerr.FileError
+func genAndWriteEnv(i *config.DojoConfig, dbURL string) error {
+	envFile := settingsFile(&i.Install, ".env.prod")
+
+	// Generate random values for keys which weren't configured
+	secretKey, err := envKey(i.Settings.Secret.Key)
+	if err != nil {
+		return &dojoerr.FileError{Path: envFile, Err: fmt.Errorf("unable to generate the secret key: %w", err)}
+	}
+	credentialKey, err := envKey(i.Settings.Credential.AES.B256.Key)
+	if err != nil {
+		return &dojoerr.FileError{Path: envFile, Err: fmt.Errorf("unable to generate the credential key: %w", err)}
+	}
+
+	// Open a file to write the contents of the parsed template, only its owner can read the keys and DB password in it
+	f, err := os.OpenFile(envFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return &dojoerr.FileError{Path: envFile, Err: err}
+	}
+	// OpenFile leaves the mode of an existing file alone
+	err = f.Chmod(0600)
 	if err != nil {
-		errorMsg("Unable to create .env.prod file for settings.py configuration")
-		os.Exit(1)
+		f.Close()
+		return &dojoerr.FileError{Path: envFile, Err: err}
 	}
-	defer f.Close()
 
 	// Make substitutions in the template
-	err = t.Execute(f, env)
+	err = renderEnv(f, envValues(i, dbURL, secretKey, credentialKey))
+	if err != nil {
+		f.Close()
+		return &dojoerr.FileError{Path: envFile, Err: err}
+	}
+	err = f.Close()
 	if err != nil {
-		errorMsg("Failed to create .env.prod from template")
-		os.Exit(1)
+		return &dojoerr.FileError{Path: envFile, Err: err}
 	}
 
-	return
+	return nil
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+		extra []string
+		want  []string
+	}{
+		{"defaults", nil, nil, []string{"localhost", "127.0.0.1", "dojo.example.com"}},
+		{"configured", []string{"vuln.example.com", "10.0.0.5"}, nil, []string{"vuln.example.com", "10.0.0.5", "dojo.example.com", "localhost"}},
+		{"de-duplicated", []string{"DOJO.example.com", "localhost", " localhost "}, []string{"localhost", "127.0.0.1", ""},
+			[]string{"DOJO.example.com", "localhost", "127.0.0.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := config.InstallConfig{AllowedHosts: tt.hosts}
+			i.TLS.Hostname = "dojo.example.com"
+			got := allowedHosts(&i, tt.extra...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expecting %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNginxServerNames(t *testing.T) {
+	i := config.InstallConfig{Root: "/opt/dojo", Source: "django-DefectDojo", AllowedHosts: []string{"vuln.example.com"}}
+	i.TLS.Hostname = "dojo.example.com"
+	out := &bytes.Buffer{}
+	err := renderNginx(out, &i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "server_name vuln.example.com dojo.example.com localhost;") {
+		t.Errorf("Expecting every allowed host as a server_name, got:\n%s", out)
+	}
+}
+
+func TestGenAndWriteEnvError(t *testing.T) {
+	c := config.DojoConfig{}
+	c.Install.Root = t.TempDir()
+	// No source has been extracted so dojo/settings doesn't exist
+	err := genAndWriteEnv(&c, "sqlite:///dojo.db")
+	var fErr *dojoerr.FileError
+	if !errors.As(err, &fErr) {
+		t.Fatalf("Expecting a *dojoerr.FileError, got %v", err)
+	}
+	if !strings.HasSuffix(fErr.Path, ".env.prod") {
+		t.Errorf("Expecting the error to name .env.prod, got %s", fErr.Path)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mtesauro/godojo/dojoerr"
+	"golang.org/x/time/rate"
+)
+
+// Handles downloading several files at once with a cap on how many run together
+
+// fetchItem - a file for FetchAll to download
+type fetchItem struct {
+	URL      string
+	Dest     string
+	Optional bool     // If true, a failed download is logged and doesn't stop the others
+	Mirrors  []string // URLs tried in order if URL fails with a connection error or 5xx
+}
+
+// fetchErrors - every required download that failed in a FetchAll
+type fetchErrors []error
+
+func (e fetchErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns each failed download's error so errors.As can find them
+func (e fetchErrors) Unwrap() []error {
+	return e
+}
+
+// FetchAll downloads items with no more than concurrency running at once, sharing lim across all of them
+// so the bandwidth cap applies to the combined rate. The first required download to fail cancels the rest
+// and every required failure is returned, optional failures are only logged
+func FetchAll(ctx context.Context, items []fetchItem, concurrency int, lim *rate.Limiter) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs fetchErrors
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, it := range items {
+		wg.Add(1)
+		go func(it fetchItem) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			// A sibling may have failed while this one waited for a slot
+			if ctx.Err() != nil {
+				return
+			}
+			err := downloadMirrored(ctx, it, lim)
+			if err == nil {
+				return
+			}
+			if it.Optional {
+				traceMsg(fmt.Sprintf("Optional download of %s failed, error was: %+v", it.URL, err))
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// Downloads cut short by another's failure aren't failures of their own
+			if errors.Is(err, context.Canceled) && len(errs) > 0 {
+				return
+			}
+			errs = append(errs, err)
+			cancel()
+		}(it)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	// Nothing failed but the caller's context may have ended before everything ran
+	return ctx.Err()
+}
+
+// downloadMirrored downloads it from its URL then each of its mirrors in turn until one succeeds
+// Only failures a mirror could avoid move on to the next, the error from the last URL tried is returned
+func downloadMirrored(ctx context.Context, it fetchItem, lim *rate.Limiter) error {
+	urls := append([]string{it.URL}, it.Mirrors...)
+	var err error
+	for n, u := range urls {
+		traceMsg(fmt.Sprintf("Downloading %s to %s", u, it.Dest))
+		err = downloadLimited(ctx, httpClient, u, it.Dest, lim)
+		if err == nil {
+			if n > 0 {
+				Info.Printf("Downloaded %s from mirror %s", filepath.Base(it.Dest), u)
+			}
+			return nil
+		}
+		if !mirrorable(err) {
+			return err
+		}
+		traceMsg(fmt.Sprintf("Download from %s failed, error was: %+v", u, err))
+	}
+	return err
+}
+
+// mirrorable returns true if err is a download failure another host might not have - no response,
+// a broken transfer or a 5xx - rather than a missing file or the install being stopped
+func mirrorable(err error) bool {
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return dErr.StatusCode == 0 || dErr.StatusCode >= 500 || dErr.Err != nil
+}
+
+// mirrorURLs returns the URLs of file on each of mirrors, base URLs like the release archive URL
+func mirrorURLs(mirrors []string, file string) []string {
+	urls := make([]string, len(mirrors))
+	for n, m := range mirrors {
+		urls[n] = strings.TrimSuffix(m, "/") + "/" + file
+	}
+	return urls
+}
+
+// maxFetches is how many of a release's files are downloaded at once
+const maxFetches = 3
+
+// verifyChecksum checks the SHA-256 of file against the first field of sumFile, the sha256sum format
+func verifyChecksum(file string, sumFile string) error {
+	b, err := ioutil.ReadFile(sumFile)
+	if err != nil {
+		return fmt.Errorf("Unable to read checksum file %s: %w", sumFile, err)
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return fmt.Errorf("Checksum file %s is empty", sumFile)
+	}
+	got, err := hashFile(file)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, fields[0]) {
+		return fmt.Errorf("Checksum of %s is %s but %s expects %s", file, got, sumFile, fields[0])
+	}
+	return nil
+}
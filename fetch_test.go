@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestFetchAll(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+	httpClient = http.DefaultClient
+
+	var running, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	names := []string{"a", "b", "c", "d", "e"}
+	items := make([]fetchItem, len(names))
+	for i, n := range names {
+		items[i] = fetchItem{URL: srv.URL + "/" + n, Dest: filepath.Join(dir, n)}
+	}
+	err := FetchAll(context.Background(), items, 2, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, n := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, n))
+		if err != nil || string(b) != "/"+n {
+			t.Errorf("Expecting %s to hold /%s, got %q, %v", n, n, b, err)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("Expecting no more than 2 downloads at once, got %d", peak)
+	}
+}
+
+func TestFetchAllCancelsOnFailure(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+	httpClient = http.DefaultClient
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer broken.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+			w.Write([]byte("too late"))
+		}
+	}))
+	defer slow.Close()
+
+	dir := t.TempDir()
+	start := time.Now()
+	err := FetchAll(context.Background(), []fetchItem{
+		{URL: slow.URL + "/tarball", Dest: filepath.Join(dir, "tarball")},
+		{URL: broken.URL + "/sha256", Dest: filepath.Join(dir, "sha256")},
+	}, 2, nil)
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expecting the failure to cancel the slow download, took %s", time.Since(start))
+	}
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || dErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expecting the 404 as a DownloadError, got %v", err)
+	}
+	if n := len(err.(fetchErrors)); n != 1 {
+		t.Errorf("Expecting only the 404 to be reported, got %d errors: %v", n, err)
+	}
+}
+
+func TestFetchAllOptional(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+	httpClient = http.DefaultClient
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tarball.asc" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("release"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	err := FetchAll(context.Background(), []fetchItem{
+		{URL: srv.URL + "/tarball", Dest: filepath.Join(dir, "tarball")},
+		{URL: srv.URL + "/tarball.asc", Dest: filepath.Join(dir, "tarball.asc"), Optional: true},
+	}, 2, nil)
+	if err != nil {
+		t.Errorf("Expecting a failed optional download to be ignored, got %v", err)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	dir := t.TempDir()
+	tb := filepath.Join(dir, "1.5.3.1.tar.gz")
+	writeFile(t, tb, "release")
+	sum := sha256.Sum256([]byte("release"))
+	writeFile(t, tb+".sha256", hex.EncodeToString(sum[:])+"  1.5.3.1.tar.gz\n")
+	if err := verifyChecksum(tb, tb+".sha256"); err != nil {
+		t.Errorf("Unexpected error for a matching checksum: %v", err)
+	}
+	writeFile(t, tb+".sha256", "deadbeef  1.5.3.1.tar.gz\n")
+	if err := verifyChecksum(tb, tb+".sha256"); err == nil {
+		t.Errorf("Expecting an error for a mismatched checksum")
+	}
+}
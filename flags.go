@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles command-line flags which override the config file and DD_ environmental variables
+
+// flagKeys maps each command-line flag to the config key it overrides
+var flagKeys = map[string]string{
+	"ignore-compat":        "Install.IgnoreCompat",
+	"http-trace":           "Install.HTTPTrace",
+	"runtime-config":       "Install.RuntimeConfigPath",
+	"container":            "Install.Container",
+	"interactive":          "Install.Prompt",
+	"allow-weak-passwords": "Install.AllowWeakPasswords",
+	"dry-run":              "Install.DryRun",
+	"no-banner":            "Install.NoBanner",
+	"yes":                  "Install.AssumeYes",
+	"result-file":          "Install.ResultFile",
+	"metrics-file":         "Install.MetricsFile",
+	"keep-going":           "Install.KeepGoing",
+	"skip-root-check":      "Install.SkipRootCheck",
+	"output":               "Install.Output",
+	"color":                "Install.Color",
+	"no-color":             "Install.NoColor",
+}
+
+// installFlags sets up the flags accepted by the installer
+func installFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("godojo", pflag.ContinueOnError)
+	fs.String("config", "", "Path to the config file, the extension sets the format - yml, yaml, json or toml")
+	fs.String("config-url", "", "URL of a config to merge over the config file, ENV variables and flags still override it")
+	fs.String("config-url-auth", "", "Authorization header sent when fetching --config-url, DD_CONFIG_URL_AUTH also works")
+	fs.Bool("interactive", false, "Prompt for the required config values - the default without a config file when run from a terminal")
+	fs.Bool("allow-weak-passwords", false, "Allow empty or weak DB and admin passwords - for development installs only")
+	fs.BoolP("yes", "y", false, "Answer yes to every confirmation instead of asking, for unattended runs")
+	fs.Bool("dry-run", false, "Log the OS commands the install would run instead of running them")
+	fs.Bool("keep-going", false, "Warn and continue if an optional step like the frontend build or nginx config fails")
+	fs.Bool("no-banner", false, "Don't print the DefectDojo banner, status output is unchanged")
+	fs.String("output", "", "Console output - quiet, progress for only progress bars and the summary, or verbose")
+	fs.String("color", "", "Color the console output - always, never or auto (the default) which colors a terminal unless NO_COLOR or CLICOLOR=0 is set")
+	fs.Lookup("color").NoOptDefVal = "always"
+	fs.Bool("no-color", false, "Never color the console output, the same as setting NO_COLOR")
+	// --force-color is the spelling some tools use for --color=always
+	fs.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "force-color" {
+			name = "color"
+		}
+		return pflag.NormalizedName(name)
+	})
+	fs.Bool("ignore-compat", false, "Install even if the DefectDojo version isn't known to work on this OS")
+	fs.Bool("http-trace", false, "Log DNS, connection, TLS, and timing details of downloads - requires Trace to be on")
+	fs.String("result-file", "", "Write the outcome of the install as JSON to this path, even if the install fails")
+	fs.String("metrics-file", "", "Write the install outcome and step durations to this .prom file for node_exporter's textfile collector")
+	fs.String("runtime-config", "", "Path to write the runtime config to, defaults to the log directory")
+	fs.String("container", "", "Force container mode on, skipping service management and softening the root check")
+	fs.Lookup("container").NoOptDefVal = "true"
+	fs.Bool("skip-root-check", false, "Run without root, with a warning, for installs where the needed permissions are already in place")
+	fs.Bool("no-container", false, "Force container mode off even if a container is detected")
+
+	return fs
+}
+
+// bindFlags ties the parsed flags to their config keys so flags win over the config file and ENV variables
+func bindFlags(v *viper.Viper, fs *pflag.FlagSet) error {
+	for name, key := range flagKeys {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		err := v.BindPFlag(key, f)
+		if err != nil {
+			return err
+		}
+	}
+	// --no-container is the negative of --container so it's set directly rather than bound
+	if f := fs.Lookup("no-container"); f != nil && f.Changed {
+		v.Set("Install.Container", "false")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles installing Node.js and building DefectDojo's frontend assets with yarn
+
+// minNodeMajor is the oldest major version of Node.js DefectDojo's components build with
+const minNodeMajor = 12
+
+// nodeVersion returns the output of 'node --version' - a var so tests can fake an installed Node
+var nodeVersion = func() (string, error) {
+	out, err := exec.Command("node", "--version").Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// nodeMajor returns the major version from 'node --version' output like v12.18.3, 0 if it can't be parsed
+func nodeMajor(v string) int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	m, err := strconv.Atoi(strings.Split(v, ".")[0])
+	if err != nil {
+		return 0
+	}
+	return m
+}
+
+// frontendCmds returns the commands for building the frontend in the components directory comp
+// installing Node.js first if installNode is true
+func frontendCmds(comp string, installNode bool, build bool) [][]string {
+	cmds := [][]string{}
+	if installNode {
+		cmds = append(cmds,
+			[]string{"bash", "-c", "curl -sL " + NodeURL + " | bash -"},
+			[]string{"apt-get", "install", "-y", "nodejs"},
+		)
+	}
+	cmds = append(cmds, []string{"yarn", "--cwd", comp, "install"})
+	if build {
+		cmds = append(cmds, []string{"yarn", "--cwd", comp, "run", "build"})
+	}
+	return cmds
+}
+
+// hasBuildScript returns true if the package.json in dir defines a build script
+func hasBuildScript(dir string) bool {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+	pkg := struct {
+		Scripts map[string]string `json:"scripts"`
+	}{}
+	if json.Unmarshal(b, &pkg) != nil {
+		return false
+	}
+	_, ok := pkg.Scripts["build"]
+	return ok
+}
+
+// setupFrontend makes sure a suitable Node.js is installed then installs and builds DefectDojo's frontend assets
+func setupFrontend(ctx context.Context, i *config.InstallConfig) error {
+	if i.SkipFrontend {
+		statusMsg("frontend.skip")
+		return nil
+	}
+
+	installNode := true
+	v, err := nodeVersion()
+	if err == nil && nodeMajor(v) >= minNodeMajor {
+		statusMsg("frontend.node-ok", v)
+		installNode = false
+	} else if err == nil {
+		statusMsg("frontend.node-old", v, minNodeMajor)
+	}
+
+	comp := filepath.Join(i.Root, i.Source, "components")
+	for _, c := range frontendCmds(comp, installNode, hasBuildScript(comp)) {
+		err = runCmd(ctx, c[0], c[1:]...)
+		if err != nil {
+			return fmt.Errorf("Unable to build the DefectDojo frontend: %w", err)
+		}
+	}
+	statusMsg("frontend.done")
+	return nil
+}
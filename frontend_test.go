@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// recordCmds replaces runCmd for the test and returns the commands it was asked to run
+func recordCmds(t *testing.T) *[]string {
+	saved := runCmd
+	t.Cleanup(func() { runCmd = saved })
+	ran := []string{}
+	runCmd = func(ctx context.Context, name string, args ...string) error {
+		ran = append(ran, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+	return &ran
+}
+
+// fakeNode makes nodeVersion report v for the test
+func fakeNode(t *testing.T, v string, err error) {
+	saved := nodeVersion
+	t.Cleanup(func() { nodeVersion = saved })
+	nodeVersion = func() (string, error) { return v, err }
+}
+
+func TestFrontendCmds(t *testing.T) {
+	got := frontendCmds("/opt/dojo/django-DefectDojo/components", true, true)
+	want := [][]string{
+		{"bash", "-c", "curl -sL " + NodeURL + " | bash -"},
+		{"apt-get", "install", "-y", "nodejs"},
+		{"yarn", "--cwd", "/opt/dojo/django-DefectDojo/components", "install"},
+		{"yarn", "--cwd", "/opt/dojo/django-DefectDojo/components", "run", "build"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected commands:\n got  %q\n want %q", got, want)
+	}
+}
+
+func TestSetupFrontendNodePresent(t *testing.T) {
+	ran := recordCmds(t)
+	fakeNode(t, "v12.18.3", nil)
+	root := t.TempDir()
+	comp := filepath.Join(root, "django-DefectDojo", "components")
+	i := config.InstallConfig{Root: root, Source: "django-DefectDojo"}
+
+	err := setupFrontend(context.Background(), &i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"yarn --cwd " + comp + " install"}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("Expecting only yarn to run with Node present, got %q", *ran)
+	}
+}
+
+func TestSetupFrontendOldNode(t *testing.T) {
+	ran := recordCmds(t)
+	fakeNode(t, "v8.10.0", nil)
+	i := config.InstallConfig{Root: t.TempDir(), Source: "django-DefectDojo"}
+	if err := setupFrontend(context.Background(), &i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(*ran) != 3 || !strings.HasPrefix((*ran)[1], "apt-get install -y nodejs") {
+		t.Errorf("Expecting Node.js to be installed for an old version, got %q", *ran)
+	}
+}
+
+func TestSetupFrontendSkipAndBuild(t *testing.T) {
+	ran := recordCmds(t)
+	fakeNode(t, "v14.0.0", nil)
+	i := config.InstallConfig{Root: t.TempDir(), Source: "src", SkipFrontend: true}
+	if err := setupFrontend(context.Background(), &i); err != nil || len(*ran) != 0 {
+		t.Errorf("Expecting nothing to run when skipped, got %q, %v", *ran, err)
+	}
+
+	// A build script in package.json adds the asset build
+	i.SkipFrontend = false
+	comp := filepath.Join(i.Root, "src", "components")
+	writeFile(t, filepath.Join(comp, "package.json"), `{"scripts": {"build": "gulp"}}`)
+	if err := setupFrontend(context.Background(), &i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (*ran)[len(*ran)-1] != "yarn --cwd "+comp+" run build" {
+		t.Errorf("Expecting the asset build to run, got %q", *ran)
+	}
+}
+
+// writeFile writes body to p creating its directory
+func writeFile(t *testing.T, p string, body string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(p), 0755)
+	if err := ioutil.WriteFile(p, []byte(body), 0644); err != nil {
+		t.Fatalf("Unable to write %s: %v", p, err)
+	}
+}
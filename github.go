@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// Handles calls to the GitHub API e.g. listing DefectDojo releases
+
+// Upstream DefectDojo repo used when RepoOwner or RepoName isn't set
+const (
+	defaultRepoOwner = "DefectDojo"
+	defaultRepoName  = "django-DefectDojo"
+)
+
+// repoPath returns the owner/name of the GitHub repo DefectDojo is installed from, upstream unless a fork is configured
+func repoPath(i *config.InstallConfig) string {
+	return orDefault(i.RepoOwner, defaultRepoOwner) + "/" + orDefault(i.RepoName, defaultRepoName)
+}
+
+// releaseURL returns the base URL of the repo's release archives, the version and extension are appended to it
+func releaseURL(i *config.InstallConfig) string {
+	return GitHubURL + repoPath(i) + "/archive/"
+}
+
+// repoCloneURL returns the https URL of the repo for source installs
+func repoCloneURL(i *config.InstallConfig) string {
+	return GitHubURL + repoPath(i) + ".git"
+}
+
+// apiURL returns the base URL of the repo in the GitHub API e.g. for its releases
+func apiURL(i *config.InstallConfig) string {
+	return APIURL + repoPath(i) + "/"
+}
+
+// setGitHubAuth adds the configured GitHub token to a request bound for the GitHub API
+// Unauthenticated requests are limited to 60 an hour per IP so a token helps busy networks
+func setGitHubAuth(req *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	req.Header.Set("Authorization", "token "+token)
+}
+
+// githubGet makes a GET request to the GitHub API at url and returns the response body
+func githubGet(ctx context.Context, c httpDoer, url string, token string) ([]byte, error) {
+	traceMsg(fmt.Sprintf("Calling the GitHub API at %+v", url))
+	req, err := newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return nil, &dojoerr.DownloadError{URL: url, Err: err}
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	setGitHubAuth(req, token)
+
+	resp, err := doRetryAfter(ctx, c, req)
+	if err != nil {
+		traceMsg(fmt.Sprintf("Error calling the GitHub API was: %+v", err))
+		var dErr *dojoerr.DownloadError
+		if errors.As(err, &dErr) {
+			return nil, err
+		}
+		return nil, &dojoerr.DownloadError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	traceMsg(fmt.Sprintf("Status of the GitHub API response was %+v", resp.Status))
+	switch {
+	case resp.StatusCode == http.StatusForbidden && token == "":
+		// Most likely the unauthenticated rate limit
+		return nil, &dojoerr.DownloadError{URL: url, StatusCode: resp.StatusCode,
+			Err: fmt.Errorf("GitHub API rate limited this request, set Install.GitHubToken or DD_GITHUB_TOKEN to raise the limit")}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &dojoerr.DownloadError{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &dojoerr.DownloadError{URL: url, StatusCode: resp.StatusCode, Err: err}
+	}
+	return body, nil
+}
+
+// defaultMaxRetryAfter is the longest a request waits on GitHub's Retry-After when MaxRetryAfter isn't set
+const defaultMaxRetryAfter = 2 * time.Minute
+
+// Total time a request may wait on Retry-After before failing, replaced once the config is loaded
+var maxRetryAfter = defaultMaxRetryAfter
+
+// retryAfterAttempts caps how many times a request is resent on Retry-After, so a wait of 0 or a date
+// already passed can't resend it forever
+const retryAfterAttempts = 5
+
+// retryAfter returns how long resp asks to wait before trying again if it's a 403 or 429 with a Retry-After
+// header in seconds or as an HTTP date, how GitHub answers when its secondary rate limits are hit
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(h); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// doRetryAfter sends req with c, sending it again after the wait GitHub asks for with Retry-After until the
+// waits would add up to more than maxRetryAfter or it's been resent retryAfterAttempts times, when the
+// secondary rate limit is explained instead
+func doRetryAfter(ctx context.Context, c httpDoer, req *http.Request) (*http.Response, error) {
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		resp, err := c.Do(req)
+		if err != nil {
+			return resp, err
+		}
+		wait, ok := retryAfter(resp)
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if maxRetryAfter <= 0 || waited+wait > maxRetryAfter {
+			return nil, &dojoerr.DownloadError{URL: req.URL.String(), StatusCode: resp.StatusCode,
+				Err: fmt.Errorf("GitHub's secondary rate limit asked to wait %s which is more than MaxRetryAfter %s allows, "+
+					"set Install.GitHubToken or DD_GITHUB_TOKEN, spread out installs or raise MaxRetryAfter", wait, maxRetryAfter)}
+		}
+		if attempt > retryAfterAttempts {
+			return nil, &dojoerr.DownloadError{URL: req.URL.String(), StatusCode: resp.StatusCode,
+				Err: fmt.Errorf("GitHub's secondary rate limit still applied after %d retries, "+
+					"set Install.GitHubToken or DD_GITHUB_TOKEN or spread out installs", retryAfterAttempts)}
+		}
+		statusMsg("github.throttled", wait)
+		select {
+		case <-ctx.Done():
+			return nil, &dojoerr.DownloadError{URL: req.URL.String(), Err: ctx.Err()}
+		case <-time.After(wait):
+		}
+		waited += wait
+	}
+}
+
+// defaultReleaseCacheTTL is how long a ReleaseCacheFile entry is reused when ReleaseCacheTTL isn't set
+const defaultReleaseCacheTTL = 10 * time.Minute
+
+// ReleaseCache - GitHub API responses fetched once and shared by everything in a run needing release data,
+// also kept in File for TTL if it's set so repeated runs don't spend the rate limit either
+type ReleaseCache struct {
+	File string        // JSON file responses are saved in across runs, empty caches in memory only
+	TTL  time.Duration // How long a response in File is reused
+	mu   sync.Mutex
+	mem  map[string][]byte
+}
+
+// cachedResponse - a response saved in a ReleaseCache's File
+type cachedResponse struct {
+	Fetched time.Time `json:"fetched"`
+	Body    string    `json:"body"`
+}
+
+// releaseCache is shared by every call to the GitHub releases API, replaced once the config is loaded
+var releaseCache = &ReleaseCache{}
+
+// newReleaseCache returns an empty ReleaseCache using the ReleaseCacheFile and ReleaseCacheTTL of i
+func newReleaseCache(i *config.InstallConfig) *ReleaseCache {
+	ttl := i.ReleaseCacheTTL
+	if ttl <= 0 {
+		ttl = defaultReleaseCacheTTL
+	}
+	return &ReleaseCache{File: i.ReleaseCacheFile, TTL: ttl}
+}
+
+// Get returns the body of the GitHub API response for url, only calling the API the first time url is asked
+// for in a run and not at all if File holds a response younger than TTL
+func (rc *ReleaseCache) Get(ctx context.Context, c httpDoer, url string, token string) ([]byte, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if body, ok := rc.mem[url]; ok {
+		traceMsg("Using the cached GitHub API response for " + url)
+		return body, nil
+	}
+	if rc.mem == nil {
+		rc.mem = map[string][]byte{}
+	}
+
+	disk := rc.load()
+	if r, ok := disk[url]; ok && time.Since(r.Fetched) < rc.TTL {
+		traceMsg(fmt.Sprintf("Using the GitHub API response for %s saved in %s at %s", url, rc.File, r.Fetched.Format(time.RFC3339)))
+		rc.mem[url] = []byte(r.Body)
+		return rc.mem[url], nil
+	}
+
+	body, err := githubGet(ctx, c, url, token)
+	if err != nil {
+		return nil, err
+	}
+	traceMsg(fmt.Sprintf("GitHub API response from %s was: %s", url, body))
+	rc.mem[url] = body
+	if rc.File != "" {
+		disk[url] = cachedResponse{Fetched: time.Now(), Body: string(body)}
+		rc.save(disk)
+	}
+	return body, nil
+}
+
+// load returns the responses saved in File, none if there isn't a File or it can't be read
+func (rc *ReleaseCache) load() map[string]cachedResponse {
+	disk := map[string]cachedResponse{}
+	if rc.File == "" {
+		return disk
+	}
+	b, err := ioutil.ReadFile(rc.File)
+	if err != nil {
+		return disk
+	}
+	err = json.Unmarshal(b, &disk)
+	if err != nil {
+		traceMsg(fmt.Sprintf("Ignoring the unreadable release cache %s, error was: %+v", rc.File, err))
+		return map[string]cachedResponse{}
+	}
+	return disk
+}
+
+// save writes disk to File, a cache that can't be written only costs a later run an API call
+func (rc *ReleaseCache) save(disk map[string]cachedResponse) {
+	b, err := json.Marshal(disk)
+	if err == nil {
+		err = ioutil.WriteFile(rc.File, b, 0600)
+	}
+	if err != nil {
+		traceMsg(fmt.Sprintf("Unable to write the release cache %s, error was: %+v", rc.File, err))
+	}
+}
+
+// githubRelease - the parts of a release from the GitHub releases API used by godojo
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+// githubAsset - a file attached to a GitHub release
+type githubAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// releaseByTag returns the DefectDojo release tagged tag of the configured repo from the GitHub releases API
+func releaseByTag(ctx context.Context, c httpDoer, i *config.InstallConfig, tag string) (*githubRelease, error) {
+	url := apiURL(i) + "releases/tags/" + tag
+	body, err := releaseCache.Get(ctx, c, url, i.GitHubToken)
+	if err != nil {
+		return nil, err
+	}
+	r := &githubRelease{}
+	err = json.Unmarshal(body, r)
+	if err != nil {
+		return nil, &dojoerr.DownloadError{URL: url, StatusCode: http.StatusOK, Err: fmt.Errorf("unable to parse the release: %w", err)}
+	}
+	return r, nil
+}
+
+// findAsset returns the first asset of r with a name matching the glob pattern or an error listing the
+// assets r does have
+func findAsset(r *githubRelease, pattern string) (githubAsset, error) {
+	names := []string{}
+	for _, a := range r.Assets {
+		ok, err := path.Match(pattern, a.Name)
+		if err != nil {
+			return githubAsset{}, &dojoerr.ConfigError{Field: "Install.AssetPattern", Msg: err.Error()}
+		}
+		if ok {
+			return a, nil
+		}
+		names = append(names, a.Name)
+	}
+	have := "it has no assets"
+	if len(names) > 0 {
+		have = "its assets are " + strings.Join(names, ", ")
+	}
+	return githubAsset{}, &dojoerr.ConfigError{Field: "Install.AssetPattern",
+		Msg: fmt.Sprintf("no asset of release %s matches %q, %s", r.TagName, pattern, have)}
+}
+
+// releaseDownloadURL returns the URL to download the configured release from, the source archive unless
+// AssetPattern is set when it's the release asset matching the pattern
+func releaseDownloadURL(ctx context.Context, c httpDoer, i *config.InstallConfig) (string, error) {
+	dwnURL, _ := releasePaths(i)
+	if i.AssetPattern == "" {
+		return dwnURL, nil
+	}
+	r, err := releaseByTag(ctx, c, i, normalizeVersion(i.Version))
+	if err != nil {
+		return "", err
+	}
+	a, err := findAsset(r, i.AssetPattern)
+	if err != nil {
+		return "", err
+	}
+	traceMsg(fmt.Sprintf("Release asset %s matches AssetPattern %s", a.Name, i.AssetPattern))
+	return a.URL, nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestGitHubGetToken(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	_, err := githubGet(context.Background(), ts.Client(), ts.URL+"/releases", "s3cr3t-t0ken")
+	if err != nil {
+		t.Fatalf("Unexpected error calling fake GitHub API: %v", err)
+	}
+	if got != "token s3cr3t-t0ken" {
+		t.Errorf("Expecting Authorization header 'token s3cr3t-t0ken', got '%s'", got)
+	}
+
+	// No token configured means no header at all
+	_, err = githubGet(context.Background(), ts.Client(), ts.URL+"/releases", "")
+	if err != nil {
+		t.Fatalf("Unexpected error calling fake GitHub API: %v", err)
+	}
+	if got != "" {
+		t.Errorf("Expecting no Authorization header without a token, got '%s'", got)
+	}
+}
+
+func TestGitHubGetRateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	_, err := githubGet(context.Background(), ts.Client(), ts.URL+"/releases", "")
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || dErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expecting a 403 DownloadError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "DD_GITHUB_TOKEN") {
+		t.Errorf("Expecting the rate limit error to suggest a token, got %s", err.Error())
+	}
+}
+
+func TestRedactToken(t *testing.T) {
+	saved := sensStr
+	defer func() { sensStr = saved }()
+
+	sensStr[12] = "s3cr3t-t0ken"
+	got := Redactatron("Authorization: token s3cr3t-t0ken", true)
+	if strings.Contains(got, "s3cr3t-t0ken") {
+		t.Errorf("Expecting the GitHub token to be redacted, got %s", got)
+	}
+}
+
+// cannedRelease is a trimmed GitHub releases API response for release 1.5.3.1
+const cannedRelease = `{
+  "tag_name": "1.5.3.1",
+  "name": "1.5.3.1",
+  "assets": [
+    {"name": "defectdojo-1.5.3.1.tar.gz.sha256", "browser_download_url": "https://github.com/DefectDojo/django-DefectDojo/releases/download/1.5.3.1/defectdojo-1.5.3.1.tar.gz.sha256"},
+    {"name": "defectdojo-1.5.3.1.tar.gz", "browser_download_url": "https://github.com/DefectDojo/django-DefectDojo/releases/download/1.5.3.1/defectdojo-1.5.3.1.tar.gz"}
+  ]
+}`
+
+// useReleaseCache replaces releaseCache with rc until the test ends
+func useReleaseCache(t *testing.T, rc *ReleaseCache) {
+	saved := releaseCache
+	t.Cleanup(func() { releaseCache = saved })
+	releaseCache = rc
+}
+
+func TestReleaseDownloadURL(t *testing.T) {
+	useReleaseCache(t, &ReleaseCache{})
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/repos/DefectDojo/django-DefectDojo/releases/tags/1.5.3.1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(cannedRelease))
+	}))
+	defer ts.Close()
+	fake := &redirectDoer{ts: ts}
+
+	// No pattern is the source archive without calling the API
+	i := &config.InstallConfig{Version: "v1.5.3.1"}
+	got, err := releaseDownloadURL(context.Background(), fake, i)
+	if err != nil || got != "https://github.com/DefectDojo/django-DefectDojo/archive/1.5.3.1.tar.gz" || len(fake.hits) != 0 {
+		t.Errorf("Expecting the source archive without an API call, got %s, %v after %v", got, err, fake.hits)
+	}
+
+	i.AssetPattern = "defectdojo-*.tar.gz"
+	got, err = releaseDownloadURL(context.Background(), fake, i)
+	want := "https://github.com/DefectDojo/django-DefectDojo/releases/download/1.5.3.1/defectdojo-1.5.3.1.tar.gz"
+	if err != nil || got != want {
+		t.Errorf("Expecting %s, got %s, %v", want, got, err)
+	}
+
+	i.AssetPattern = "*.whl"
+	_, err = releaseDownloadURL(context.Background(), fake, i)
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.AssetPattern" || !strings.Contains(err.Error(), "defectdojo-1.5.3.1.tar.gz.sha256") {
+		t.Errorf("Expecting a ConfigError listing the release's assets, got %v", err)
+	}
+
+	i.Version = "9.9.9"
+	_, err = releaseDownloadURL(context.Background(), fake, i)
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || dErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expecting a 404 DownloadError for a missing release, got %v", err)
+	}
+}
+
+func TestReleaseCacheFetchesOnce(t *testing.T) {
+	useReleaseCache(t, &ReleaseCache{})
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(cannedRelease))
+	}))
+	defer ts.Close()
+	fake := &redirectDoer{ts: ts}
+
+	// Every consumer of the release's data in a run shares the one response, even at the same time
+	i := &config.InstallConfig{Version: "1.5.3.1", AssetPattern: "defectdojo-*.tar.gz"}
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := releaseDownloadURL(context.Background(), fake, i); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	r, err := releaseByTag(context.Background(), fake, i, "1.5.3.1")
+	if err != nil || r.TagName != "1.5.3.1" {
+		t.Errorf("Expecting the cached release, got %+v, %v", r, err)
+	}
+	if hits != 1 {
+		t.Errorf("Expecting the releases API to be called once, got %d calls", hits)
+	}
+}
+
+func TestReleaseCacheFile(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(cannedRelease))
+	}))
+	defer ts.Close()
+	fake := &redirectDoer{ts: ts}
+	file := filepath.Join(t.TempDir(), "releases.json")
+	i := &config.InstallConfig{ReleaseCacheFile: file}
+	url := apiURL(i) + "releases/tags/1.5.3.1"
+
+	// A later run reuses the saved response while it's younger than the TTL
+	for run := 0; run < 2; run++ {
+		body, err := newReleaseCache(i).Get(context.Background(), fake, url, "")
+		if err != nil || string(body) != cannedRelease {
+			t.Fatalf("Expecting the release from run %d, got %q, %v", run, body, err)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("Expecting the second run to use %s, got %d API calls", file, hits)
+	}
+
+	i.ReleaseCacheTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := newReleaseCache(i).Get(context.Background(), fake, url, ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hits != 2 {
+		t.Errorf("Expecting an expired response to be fetched again, got %d API calls", hits)
+	}
+}
+
+func TestForkURLs(t *testing.T) {
+	useReleaseCache(t, &ReleaseCache{})
+	i := &config.InstallConfig{Version: "1.5.3.1", Root: "/opt/dojo", RepoOwner: "acme", RepoName: "dojo-fork"}
+
+	dwnURL, _ := releasePaths(i)
+	tests := map[string]string{
+		"release": dwnURL,
+		"clone":   cloneURL(i),
+		"api":     apiURL(i),
+	}
+	want := map[string]string{
+		"release": "https://github.com/acme/dojo-fork/archive/1.5.3.1.tar.gz",
+		"clone":   "https://github.com/acme/dojo-fork.git",
+		"api":     "https://api.github.com/repos/acme/dojo-fork/",
+	}
+	for name, got := range tests {
+		if got != want[name] {
+			t.Errorf("Expecting the %s URL %s, got %s", name, want[name], got)
+		}
+	}
+
+	// Release assets are looked up in the fork's releases too
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/dojo-fork/releases/tags/1.5.3.1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(cannedRelease))
+	}))
+	defer ts.Close()
+	i.AssetPattern = "defectdojo-*.tar.gz"
+	_, err := releaseDownloadURL(context.Background(), &redirectDoer{ts: ts}, i)
+	if err != nil {
+		t.Errorf("Expecting the asset from the fork's release, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	saved := maxRetryAfter
+	defer func() { maxRetryAfter = saved }()
+	maxRetryAfter = 5 * time.Second
+
+	var hits int32
+	var first time.Time
+	var gap time.Duration
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		gap = time.Since(first)
+		w.Write([]byte(cannedRelease))
+	}))
+	defer ts.Close()
+
+	body, err := githubGet(context.Background(), ts.Client(), ts.URL+"/repos/DefectDojo/django-DefectDojo/releases/tags/1.5.3.1", "")
+	if err != nil || string(body) != cannedRelease {
+		t.Fatalf("Expecting the release after a retry, got %q, %v", body, err)
+	}
+	if hits != 2 || gap < time.Second {
+		t.Errorf("Expecting a second request at least 1s after the first, got %d requests %s apart", hits, gap)
+	}
+
+	// A wait longer than the cap fails straight away explaining the limit
+	maxRetryAfter = time.Second
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer slow.Close()
+	err = downloadFile(context.Background(), slow.Client(), slow.URL+"/archive/1.5.3.1.tar.gz", filepath.Join(t.TempDir(), "dl"), 0)
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || dErr.StatusCode != http.StatusForbidden || !strings.Contains(err.Error(), "secondary rate limit") {
+		t.Errorf("Expecting a DownloadError explaining the secondary rate limit, got %v", err)
+	}
+}
+
+func TestRetryAfterZero(t *testing.T) {
+	saved := maxRetryAfter
+	defer func() { maxRetryAfter = saved }()
+	maxRetryAfter = 5 * time.Second
+
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	_, err := githubGet(context.Background(), ts.Client(), ts.URL+"/repos/DefectDojo/django-DefectDojo/releases/tags/1.5.3.1", "")
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) || !strings.Contains(err.Error(), "secondary rate limit") {
+		t.Errorf("Expecting a DownloadError explaining the secondary rate limit, got %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != retryAfterAttempts+1 {
+		t.Errorf("Expecting %d requests, got %d", retryAfterAttempts+1, n)
+	}
+
+	// MaxRetryAfter 0 never waits so never resends
+	maxRetryAfter = 0
+	atomic.StoreInt32(&hits, 0)
+	_, err = githubGet(context.Background(), ts.Client(), ts.URL+"/repos/DefectDojo/django-DefectDojo/releases/tags/1.5.3.2", "")
+	if err == nil || atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("Expecting a single request with MaxRetryAfter 0, got %d and %v", hits, err)
+	}
+}
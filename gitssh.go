@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	gossh "golang.org/x/crypto/ssh"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// Handles the auth used to clone the DefectDojo source, SSH clone URLs use a key and check the host's key
+
+// cloneURL returns the git URL source installs clone, the configured CloneURL or DefectDojo's GitHub repo
+func cloneURL(i *config.InstallConfig) string {
+	if i.CloneURL != "" {
+		return i.CloneURL
+	}
+	return repoCloneURL(i)
+}
+
+// sshUser returns the user in an SSH clone URL e.g. git for git@git.example.com:dojo.git, git if there isn't one
+func sshUser(u string) string {
+	if strings.HasPrefix(u, "ssh://") {
+		p, err := url.Parse(u)
+		if err == nil && p.User != nil && p.User.Username() != "" {
+			return p.User.Username()
+		}
+		return "git"
+	}
+	if at := strings.Index(u, "@"); at > 0 {
+		return u[:at]
+	}
+	return "git"
+}
+
+// cloneAuth returns the auth for cloning the CloneURL, nil for https URLs which go-git handles on its own
+// SSH URLs authenticate with SSHKey and check the server against KnownHosts
+func cloneAuth(i *config.InstallConfig) (transport.AuthMethod, error) {
+	u := cloneURL(i)
+	if !config.IsSSHURL(u) {
+		return nil, nil
+	}
+	auth, err := ssh.NewPublicKeysFromFile(sshUser(u), i.SSHKey, i.SSHKeyPass)
+	if err != nil {
+		// The error names the key file so it's redacted along with the passphrase
+		return nil, &dojoerr.ConfigError{Field: "Install.SSHKey", Msg: "unable to load the SSH key: " + Redactatron(err.Error(), true)}
+	}
+	auth.HostKeyCallback, err = hostKeyCallback(i)
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// hostKeyCallback returns the check of an SSH server's host key against KnownHosts, or against
+// ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts if KnownHosts is empty
+// Only SSHInsecureHostKey accepts any host, which Validate warns about
+func hostKeyCallback(i *config.InstallConfig) (gossh.HostKeyCallback, error) {
+	if i.SSHInsecureHostKey {
+		traceMsg("SSHInsecureHostKey is set, accepting any SSH host key for " + i.CloneURL)
+		return gossh.InsecureIgnoreHostKey(), nil
+	}
+	files := []string{}
+	if i.KnownHosts != "" {
+		files = append(files, i.KnownHosts)
+	}
+	cb, err := ssh.NewKnownHostsCallback(files...)
+	if err != nil {
+		return nil, &dojoerr.ConfigError{Field: "Install.KnownHosts", Msg: "unable to read the known_hosts file: " + err.Error()}
+	}
+	return cb, nil
+}
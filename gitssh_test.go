@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// testSSHKey writes a new private key to dir returning its path and public key
+func testSSHKey(t *testing.T, dir string) (string, gossh.PublicKey) {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, "id_rsa")
+	writeFile(t, p, string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})))
+	pub, err := gossh.NewPublicKey(&k.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, pub
+}
+
+func TestCloneAuth(t *testing.T) {
+	dir := t.TempDir()
+	key, hostKey := testSSHKey(t, dir)
+	known := filepath.Join(dir, "known_hosts")
+	writeFile(t, known, knownhosts.Line([]string{"git.example.com"}, hostKey)+"\n")
+
+	i := &config.InstallConfig{}
+	if auth, err := cloneAuth(i); auth != nil || err != nil {
+		t.Errorf("Expecting no auth for the GitHub https URL, got %v, %v", auth, err)
+	}
+
+	i.CloneURL = "deploy@git.example.com:dojo/django-DefectDojo.git"
+	i.SSHKey = key
+	i.KnownHosts = known
+	auth, err := cloneAuth(i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pk, ok := auth.(*ssh.PublicKeys)
+	if !ok || pk.User != "deploy" || pk.HostKeyCallback == nil {
+		t.Errorf("Expecting public key auth as deploy checking host keys, got %#v", auth)
+	}
+
+	i.SSHKey = filepath.Join(dir, "missing")
+	_, err = cloneAuth(i)
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.SSHKey" {
+		t.Errorf("Expecting a ConfigError for a missing key, got %v", err)
+	}
+}
+
+func TestSSHUser(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:DefectDojo/django-DefectDojo.git":          "git",
+		"deploy@git.example.com:dojo.git":                          "deploy",
+		"ssh://builder@git.example.com:2222/dojo.git":              "builder",
+		"ssh://git.example.com/dojo.git":                           "git",
+		"https://github.com/DefectDojo/django-DefectDojo.git":      "git",
+		"ssh://git.example.com/DefectDojo/django-DefectDojo@1.git": "git",
+	}
+	for u, want := range tests {
+		if got := sshUser(u); got != want {
+			t.Errorf("Expecting the user of %s to be %s, got %s", u, want, got)
+		}
+	}
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	dir := t.TempDir()
+	_, known := testSSHKey(t, dir)
+	_, other := testSSHKey(t, t.TempDir())
+	kh := filepath.Join(dir, "known_hosts")
+	writeFile(t, kh, knownhosts.Line([]string{"git.example.com"}, known)+"\n")
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+
+	i := &config.InstallConfig{CloneURL: "git@git.example.com:dojo.git", KnownHosts: kh}
+	cb, err := hostKeyCallback(i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := cb("git.example.com:22", addr, known); err != nil {
+		t.Errorf("Expecting the known host to be accepted, got %v", err)
+	}
+	if err := cb("other.example.com:22", addr, known); err == nil {
+		t.Error("Expecting an unknown host to be rejected")
+	}
+	if err := cb("git.example.com:22", addr, other); err == nil {
+		t.Error("Expecting a known host with a different key to be rejected")
+	}
+
+	i.SSHInsecureHostKey = true
+	cb, err = hostKeyCallback(i)
+	if err != nil || cb("other.example.com:22", addr, other) != nil {
+		t.Errorf("Expecting SSHInsecureHostKey to accept any host, got %v", err)
+	}
+
+	i = &config.InstallConfig{KnownHosts: filepath.Join(dir, "missing")}
+	_, err = hostKeyCallback(i)
+	var cErr *dojoerr.ConfigError
+	if !errors.As(err, &cErr) || cErr.Field != "Install.KnownHosts" {
+		t.Errorf("Expecting a ConfigError for a missing known_hosts file, got %v", err)
+	}
+}
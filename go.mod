@@ -7,10 +7,14 @@ require (
 	github.com/go-sql-driver/mysql v1.4.1
 	github.com/google/pprof v0.0.0-20191028172815-5e965273ee43 // indirect
 	github.com/lib/pq v1.2.0
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.4.0
 	golang.org/x/arch v0.0.0-20191101135251-a0d8588395bd // indirect
+	golang.org/x/crypto v0.0.0-20190422183909-d864b10871cd
 	golang.org/x/sys v0.0.0-20190616124812-15dcb6c0061f // indirect
 	golang.org/x/text v0.3.2 // indirect
+	golang.org/x/time v0.0.0-20190308202827-9d24e82272b4
 	golang.org/x/tools/gopls v0.1.3 // indirect
 	gopkg.in/src-d/go-git.v4 v4.12.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -3,24 +3,31 @@ package main
 // TODO:
 // Add Cobra for command-line args - https://github.com/spf13/cobra
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
-	"os/user"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
 	git "gopkg.in/src-d/go-git.v4"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 )
 
 // Global vars
@@ -29,7 +36,7 @@ var (
 	version = "0.1.1"
 	// Global config struct
 	conf    config.DojoConfig
-	sensStr [12]string // Hold sensitive strings to redact
+	sensStr [16]string // Hold sensitive strings to redact
 	// For logging
 	logLocation = "logs"
 	Trace       *log.Logger
@@ -37,9 +44,21 @@ var (
 	Warning     *log.Logger
 	Error       *log.Logger
 	// For Global config flags
-	Quiet   bool
-	TraceOn bool
-	Redact  bool
+	Quiet     bool
+	Output    string // quiet, progress or verbose console output, Quiet is true unless it's verbose
+	TraceOn   bool
+	Redact    bool
+	HTTPTrace bool
+	// Running in a container, skip service management
+	ContainerMode bool
+	// Log commands instead of running them
+	DryRun bool
+	// Answer yes to every confirmation without asking
+	AssumeYes bool
+	// Continue past failures of optional install steps
+	KeepGoing bool
+	// Language of console messages
+	Lang = defaultLang
 	// Spinner FTW
 	Spin spinner.Spinner
 )
@@ -47,89 +66,120 @@ var (
 // Global Constants
 const (
 	// URLs needed by the installer
-	HelpURL    = "https://github.com/mtesauro/godojo"
-	ReleaseURL = "https://github.com/DefectDojo/django-DefectDojo/archive/"
-	CloneURL   = "https://github.com/DefectDojo/django-DefectDojo.git"
-	YarnGPG    = "https://dl.yarnpkg.com/debian/pubkey.gpg"
-	YarnRepo   = "deb https://dl.yarnpkg.com/debian/ stable main"
-	NodeURL    = "https://deb.nodesource.com/setup_6.x"
+	HelpURL   = "https://github.com/mtesauro/godojo"
+	GitHubURL = "https://github.com/"
+	APIURL    = "https://api.github.com/repos/"
+	YarnGPG   = "https://dl.yarnpkg.com/debian/pubkey.gpg"
+	YarnRepo  = "deb [arch=%s] https://dl.yarnpkg.com/debian/ stable main" // %s is replaced by HostArch()
+	NodeURL   = "https://deb.nodesource.com/setup_12.x"
 )
 
+// Exit codes returned by the installer so wrappers can tell failures apart
+const (
+	exitFailure     = 1   // Generic failure
+	exitConfig      = 2   // Bad or missing configuration
+	exitDownload    = 3   // Unable to download the DefectDojo release or source
+	exitExtract     = 4   // Unable to extract the DefectDojo release tarball
+	exitInterrupted = 130 // Install was canceled e.g. by Ctrl-C
+)
+
+// exitCode picks the installer's exit code based on the type of error returned
+func exitCode(err error) int {
+	var cErr *dojoerr.ConfigError
+	var dErr *dojoerr.DownloadError
+	var eErr *dojoerr.ExtractError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return exitInterrupted
+	case errors.As(err, &cErr):
+		return exitConfig
+	case errors.As(err, &dErr):
+		return exitDownload
+	case errors.As(err, &eErr):
+		return exitExtract
+	}
+	return exitFailure
+}
+
 // Setup logging with type appended to the log lines - this logs all types to a single file
-func logSetup(logHandler io.Writer) {
+// and, if sw isn't nil, to syslog as well
+func logSetup(logHandler io.Writer, sw syslogWriter) {
+	tw, iw, ww, ew := levelWriters(logHandler, sw)
 	// Setup logging 'levels' which can be called globally like Info.Println("Example info log")
-	Trace = log.New(logHandler, "TRACE:   ", log.Ldate|log.Ltime)
-	Info = log.New(logHandler, "INFO:    ", log.Ldate|log.Ltime)
-	Warning = log.New(logHandler, "WARNING: ", log.Ldate|log.Ltime)
-	Error = log.New(logHandler, "ERROR:   ", log.Ldate|log.Ltime)
+	Trace = log.New(tw, "TRACE:   ", log.Ldate|log.Ltime)
+	Info = log.New(iw, "INFO:    ", log.Ldate|log.Ltime)
+	Warning = log.New(ww, "WARNING: ", log.Ldate|log.Ltime)
+	Error = log.New(ew, "ERROR:   ", log.Ldate|log.Ltime)
+}
+
+// bannerText returns the installer banner pointing at HelpURL
+func bannerText() string {
+	return strings.Join([]string{
+		"        ____       ____          __     ____          _      ",
+		"       / __ \\___  / __/__  _____/ /_   / __ \\____    (_)___  ",
+		"      / / / / _ \\/ /_/ _ \\/ ___/ __/  / / / / __ \\  / / __ \\ ",
+		"     / /_/ /  __/ __/  __/ /__/ /_   / /_/ / /_/ / / / /_/ / ",
+		"    /_____/\\___/_/  \\___/\\___/\\__/  /_____/\\____/_/ /\\____/  ",
+		"                                               /___/         ",
+		"",
+		"  Welcome to goDojo, the official way to install DefectDojo.",
+		"  For more information on how goDojo does an install, see:",
+		fmt.Sprintf("  %s", HelpURL),
+		"",
+	}, "\n")
 }
 
 // Output the installer banner
-func dojoBanner() {
-	fmt.Println("        ____       ____          __     ____          _      ")
-	fmt.Println("       / __ \\___  / __/__  _____/ /_   / __ \\____    (_)___  ")
-	fmt.Println("      / / / / _ \\/ /_/ _ \\/ ___/ __/  / / / / __ \\  / / __ \\ ")
-	fmt.Println("     / /_/ /  __/ __/  __/ /__/ /_   / /_/ / /_/ / / / /_/ / ")
-	fmt.Println("    /_____/\\___/_/  \\___/\\___/\\__/  /_____/\\____/_/ /\\____/  ")
-	fmt.Println("                                               /___/         ")
-	fmt.Println("")
-	fmt.Println("  Welcome to goDojo, the official way to install DefectDojo.")
-	fmt.Println("  For more information on how goDojo does an install, see:")
-	fmt.Printf("  %s", HelpURL)
-	fmt.Println("")
+func dojoBanner(w io.Writer) {
+	fmt.Fprint(w, bannerText())
 }
 
-// Output a section message and log the same string
-func sectionMsg(s string) {
-	// Pring status message if quiet isn't set
-	if !Quiet {
-		fmt.Println("")
-		fmt.Println("==============================================================================")
-		fmt.Printf("  %s\n", s)
-		fmt.Println("==============================================================================")
-		fmt.Println("")
-	}
-	Info.Println("SECTION: " + s)
+// showBanner returns true unless the banner is turned off by quiet or progress output or NoBanner
+func showBanner(i *config.InstallConfig) bool {
+	return i.OutputMode() == "verbose" && !i.NoBanner
 }
 
-// Output a status message and log the same string
-func statusMsg(s string) {
+// Output section message id in Lang through the reporter and log it in English
+func sectionMsg(id string, args ...interface{}) {
+	reporter.Section(localize(Lang, id, args...))
+	Info.Println("SECTION: " + localize(defaultLang, id, args...))
+}
+
+// Output status message id in Lang through the reporter and log it in English
+func statusMsg(id string, args ...interface{}) {
 	// Redact sensitive info in redact is true
-	Redactatron(s, Redact)
-	// Pring status message if quiet isn't set
-	if !Quiet {
-		fmt.Printf("%s\n", s)
-	}
-	Info.Println(s)
+	reporter.Status(Redactatron(localize(Lang, id, args...), Redact))
+	Info.Println(Redactatron(localize(defaultLang, id, args...), Redact))
 }
 
-// Output a blatant error message and log the string as an error
-func errorMsg(s string) {
-	// Pring status message if quiet isn't set
-	if !Quiet {
+// Output a blatant error message id in Lang and log it in English as an error
+func errorMsg(id string, args ...interface{}) {
+	// Redact sensitive info in redact is true
+	s := Redactatron(localize(Lang, id, args...), Redact)
+	// Pring status message if quiet isn't set, progress output keeps errors as part of the summary
+	if showSummary() {
 		fmt.Println("")
 		fmt.Println("##############################################################################")
-		fmt.Printf("  ERROR: %s\n", s)
+		fmt.Printf("  %s %s\n", colorize(colorRed, "ERROR:"), s)
 		fmt.Println("##############################################################################")
 		fmt.Println("")
 	}
-	Error.Println(s)
+	Error.Println(Redactatron(localize(defaultLang, id, args...), Redact))
 }
 
 // Output a blatant error message and log the string as an error
 func traceMsg(s string) {
 	// Pring status message if quiet isn't set
 	if TraceOn {
-		Trace.Println(s)
+		Trace.Println(Redactatron(s, Redact))
 	}
 }
 
 // getDojoRelease retrives the supplied version of DefectDojo from the Git repo
 // and places it in the specified dojoSource directory (default is /opt/dojo)
-func getDojoRelease(i *config.InstallConfig) error {
-	statusMsg(fmt.Sprintf("Downloading the configured release of DefectDojo => version %+v", i.Version))
-	s := spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	s.Prefix = "Downloading release..."
+func getDojoRelease(ctx context.Context, i *config.InstallConfig) error {
+	statusMsg("release.download", i.Version)
+	s := newSpinner("Downloading release...")
 	s.Start()
 
 	// Create the directory to clone the source into if it doesn't exist already
@@ -143,93 +193,274 @@ func getDojoRelease(i *config.InstallConfig) error {
 			// TODO: Better handle the case when the repo already exists at that path - maybe?
 			return err
 		}
+		manifest.addPath(i.Root)
 	}
 
-	// Setup needed info
-	dwnURL := ReleaseURL + i.Version + ".tar.gz"
-	tarball := i.Root + "/dojo-v" + i.Version + ".tar.gz"
+	// Download and extract in a staging directory so a failed or partial download never lands in Root
+	stage, cleanup, err := stagingDir(i)
+	if err != nil {
+		traceMsg(fmt.Sprintf("Error creating the staging directory was: %+v", err))
+		return err
+	}
+	defer cleanup()
+	dwnURL, err := releaseDownloadURL(ctx, httpClient, i)
+	if err != nil {
+		return err
+	}
+	_, tarball := releasePaths(i)
+	staged := filepath.Join(stage, filepath.Base(tarball))
 	traceMsg(fmt.Sprintf("Relese download list is %+v", dwnURL))
-	traceMsg(fmt.Sprintf("File path to write tarball is %+v", tarball))
+	traceMsg(fmt.Sprintf("File path to write tarball is %+v", staged))
+
+	// Download requested release from Dojo's Github repo along with its checksum and signature if configured
+	items := []fetchItem{{URL: dwnURL, Dest: staged, Mirrors: mirrorURLs(i.ReleaseMirrors, path.Base(dwnURL))}}
+	if i.ChecksumURL != "" {
+		items = append(items, fetchItem{URL: i.ChecksumURL, Dest: staged + ".sha256"})
+	}
+	if i.SignatureURL != "" {
+		// Kept alongside the tarball for verifying with gpg, not required for the install
+		items = append(items, fetchItem{URL: i.SignatureURL, Dest: staged + ".asc", Optional: true})
+	}
+	err = FetchAll(ctx, items, maxFetches, newLimiter(i.MaxDownloadKBps))
+	if err != nil {
+		return err
+	}
+	if i.ChecksumURL != "" {
+		err = verifyChecksum(staged, staged+".sha256")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Extract the tarball into the staged source directory, dropping the release's versioned top directory
+	stagedSrc := filepath.Join(stage, i.Source)
+	traceMsg("Extracting tarball into the staging directory " + stagedSrc)
+	// Hashing every file is only done when asked for as it slows the extract
+	hashes, err := extractArchive(staged, stagedSrc, i.ArchiveType, i.HashSource)
+	if err != nil {
+		traceMsg(fmt.Sprintf("Error extracting tarball was: %+v", err))
+		return err
+	}
+	// A tarball without the usual django-DefectDojo-<version> top directory leaves nothing usable
+	_, err = os.Stat(filepath.Join(stagedSrc, "manage.py"))
+	if err != nil {
+		return &dojoerr.ExtractError{Entry: "django-DefectDojo-" + normalizeVersion(i.Version) + "/manage.py",
+			Err: fmt.Errorf("%s isn't a DefectDojo release, its top directory doesn't hold manage.py", tarball)}
+	}
+
+	// Move the verified release into Root, the checksum and signature are kept next to the tarball
+	for _, ext := range []string{"", ".sha256", ".asc"} {
+		if _, err := os.Stat(staged + ext); err != nil {
+			continue
+		}
+		err = moveInto(staged+ext, tarball+ext)
+		if err != nil {
+			return err
+		}
+		manifest.addPath(tarball + ext)
+	}
+	srcPath := filepath.Join(i.Root, i.Source)
+	traceMsg("Moving the extracted source into the Dojo source directory " + srcPath)
+	err = moveInto(stagedSrc, srcPath)
+	if err != nil {
+		traceMsg(fmt.Sprintf("Error moving the extracted source was: %+v", err))
+		return err
+	}
+	manifest.addPath(srcPath)
+	if i.HashSource {
+		mf := filepath.Join(i.Root, hashManifestName)
+		traceMsg("Writing the hashes of the extracted files to " + mf)
+		err = writeHashManifest(mf, hashes)
+		if err != nil {
+			return err
+		}
+		manifest.addPath(mf)
+	}
+
+	// Successfully extracted the file, return nil
+	s.Stop()
+	statusMsg("release.done")
+	return nil
+}
+
+// normalizeVersion strips surrounding space and a leading v so 2.3.1 and v2.3.1 are the same release
+func normalizeVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "v") || strings.HasPrefix(v, "V") {
+		return v[1:]
+	}
+	return v
+}
 
-	// Setup a custom http client for downloading the Dojo release
-	var ddClient = &http.Client{
-		// Set time to a max of 20 seconds
-		Timeout: time.Second * 20,
+// releasePaths returns the download URL and the local tarball for the configured release, a zip if ArchiveType is zip
+// with a leading v dropped from the version as GitHub's archive URLs don't use it
+func releasePaths(i *config.InstallConfig) (string, string) {
+	ver := normalizeVersion(i.Version)
+	ext := ".tar.gz"
+	if i.ArchiveType == "zip" {
+		ext = ".zip"
 	}
-	traceMsg("http.Client timeout set to 20 seconds for release download")
+	return releaseURL(i) + ver + ext,
+		i.Root + "/dojo-v" + ver + ext
+}
 
-	// Download requested release from Dojo's Github repo
-	traceMsg(fmt.Sprintf("Downloading release from %+v", dwnURL))
-	resp, err := ddClient.Get(dwnURL)
+// downloadFile fetches url with the provided client and writes the response body to dest
+// at up to maxKBps kilobytes per second, or as fast as possible if maxKBps is 0
+// Any response other than a 200 is returned as a *dojoerr.DownloadError
+func downloadFile(ctx context.Context, c httpDoer, url string, dest string, maxKBps int) error {
+	return downloadLimited(ctx, c, url, dest, newLimiter(maxKBps))
+}
+
+// downloadLimited is downloadFile with the rate set by lim so several downloads can share a cap, nil is unlimited
+func downloadLimited(ctx context.Context, c httpDoer, url string, dest string, lim *rate.Limiter) error {
+	traceMsg(fmt.Sprintf("Downloading release from %+v", url))
+	req, err := newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return &dojoerr.DownloadError{URL: url, Err: err}
+	}
+	resp, err := doRetryAfter(ctx, c, req)
 	if resp != nil {
 		defer func() {
 			err := resp.Body.Close()
 			if err != nil {
 				traceMsg(fmt.Sprintf("Error closing response.\nError was: %v", err))
-				os.Exit(1)
 			}
 		}()
 	}
 	if err != nil {
-		traceMsg(fmt.Sprintf("Error downloading from %+v", dwnURL))
+		traceMsg(fmt.Sprintf("Error downloading from %+v", url))
 		traceMsg(fmt.Sprintf("Error downloading was: %+v", err))
-		return err
+		var dErr *dojoerr.DownloadError
+		if errors.As(err, &dErr) {
+			return err
+		}
+		return &dojoerr.DownloadError{URL: url, Err: err}
 	}
 
-	// TODO: Check for 200 status before moving on
+	// Check for 200 status before moving on
 	traceMsg(fmt.Sprintf("Status of http.Client response was %+v", resp.Status))
+	if resp.StatusCode != http.StatusOK {
+		return &dojoerr.DownloadError{URL: url, StatusCode: resp.StatusCode}
+	}
 
 	// Create the file handle
 	traceMsg("Creating file for downloaded tarball")
-	out, err := os.Create(tarball)
+	out, err := os.Create(dest)
 	if err != nil {
 		traceMsg(fmt.Sprintf("Error creating tarball was: %+v", err))
 		return err
 	}
+	defer out.Close()
 
 	// Write the content downloaded into the file
 	traceMsg("Writing downloaded content to tarball file")
-	_, err = io.Copy(out, resp.Body)
+	_, err = io.Copy(out, &progressReader{r: throttleWith(ctx, resp.Body, lim), total: resp.ContentLength, report: reporter.Download})
 	if err != nil {
 		traceMsg(fmt.Sprintf("Error writing file contents was: %+v", err))
-		return err
+		return &dojoerr.DownloadError{URL: url, StatusCode: resp.StatusCode, Err: err}
 	}
 
-	// Extract the tarball to create the Dojo source directory
-	traceMsg("Extracting tarball into the Dojo source directory")
-	tb, err := os.Open(tarball)
+	return nil
+}
+
+// getDojo determines if a release or Dojo source will be installed and downloads it
+func getDojo(ctx context.Context, i *config.InstallConfig) error {
+	traceMsg(fmt.Sprintf("Determining if this is a source or release install: SourceInstall is %+v", i.SourceInstall))
+	if !i.PullSource {
+		statusMsg("source.none")
+		traceMsg("Source NOT downloaded sa PullSource is false")
+		return nil
+	}
+
+	err := prepareSourceDir(i, time.Now())
 	if err != nil {
-		traceMsg(fmt.Sprintf("Error openging tarball was: %+v", err))
 		return err
 	}
-	err = Untar(i.Root, tb)
+
+	if i.SourceInstall {
+		// Checkout the Dojo source directly from Github
+		traceMsg("Dojo will be installed from source")
+		err := getDojoSource(ctx, i)
+		if err != nil {
+			return fmt.Errorf("Error attempting to install Dojo source was:\n    %w", err)
+		}
+		return setSourcePerms(i)
+	}
+
+	// Download Dojo source as a Github release tarball
+	traceMsg("Dojo will be installed from a release tarball")
+	err = getDojoRelease(ctx, i)
 	if err != nil {
-		traceMsg(fmt.Sprintf("Error extracting tarball was: %+v", err))
-		return err
+		return fmt.Errorf("Error attempting to install Dojo from a release tarball was:\n    %w", err)
 	}
+	return setSourcePerms(i)
+}
+
+// defaultUmask is the umask used when Umask isn't set, leaving nothing the install creates world readable
+const defaultUmask = "0027"
 
-	// Remane source directory to the non-versioned name
-	traceMsg("Renaming source directory to the non-versioned name")
-	oldPath := filepath.Join(i.Root, "django-DefectDojo-"+i.Version)
-	newPath := filepath.Join(i.Root, i.Source)
-	err = os.Rename(oldPath, newPath)
+// applyUmask sets the process umask to the octal Umask so files created by the install aren't group or
+// world readable by accident. Files with an explicit mode like the 0600 secrets can only lose bits to it
+func applyUmask(i *config.InstallConfig) error {
+	u := i.Umask
+	if u == "" {
+		u = defaultUmask
+	}
+	mask, err := strconv.ParseUint(u, 8, 32)
 	if err != nil {
-		traceMsg(fmt.Sprintf("Error renaming Dojo source directory was: %+v", err))
-		return err
+		return &dojoerr.ConfigError{Field: "Install.Umask", Msg: "must be an octal umask like 0027"}
 	}
+	setUmask(int(mask))
+	return nil
+}
 
-	// Successfully extracted the file, return nil
-	s.Stop()
-	statusMsg("Successfully downloaded and extracted the DefectDojo release file")
+// setSourcePerms applies SourcePerms to the source tree if it's set, otherwise the tarball's modes are kept
+func setSourcePerms(i *config.InstallConfig) error {
+	if i.SourcePerms == "" {
+		return nil
+	}
+	perm, err := strconv.ParseUint(i.SourcePerms, 8, 32)
+	if err != nil {
+		return &dojoerr.ConfigError{Field: "Install.SourcePerms", Msg: "must be an octal mode like 0750"}
+	}
+	srcPath := filepath.Join(i.Root, i.Source)
+	traceMsg(fmt.Sprintf("Setting permissions of %s to %s", srcPath, i.SourcePerms))
+	err = applyPerms(srcPath, os.FileMode(perm))
+	if err != nil {
+		return fmt.Errorf("Unable to set the permissions of %s: %w", srcPath, err)
+	}
 	return nil
 }
 
+// prepareSourceDir clears the way for the source directory if an earlier install left one behind
+// per ExistingSource - removing it, moving it aside with the time now appended, or returning an error
+func prepareSourceDir(i *config.InstallConfig, now time.Time) error {
+	srcPath := filepath.Join(i.Root, i.Source)
+	_, err := os.Stat(srcPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch i.ExistingSource {
+	case "overwrite":
+		statusMsg("source.remove", srcPath)
+		return os.RemoveAll(srcPath)
+	case "backup":
+		bak := srcPath + ".bak-" + now.Format("20060102-150405")
+		statusMsg("source.backup", srcPath, bak)
+		return os.Rename(srcPath, bak)
+	}
+	return &dojoerr.ConfigError{Field: "Install.ExistingSource",
+		Msg: srcPath + " already exists from an earlier install, remove it or set ExistingSource to overwrite or backup"}
+}
+
 // Use go-git to checkout latest source - either from a specific commit or HEAD on a branch
 // and places it in the specified dojoSource directory (default is /opt/dojo)
-func getDojoSource(i *config.InstallConfig) error {
-	statusMsg("Downloading DefectDojo source as a branch or commit from the repo directly")
-	s := spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	s.Prefix = "Downloading DefectDojo source..."
+func getDojoSource(ctx context.Context, i *config.InstallConfig) error {
+	statusMsg("source.clone")
+	s := newSpinner("Downloading DefectDojo source...")
 
 	// Create the directory to clone the source into if it doesn't exist already
 	traceMsg("Creating source directory if it doesn't exist already")
@@ -243,23 +474,47 @@ func getDojoSource(i *config.InstallConfig) error {
 			// TODO: Better handle the case when the repo already exists at that path - maybe?
 			return err
 		}
+		manifest.addPath(srcPath)
 	}
 
-	// Check out a specific branch or commit - but only one of those
-	// In the case that both commit and branch are set to non-empty strings,
-	// the configured commit will win (aka only the commit alone will be done)
-	traceMsg("Determining if a commit or branch will be checked out of the repo")
-	if len(i.SourceCommit) > 0 {
+	repoURL := cloneURL(i)
+	auth, err := cloneAuth(i)
+	if err != nil {
+		traceMsg(fmt.Sprintf("Error setting up the clone auth was: %+v", err))
+		return err
+	}
+
+	// Check out a specific pull request, commit or branch - but only one of those
+	// A pull request wins over a commit and a commit wins over a branch when more than one is set
+	traceMsg("Determining if a pull request, commit or branch will be checked out of the repo")
+	if i.SourcePR > 0 {
+		statusMsg("source.pr", i.SourcePR)
+		s.Start()
+
+		// Clone the default branch then fetch the pull request's ref which isn't cloned by default
+		traceMsg(fmt.Sprintf("Initial clone of %+v", repoURL))
+		repo, err := cloner.PlainCloneContext(ctx, srcPath, false, &git.CloneOptions{URL: repoURL, Auth: auth, NoCheckout: true})
+		if err != nil {
+			traceMsg(fmt.Sprintf("Error cloning the DefectDojo repo was: %+v", err))
+			return &dojoerr.DownloadError{URL: repoURL, Err: err}
+		}
+		err = checkoutPR(ctx, repo, repoURL, i.SourcePR, auth)
+		if err != nil {
+			traceMsg(fmt.Sprintf("Error checking out the pull request was: %+v", err))
+			return err
+		}
+
+	} else if len(i.SourceCommit) > 0 {
 		// Commit is set, so it will be used and branch ignored
-		statusMsg(fmt.Sprintf("Dojo will be installed from commit %+v", i.SourceCommit))
+		statusMsg("source.commit", i.SourceCommit)
 		s.Start()
 
 		// Do the initial clone of DefectDojo from Github
-		traceMsg(fmt.Sprintf("Initial clone of %+v", CloneURL))
-		repo, err := git.PlainClone(srcPath, false, &git.CloneOptions{URL: CloneURL})
+		traceMsg(fmt.Sprintf("Initial clone of %+v", repoURL))
+		repo, err := cloner.PlainCloneContext(ctx, srcPath, false, &git.CloneOptions{URL: repoURL, Auth: auth})
 		if err != nil {
 			traceMsg(fmt.Sprintf("Error cloning the DefectDojo repo was: %+v", err))
-			return err
+			return &dojoerr.DownloadError{URL: repoURL, Err: err}
 		}
 
 		// Setup the working tree for checking out a particular commit
@@ -268,7 +523,6 @@ func getDojoSource(i *config.InstallConfig) error {
 		// TODO: consider checking the err above that is removed with _
 		err = wk.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(i.SourceCommit)})
 		if err != nil {
-			fmt.Printf("Error checking out was %+v\n", err)
 			traceMsg(fmt.Sprintf("Error checking out was: %+v", err))
 			return err
 		}
@@ -276,115 +530,238 @@ func getDojoSource(i *config.InstallConfig) error {
 	} else {
 		if len(i.SourceBranch) == 0 {
 			// Handle the case that both source commit and branch are wonky
-			err = fmt.Errorf("Both source commit and branch have empty or nonsensical values configured.\n"+
-				"  Source commit was configured as %s and branch was configured as %s", i.SourceCommit, i.SourceBranch)
+			err = &dojoerr.ConfigError{
+				Field: "Install.SourceBranch",
+				Msg: fmt.Sprintf("both source commit and branch have empty or nonsensical values configured.\n"+
+					"  Source commit was configured as %s and branch was configured as %s", i.SourceCommit, i.SourceBranch),
+			}
 			traceMsg(fmt.Sprintf("Error checking out Dojo source was: %+v", err))
 			return err
 		}
-		statusMsg(fmt.Sprintf("DefectDojo will be installed from %+v branch", i.SourceBranch))
+		statusMsg("source.branch", i.SourceBranch)
 		s.Start()
 
 		// Check out a specific branch
 		// Note: Branch and tag references are a bit odd, see https://github.com/src-d/go-git/blob/master/_examples/branch/main.go#L33
 		//       However, the installer appends the necessary string to the 'normal' branch name
 		traceMsg(fmt.Sprintf("Checking out branch %+v", i.SourceBranch))
-		_, err = git.PlainClone(srcPath, false, &git.CloneOptions{
-			URL:           CloneURL,
+		_, err = cloner.PlainCloneContext(ctx, srcPath, false, &git.CloneOptions{
+			URL:           repoURL,
+			Auth:          auth,
 			ReferenceName: plumbing.ReferenceName("refs/heads/" + i.SourceBranch),
 			SingleBranch:  true,
 		})
 		if err != nil {
 			traceMsg(fmt.Sprintf("Error checking out branch was: %+v", err))
-			return err
+			return &dojoerr.DownloadError{URL: repoURL, Err: err}
 		}
 
 	}
 
 	// Successfully checked out the configured source, return nil
 	s.Stop()
-	statusMsg("Successfully checked out the configured DefectDojo source")
+	statusMsg("source.done")
 	return nil
 }
 
-func sendCmd(o io.Writer, cmd string, lerr string, hard bool) {
-	// Setup command
-	runCmd := exec.Command("bash", "-c", cmd)
-	_, err := o.Write([]byte("[godojo] # " + Redactatron(cmd, Redact) + "\n"))
+// checkoutPR fetches GitHub's refs/pull/<pr>/head ref from the origin of repo and checks it out
+func checkoutPR(ctx context.Context, repo *git.Repository, repoURL string, pr int, auth transport.AuthMethod) error {
+	ref := fmt.Sprintf("refs/pull/%d/head", pr)
+	local := plumbing.ReferenceName(fmt.Sprintf("refs/remotes/origin/pr/%d", pr))
+	traceMsg(fmt.Sprintf("Fetching %s from %s", ref, repoURL))
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec("+" + ref + ":" + string(local))},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		// go-git doesn't have an error type for a missing ref, only this message
+		if strings.Contains(err.Error(), "couldn't find remote ref") {
+			return &dojoerr.ConfigError{Field: "Install.SourcePR", Msg: fmt.Sprintf("pull request %d doesn't exist in %s", pr, repoURL)}
+		}
+		return &dojoerr.DownloadError{URL: repoURL, Err: err}
+	}
+	head, err := repo.Reference(local, true)
 	if err != nil {
-		errorMsg(fmt.Sprintf("Failed to setup command, error was: %+v", err))
+		return err
 	}
-	// TODO: Remove DEBUG below
-	fmt.Println("\nRunning ", cmd)
-
-	// Run and gather its output
-	cmdOut, err := runCmd.CombinedOutput()
+	wk, err := repo.Worktree()
 	if err != nil {
-		errorMsg(fmt.Sprintf("Failed to run OS command, error was: %+v", err))
-		if hard {
-			// Exit on hard aka fatal errors
-			os.Exit(1)
+		return err
+	}
+	// Forced as the clone wasn't checked out so every file is missing from the worktree
+	return wk.Checkout(&git.CheckoutOptions{Hash: head.Hash(), Force: true})
+}
+
+// rootContext returns the context used for the whole install which is canceled
+// when the installer receives an interrupt or terminate signal
+func rootContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			fmt.Printf("\nReceived %+v, stopping the install\n", sig)
+			cancel()
+		case <-ctx.Done():
 		}
+		signal.Stop(sigs)
+	}()
+	return ctx, cancel
+}
+
+// applyConfig sets up output, logging and the HTTP client from c as the install and the subcommands
+// running its steps need them
+func applyConfig(c *config.DojoConfig) error {
+	setOutput(&c.Install)
+	TraceOn = c.Install.Trace
+	Redact = c.Install.Redact
+	err := applyUmask(&c.Install)
+	if err != nil {
+		return err
 	}
-	_, err = o.Write(cmdOut)
+	HTTPTrace = c.Install.HTTPTrace
+	DryRun = c.Install.DryRun
+	AssumeYes = c.Install.AssumeYes
+	KeepGoing = c.Install.KeepGoing
+	Lang = c.Install.Lang
+	client, err := newHTTPClient(&c.Install)
 	if err != nil {
-		errorMsg(fmt.Sprintf("Failed to write to OS command log file, error was: %+v", err))
+		return err
 	}
+	httpClient = client
+	releaseCache = newReleaseCache(&c.Install)
+	maxRetryAfter = c.Install.MaxRetryAfter
+	return nil
 }
 
-func main() {
-	// Setup viper config
-	viper.AddConfigPath(".")
-	viper.SetConfigName("dojoConfig")
+// validateConfig sets up the strings to redact from c then validates it, printing its warnings unless Quiet is set
+func validateConfig(c *config.DojoConfig) ([]string, error) {
+	InitRedact(c)
+	warns, err := c.Validate()
+	for _, w := range warns {
+		if !Quiet {
+			fmt.Println("WARNING: " + Redactatron(w, Redact))
+		}
+	}
+	return warns, err
+}
 
-	// Setup ENV variables
-	viper.SetEnvPrefix("DD")
-	replace := strings.NewReplacer(".", "_")
-	viper.SetEnvKeyReplacer(replace)
-	viper.AutomaticEnv()
+func main() {
+	// Setup a root context that is canceled on Ctrl-C or SIGTERM
+	ctx, cancel := rootContext()
+	defer cancel()
+
+	// Run a subcommand instead of an install if one was given
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(ctx, os.Args[2:]))
+		}
+	}
+	os.Exit(install(ctx, os.Args[1:]))
+}
 
-	// Read the default config file dojoConfig.yml
-	err := viper.ReadInConfig()
+// install runs a full install of DefectDojo configured by the config file, ENV variables and the flags in args
+// returning the exit code for its error, 0 if it succeeded
+func install(ctx context.Context, args []string) int {
+	// Parse command-line flags which override the config file and ENV variables
+	flags := installFlags()
+	err := flags.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
 	if err != nil {
 		fmt.Println("")
-		fmt.Println("Unable to read the godojo config file (dojoConfig.yml), exiting install")
-		os.Exit(1)
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+
+	// Read the config file, ENV variables and flags into the DojoConfig struct
+	err = loadConfig(viper.GetViper(), flags, &conf)
+	haveFile := err != errNoConfig
+	if !haveFile && stdinIsTerminal() {
+		// First run without a config file, ask for what's needed instead
+		conf.Install.Prompt = true
+		err = nil
 	}
-	// Marshall the config values into the DojoConfig struct
-	err = viper.Unmarshal(&conf)
 	if err != nil {
 		fmt.Println("")
-		fmt.Println("Unable to set the config values based on config file and ENV variables, exiting install")
-		os.Exit(1)
+		fmt.Printf("%+v, exiting install\n", err)
+		return 1
 	}
 
 	// Setup output and logging levels and print the DefectDojo banner if needed
-	Quiet = conf.Install.Quiet
-	TraceOn = conf.Install.Trace
-	Redact = conf.Install.Redact
-	if !Quiet {
-		dojoBanner()
+	err = applyConfig(&conf)
+	if err != nil {
+		fmt.Println("")
+		fmt.Printf("%+v, exiting install\n", err)
+		return exitConfig
+	}
+	if showBanner(&conf.Install) {
+		dojoBanner(os.Stdout)
+	}
+	// Prompt for the required config values if running interactively
+	if conf.Install.Prompt {
+		if !stdinIsTerminal() {
+			fmt.Println("")
+			fmt.Println("Interactive install requested but stdin isn't a terminal, exiting install")
+			return exitConfig
+		}
+		err = interactiveConfig(stdPrompter(), &conf, haveFile)
+		if err != nil {
+			fmt.Println("")
+			fmt.Printf("%+v, exiting install\n", err)
+			return exitConfig
+		}
+	}
+
+	// Catch unusable config values before anything is changed on the system
+	warns, err := validateConfig(&conf)
+	if err != nil {
+		fmt.Println("")
+		fmt.Printf("%+v\n", Redactatron(err.Error(), Redact))
+		fmt.Println("Correct the configuration or use --allow-weak-passwords for development installs, exiting install")
+		return exitConfig
+	}
+	// Values answered at the prompt are listed too as viper never saw them, confirming them once more
+	defaulted := defaultedKeys(viper.GetViper(), &conf.Install)
+	if len(defaulted) > 0 {
+		w := defaultedWarning(defaulted, &conf.Install)
+		if !Quiet {
+			fmt.Println("WARNING: " + w)
+		}
+		warns = append(warns, w)
+		if conf.Install.Prompt && !confirm("Continue the install with these values") {
+			fmt.Println("Set them in the config file, ENV variables or flags, exiting install")
+			return exitConfig
+		}
 	}
-	// Setup strings to be redacted
-	InitRedact(&conf)
 
 	// Check that user is root for the installer or run with "sudo godojo"
-	usr, err := user.Current()
+	ContainerMode = containerMode(&conf.Install)
+	rootWarn, err := rootCheck(&conf.Install, ContainerMode)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Println("")
+		fmt.Println("##############################################################################")
+		fmt.Printf("  ERROR: %s\n", err)
+		fmt.Println("##############################################################################")
+		fmt.Println("")
+		return 1
 	}
-	if usr.Uid != "0" {
+	if rootWarn != "" {
+		// Shown even if Quiet is set as a bypassed root check explains most failures that follow
 		fmt.Println("")
 		fmt.Println("##############################################################################")
-		fmt.Println("  ERROR: This program must be run as root or with sudo\n  Please correct and run installer again")
+		fmt.Printf("  WARNING: %s\n", rootWarn)
 		fmt.Println("##############################################################################")
 		fmt.Println("")
-		os.Exit(1)
+		warns = append(warns, rootWarn)
 	}
 
 	// Setup logging for the installer
 	n := time.Now()
-	when := strconv.Itoa(int(n.UnixNano()))
-	logName := "dojo-install_" + when + ".log"
+	logName := installLogName(n)
 	logPath := path.Join(logLocation, logName)
 	// Create the logs directory if it does not exist
 	_, err = os.Stat(logPath)
@@ -401,7 +778,7 @@ func main() {
 			fmt.Println("##############################################################################")
 			fmt.Println("")
 			fmt.Println("Exiting install")
-			os.Exit(1)
+			return 1
 		}
 	}
 
@@ -414,259 +791,138 @@ func main() {
 		fmt.Println("##############################################################################")
 		fmt.Println("")
 		fmt.Println("Log files are required for the install, exiting install")
-		os.Exit(1)
+		return 1
+	}
+	// Start the log with where and how godojo was run to make triage easier
+	err = logHeader(logFile, viper.GetViper(), os.Args)
+	if err != nil {
+		fmt.Printf("WARNING: Unable to write the log header, error was: %+v\n", err)
+	}
+	// Log everything to the specificied log file location plus syslog if configured
+	var sw syslogWriter
+	var swErr error
+	if conf.Install.Syslog {
+		sw, swErr = newSyslog()
+	}
+	logSetup(logFile, sw)
+	if swErr != nil {
+		statusMsg("log.syslog", swErr)
+		Warning.Println(swErr)
 	}
-	// Log everything to the specificied log file location
-	logSetup(logFile)
 
 	// Logging is setup, start using statusMsg and errorMsg functions for output
 	traceMsg("Logging established, trace log begins here")
-	sectionMsg("Starting the dojo install at " + n.Format("Mon Jan 2, 2006 15:04:05 MST"))
-
-	// Setup OS command logging
-	traceMsg("Creating log file for OS command output for debugging reasons")
-	cmdLog := "cmd-output_" + when + ".log"
-	cmdPath := path.Join(logLocation, cmdLog)
-	// Create command output log file in the existing logging directory
-	cmdFile, err := os.OpenFile(cmdPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		fmt.Println("")
-		fmt.Println("##############################################################################")
-		fmt.Printf("  ERROR: Failed to open OS Command log file %s.  Error was:\n    %+v\n", cmdPath, err)
-		fmt.Println("##############################################################################")
-		fmt.Println("")
-		fmt.Println("Log files are required for the install, exiting install")
-		os.Exit(1)
+	for _, w := range warns {
+		Warning.Println(Redactatron(w, Redact))
+	}
+	traceMsg(fmt.Sprintf("Config was read from %s", viper.ConfigFileUsed()))
+	sectionMsg("install.start", n.Format("Mon Jan 2, 2006 15:04:05 MST"))
+	if !knownLang(Lang) {
+		statusMsg("lang.unknown", Lang)
+	}
+	if ContainerMode {
+		statusMsg("install.container")
 	}
-	traceMsg(fmt.Sprintf("Successfully created OS Command log file at %+v", cmdPath))
 
 	// Write out the runtime config based on the net of the config file + ENV variables
 	// TODO: Consider moving this closer to the end of main
-	traceMsg("Writing out the runtime install configuration file")
-	err = viper.WriteConfigAs("runtime-install-config.yml")
-	if err != nil {
-		errorMsg(fmt.Sprintf("Error from writing the runtime config was: %+v", err))
-		os.Exit(1)
+	if conf.Install.WriteRuntimeConfig {
+		traceMsg("Writing out the runtime install configuration file")
+		err = writeRuntimeConfig(viper.GetViper(), runtimeConfigPath(&conf.Install), conf.Install.ConfigPassphrase)
+		if err != nil {
+			errorMsg("runtime-config", err)
+			return 1
+		}
+	} else {
+		traceMsg("Runtime install configuration file not written per configuration")
 	}
 
 	// Check install OS
-	sectionMsg("Determining OS for installation")
+	sectionMsg("os.section")
 
 	// TODO: write OS determination code for OS X
 	// TODO: test OS detection on Alpine Linux docker
 	target := targetOS{}
 	determineOS(&target)
 
-	statusMsg(fmt.Sprintf("OS was determined to be %+v, %+v", strings.Title(target.os), strings.Title(target.id)))
-	statusMsg("DefectDojo installation on this OS is supported, continuing")
-
-	// Bootstrap installer
-	sectionMsg("Bootstrapping the godojo installer")
-	bs := osCmds{}
-	initBootstrap(target.id, &bs)
-
-	Spin := spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	Spin.Prefix = "Bootstrapping..."
-	Spin.Start()
-	// TODO REMOVE COMMENTS BELOW
-	for i := range bs.cmds {
-		sendCmd(cmdFile,
-			bs.cmds[i],
-			bs.errmsg[i],
-			bs.hard[i])
-	}
-	Spin.Stop()
-	statusMsg("Boostraping godojo installer complete")
-
-	sectionMsg("Checking for Python 3")
-	if checkPythonVersion() {
-		statusMsg("Python 3 found, install can continue")
-	} else {
-		errorMsg("Python 3 wasn't found, quitting installer")
-		os.Exit(1)
+	statusMsg("os.found", strings.Title(target.os), strings.Title(target.id))
+	err = checkCompat(&conf.Install, target)
+	if err != nil {
+		errorMsg("error", err)
+		statusMsg("os.ignore-compat")
+		return exitConfig
 	}
+	statusMsg("os.supported")
+	checkArch(HostArch())
 
-	sectionMsg("Downloading the source for DefectDojo")
-
-	// Determine if a release or Dojo source will be installed
-	traceMsg(fmt.Sprintf("Determining if this is a source or release install: SourceInstall is %+v", conf.Install.SourceInstall))
-	if conf.Install.PullSource {
-		// TODO: Move this to a separate funtion
-		if conf.Install.SourceInstall {
-			// Checkout the Dojo source directly from Github
-			traceMsg("Dojo will be installed from source")
-
-			err = getDojoSource(&conf.Install)
-			if err != nil {
-				errorMsg(fmt.Sprintf("Error attempting to install Dojo source was:\n    %+v", err))
-				os.Exit(1)
-			}
-		} else {
-			// Download Dojo source as a Github release tarball
-			traceMsg("Dojo will be installed from a release tarball")
-
-			err = getDojoRelease(&conf.Install)
-			if err != nil {
-				errorMsg(fmt.Sprintf("Error attempting to install Dojo from a release tarball was:\n    %+v", err))
-				os.Exit(1)
-			}
+	// Check every program the steps will run is installed before changing anything, dry runs don't run them
+	steps := installSteps(&conf, target)
+	if !DryRun {
+		err = RequireBinaries(stepBinaries(steps)...)
+		if err != nil {
+			errorMsg("error", err)
+			return exitFailure
 		}
-	} else {
-		statusMsg("No source for DefectDojo downloaded per configuration")
-		traceMsg("Source NOT downloaded sa PullSource is false")
+	}
+	// Not fatal as a re-install finds the app server and nginx from the last install on their ports
+	err = checkPorts(installPorts(&conf.Install))
+	if err != nil {
+		statusMsg("ports.busy", err)
+		Warning.Printf("Port check failed: %+v", err)
 	}
 
-	// Stup for prompting for install-time items
-	if conf.Install.Prompt {
-		sectionMsg("Prompt set to true, interactive installation beginning")
-		fmt.Println("TODO: Write prompting code")
-		os.Exit(1)
-	} else {
-		sectionMsg("Prompt set to false, non-interactive installation")
-	}
-
-	// Gather OS commands to bootstrap the install
-	sectionMsg("Installing OS packages needed for DefectDojo")
-	osInst := osCmds{}
-	initOSInst(target.id, &osInst)
-
-	// Install the OS packages
-	Spin = spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	Spin.Prefix = "Installing OS packages..."
-	Spin.Start()
-	// TODO REMOVE COMMENTS BELOW
-	for i := range osInst.cmds {
-		sendCmd(cmdFile,
-			osInst.cmds[i],
-			osInst.errmsg[i],
-			osInst.hard[i])
-	}
-	Spin.Stop()
-	statusMsg("Installing OS packages complete")
-
-	// InstallDB (if needed)
-	if !conf.Install.DB.Local && !conf.Install.DB.Exists {
-		// Remote database that doesn't exist - godojo can't help you here
-		errorMsg("Remote database which doens't exist confgiured - unsupported option")
-		statusMsg("Correct configuration or install remote DB before continuing")
-		fmt.Printf("Exiting...\n\n")
-		os.Exit(1)
-	} else if !conf.Install.DB.Exists {
-		// Handle the case that the DB is local and doesn't exist
-		sectionMsg("Installing database needed for DefectDojo")
-
-		// Gather OS commands to install the DB
-		dbInst := osCmds{}
-		dbConf := &conf.Install.DB
-		installDB(target.id, dbConf, &dbInst)
-
-		// Run the commands to install the chosen DB
-		Spin = spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-		Spin.Prefix = "Installing " + conf.Install.DB.Engine + " database for DefectDojo..."
-		Spin.Start()
-		for i := range dbInst.cmds {
-			sendCmd(cmdFile,
-				dbInst.cmds[i],
-				dbInst.errmsg[i],
-				dbInst.hard[i])
+	warn, err := checkTempDir(&conf.Install)
+	if err != nil {
+		errorMsg("error", err)
+		return exitCode(err)
+	}
+	if warn != "" {
+		statusMsg("warning", warn)
+		Warning.Println(warn)
+	}
+
+	// Make sure no other install is running against the same Root
+	_, err = os.Stat(conf.Install.Root)
+	if err != nil {
+		err = os.MkdirAll(conf.Install.Root, 0755)
+		if err != nil {
+			errorMsg("root.create", err)
+			return 1
 		}
-		Spin.Stop()
-		statusMsg("Installing Database complete")
-
-	}
-
-	// Start the database if local and didn't already exist
-	dbConf := &conf.Install.DB
-	if conf.Install.DB.Local && !conf.Install.DB.Exists {
-		// Handle the case that the DB is local and doesn't exist
-		sectionMsg("Starting the database needed for DefectDojo")
-
-		// Gather OS commands to install the DB
-		dbStart := osCmds{}
-		startDB(target.id, dbConf, &dbStart)
-
-		// Run the commands to install the chosen DB
-		Spin = spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-		Spin.Prefix = "Starting " + conf.Install.DB.Engine + " database for DefectDojo..."
-		Spin.Start()
-		for i := range dbStart.cmds {
-			sendCmd(cmdFile,
-				dbStart.cmds[i],
-				dbStart.errmsg[i],
-				dbStart.hard[i])
+		manifest.addPath(conf.Install.Root)
+	}
+
+	// Run the install steps, giving up once InstallTimeout has passed if it's set
+	ctx, cancel := installContext(ctx, conf.Install.InstallTimeout)
+	defer cancel()
+	rec := &resultReporter{ProgressReporter: reporter}
+	// Includes a stop by Ctrl-C or SIGTERM which cancels ctx and ends runSteps
+	err = runStepsLocked(ctx, conf.Install.Root, rec, steps)
+	res := newResult(steps, rec, &manifest, installedVersion(&conf.Install), err)
+	if conf.Install.ResultFile != "" {
+		// Written for failures too so automation can tell what happened
+		rerr := writeResult(conf.Install.ResultFile, res)
+		if rerr != nil {
+			errorMsg("result.write", conf.Install.ResultFile, rerr)
 		}
-		Spin.Stop()
-		statusMsg("Installing Database complete")
-	}
-
-	// Preapare the database for DefectDojo by:
-	// (1) Checking connectivity to the DB, (2) checking that the configured Dojo database name doesn't exit already
-	// (3) Droping the existing database if Drop = true is configured (4) Create the DefectDojo database
-	// (5) Add the DB user for DefectDojo to use
-	sectionMsg("Preparing the database needed for DefectDojo")
-	err = dbPrep(target.id, dbConf)
-	if err != nil {
-		errorMsg(fmt.Sprintf("%+v", err))
-		os.Exit(1)
-	}
-
-	// Prep OS (user, virtualenv, chownership)
-	sectionMsg("Preparing the OS for DefectDojo installation")
-	prepCmds := osCmds{}
-	osPrep(target.id, &conf.Install, &prepCmds)
-	// Run the OS Prep commands
-	Spin = spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	Spin.Prefix = "Preparing the OS for DefectDojo..."
-	Spin.Start()
-	for i := range prepCmds.cmds {
-		sendCmd(cmdFile,
-			prepCmds.cmds[i],
-			prepCmds.errmsg[i],
-			prepCmds.hard[i])
-	}
-	Spin.Stop()
-	statusMsg("Preparing the OS complete")
-
-	// Create settings.py for DefectDojo
-	sectionMsg("Creating settings.py for DefectDojo")
-	settCmds := osCmds{}
-	createSettingsPy(target.id, &conf, &settCmds)
-	// Run the commands to create settings.py
-	Spin = spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	Spin.Prefix = "Creating settings.py for DefectDojo..."
-	Spin.Start()
-	for i := range settCmds.cmds {
-		sendCmd(cmdFile,
-			settCmds.cmds[i],
-			settCmds.errmsg[i],
-			settCmds.hard[i])
-	}
-	Spin.Stop()
-	statusMsg("Creating settings.py for DefectDojo complete")
-
-	// Django/Python installs
-	sectionMsg("Setting up Django for DefectDojo")
-	setupDj := osCmds{}
-	setupDjango(target.id, &conf, &setupDj)
-	// Run the Django commands
-	Spin = spinner.New(spinner.CharSets[34], 100*time.Millisecond)
-	Spin.Prefix = "Setting up Django for DefectDojo..."
-	Spin.Start()
-	for i := range setupDj.cmds {
-		sendCmd(cmdFile,
-			setupDj.cmds[i],
-			setupDj.errmsg[i],
-			setupDj.hard[i])
-	}
-	Spin.Stop()
-	statusMsg("Setting up Django complete")
-
-	// Static items
-
-	// Celery / TODO: RabitMQ
-
-	// Optional Installs
-
-	// Look at setup.bash's high-level workflow
-	statusMsg(fmt.Sprintf("\n\nSuccessfully reached the end of main in godojo version %+v", version))
+	}
+	if conf.Install.MetricsFile != "" {
+		merr := writeMetrics(conf.Install.MetricsFile, res, time.Since(n), time.Now())
+		if merr != nil {
+			errorMsg("metrics.write", conf.Install.MetricsFile, merr)
+		}
+	}
+	// A fresh context as ctx is cancelled by a timeout or Ctrl-C which are outcomes worth reporting too
+	reportTelemetry(context.Background(), &conf.Install, res)
+	if err != nil {
+		errorMsg("error", err)
+		return exitCode(err)
+	}
+
+	// Tell the operator how to reach the new install
+	Info.Printf("Install completed by godojo version %+v", version)
+	if showSummary() {
+		closingMsg(os.Stdout, &conf.Install, logPath, rec.failed())
+	}
+	return 0
 }
@@ -1,9 +1,30 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	"github.com/spf13/viper"
 )
 
+func TestMain(m *testing.M) {
+	// Send all installer output to the bit bucket while testing
+	Quiet = true
+	logSetup(ioutil.Discard, nil)
+	os.Exit(m.Run())
+}
+
 func TestGetDojo(t *testing.T) {
 	testVer := "1.5.3.1"
 	//result := getDojo(testVer)
@@ -14,3 +35,120 @@ func TestGetDojo(t *testing.T) {
 	}
 	//TODO: Expand this more than just string matching the Dojo version
 }
+
+func TestDownloadFileStatus(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "dojo.tar.gz")
+	err := downloadFile(context.Background(), ts.Client(), ts.URL+"/archive/9.9.9.tar.gz", dest, 0)
+
+	var dErr *dojoerr.DownloadError
+	if !errors.As(err, &dErr) {
+		t.Fatalf("Expecting a *dojoerr.DownloadError, got %v", err)
+	}
+	if dErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expecting status code %d, got %d", http.StatusNotFound, dErr.StatusCode)
+	}
+	if exitCode(err) != exitDownload {
+		t.Errorf("Expecting exit code %d, got %d", exitDownload, exitCode(err))
+	}
+}
+
+func TestDownloadFileCanceled(t *testing.T) {
+	// Server that never answers until the client goes away
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	dest := filepath.Join(t.TempDir(), "dojo.tar.gz")
+	err := downloadFile(ctx, ts.Client(), ts.URL+"/archive/9.9.9.tar.gz", dest, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expecting a context.Canceled error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expecting downloadFile to return promptly after cancel, took %v", time.Since(start))
+	}
+	if exitCode(err) != exitInterrupted {
+		t.Errorf("Expecting exit code %d, got %d", exitInterrupted, exitCode(err))
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&dojoerr.ConfigError{Field: "Install.Version"}, exitConfig},
+		{&dojoerr.DownloadError{URL: "https://github.com/DefectDojo/django-DefectDojo.git"}, exitDownload},
+		{&dojoerr.ExtractError{Entry: "foo"}, exitExtract},
+		{errors.New("something else"), exitFailure},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, expecting %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReleasePaths(t *testing.T) {
+	for _, v := range []string{"2.3.1", "v2.3.1", " v2.3.1 "} {
+		i := config.InstallConfig{Version: v, Root: "/opt/dojo"}
+		url, tarball := releasePaths(&i)
+		if url != "https://github.com/DefectDojo/django-DefectDojo/archive/2.3.1.tar.gz" {
+			t.Errorf("Version %q: expecting URL %s, got %s", v, "https://github.com/DefectDojo/django-DefectDojo/archive/2.3.1.tar.gz", url)
+		}
+		if tarball != "/opt/dojo/dojo-v2.3.1.tar.gz" {
+			t.Errorf("Version %q: unexpected tarball %s", v, tarball)
+		}
+	}
+}
+
+func TestShowBanner(t *testing.T) {
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		fs := installFlags()
+		fs.Parse([]string{"--no-banner"})
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), fs, &c)
+		if err != nil {
+			t.Fatalf("Unable to load config: %v", err)
+		}
+		if showBanner(&c.Install) {
+			t.Errorf("Expecting --no-banner to skip the banner")
+		}
+		if c.Install.Quiet {
+			t.Errorf("Expecting --no-banner to leave status output on")
+		}
+	})
+	if !showBanner(&config.InstallConfig{}) {
+		t.Errorf("Expecting the banner by default")
+	}
+	if showBanner(&config.InstallConfig{Quiet: true}) {
+		t.Errorf("Expecting Quiet to skip the banner")
+	}
+}
+
+func TestBannerText(t *testing.T) {
+	b := bannerText()
+	if !strings.Contains(b, "  "+HelpURL+"\n") {
+		t.Errorf("Expecting the banner to end with HelpURL on its own line, got:\n%s", b)
+	}
+	if strings.Contains(b, "%s") || strings.Contains(b, "%!") {
+		t.Errorf("Expecting no stray format verbs in the banner, got:\n%s", b)
+	}
+	var buf bytes.Buffer
+	dojoBanner(&buf)
+	if buf.String() != b {
+		t.Errorf("Expecting dojoBanner to print bannerText")
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the health check run once the services are started to confirm DefectDojo actually answers
+
+// Health check defaults used when the config doesn't set them
+const (
+	defaultHealthTimeout  = 2 * time.Minute
+	defaultHealthInterval = 2 * time.Second
+)
+
+// healthMaxBody is the most of a page the health check reads looking for healthMarker
+const healthMaxBody = 1 << 20
+
+// healthRequestTimeout is the longest a single health check request may take before it counts as not ready
+const healthRequestTimeout = 10 * time.Second
+
+// healthMaxErrors is how many error responses in a row fail the health check before its timeout, a
+// DefectDojo answering 500 or 400 is misconfigured rather than still starting
+var healthMaxErrors = 3
+
+// notReady returns true if status means the app server is still starting behind nginx rather than broken
+func notReady(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// healthCheck GETs url every interval until DefectDojo's login page answers, returning an error once timeout
+// has passed. Connection errors and 502, 503 or 504 from nginx mean the app is still warming up so they're
+// retried until the timeout while any other status, or a page that isn't DefectDojo's like nginx's default
+// site, fails after healthMaxErrors of them in a row
+func healthCheck(ctx context.Context, c *http.Client, url string, timeout time.Duration, interval time.Duration) error {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	failures := 0
+	for attempt := 1; ; attempt++ {
+		status, dojo, err := healthGet(ctx, c, url)
+		switch {
+		case err == nil && status < 400 && dojo:
+			statusMsg("health.ok", url, attempt, time.Since(start).Round(time.Second))
+			return nil
+		case err != nil:
+			Info.Printf("Health check attempt %d of %s isn't ready yet: %+v", attempt, url, err)
+			failures = 0
+		case notReady(status):
+			Info.Printf("Health check attempt %d of %s isn't ready yet, got %d", attempt, url, status)
+			failures = 0
+			err = fmt.Errorf("got %d %s", status, http.StatusText(status))
+		default:
+			Warning.Printf("Health check attempt %d of %s got %d", attempt, url, status)
+			failures++
+			err = fmt.Errorf("got %d %s", status, http.StatusText(status))
+			if status < 400 {
+				err = fmt.Errorf("got %d %s without DefectDojo's login page, is another site answering?", status, http.StatusText(status))
+			}
+			if failures >= healthMaxErrors {
+				return fmt.Errorf("DefectDojo at %s is answering with errors after %d attempts over %s, check the install and app server logs: %w",
+					url, attempt, time.Since(start).Round(time.Second), err)
+			}
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("DefectDojo at %s wasn't ready after %d attempts over %s: %w", url, attempt, timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// healthMarker is in DefectDojo's login page and not in nginx's default site or another app's
+var healthMarker = []byte("DefectDojo")
+
+// healthGet returns the status url answers a GET with and if the page is DefectDojo's, reading at most
+// healthMaxBody of it
+func healthGet(ctx context.Context, c *http.Client, url string) (int, bool, error) {
+	req, err := newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return 0, false, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, healthMaxBody))
+	if err != nil {
+		return 0, false, err
+	}
+	return resp.StatusCode, bytes.Contains(body, healthMarker), nil
+}
+
+// healthURL returns the URL the health check GETs, DefectDojo's login page on localhost as the hostname may
+// not resolve from the host itself while allowedHosts always adds localhost to ALLOWED_HOSTS and nginx's
+// server_name, and it's in the self-signed certificate's names
+func healthURL(i *config.InstallConfig) string {
+	scheme := "http"
+	if i.TLS.SelfSigned {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort("localhost", strconv.Itoa(listenPort(i))) + "/login"
+}
+
+// healthClient returns the client for the health check, trusting the install's self-signed certificate
+func healthClient(i *config.InstallConfig) (*http.Client, error) {
+	t := newTransport(i)
+	if i.TLS.SelfSigned {
+		cert, _ := tlsPaths(i)
+		pool, err := caPool(cert)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: t, Timeout: healthRequestTimeout}, nil
+}
+
+// runHealthCheck checks the install answers on healthURL, polling for up to HealthTimeout
+func runHealthCheck(ctx context.Context, i *config.InstallConfig) error {
+	if DryRun {
+		// Nothing was started so there's nothing to check
+		return nil
+	}
+	_, err := os.Stat(nginxSites)
+	if err != nil {
+		// Without nginx nothing listens on the URL the install reports
+		statusMsg("health.no-nginx")
+		return nil
+	}
+	c, err := healthClient(i)
+	if err != nil {
+		return err
+	}
+	return healthCheck(ctx, c, healthURL(i), i.HealthTimeout, i.HealthInterval)
+}
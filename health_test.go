@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// freeAddr returns a localhost address nothing is listening on
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// loginPage answers like DefectDojo's login page
+func loginPage(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("<html><head><title>Login | DefectDojo</title></head></html>"))
+}
+
+func TestHealthCheckWaitsForStart(t *testing.T) {
+	r := &recordingReporter{}
+	useReporter(t, r)
+	addr := freeAddr(t)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(loginPage))
+	defer ts.Close()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		ts.Listener = l
+		ts.Start()
+	}()
+
+	err := healthCheck(context.Background(), &http.Client{}, "http://"+addr+"/login", 5*time.Second, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expecting the check to pass once the server starts, got %v", err)
+	}
+	if len(r.calls) != 1 || !strings.HasPrefix(r.calls[0], "status:DefectDojo answered at http://"+addr+"/login after ") {
+		t.Errorf("Expecting the attempts and wait reported, got %v", r.calls)
+	}
+}
+
+func TestHealthCheckFailsFastOnErrors(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	start := time.Now()
+	err := healthCheck(context.Background(), ts.Client(), ts.URL, time.Minute, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "500 Internal Server Error") {
+		t.Errorf("Expecting the check to fail on the 500s, got %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); int(n) != healthMaxErrors {
+		t.Errorf("Expecting %d attempts, got %d", healthMaxErrors, n)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("Expecting the check to fail long before its timeout")
+	}
+}
+
+func TestHealthCheckWrongSite(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// nginx's default site answers 200 when the DefectDojo site isn't enabled
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte("<html><head><title>Welcome to nginx!</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	err := healthCheck(context.Background(), ts.Client(), ts.URL+"/login", time.Minute, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "without DefectDojo's login page") {
+		t.Errorf("Expecting the check to fail on another site's page, got %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); int(n) != healthMaxErrors {
+		t.Errorf("Expecting %d attempts, got %d", healthMaxErrors, n)
+	}
+}
+
+func TestHealthCheckRetriesBadGateway(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// nginx answers 502 until gunicorn is listening
+		if atomic.AddInt32(&hits, 1) <= int32(healthMaxErrors)+1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		loginPage(w, r)
+	}))
+	defer ts.Close()
+
+	err := healthCheck(context.Background(), ts.Client(), ts.URL, 5*time.Second, 10*time.Millisecond)
+	if err != nil {
+		t.Errorf("Expecting the 502s to be retried until the app answers, got %v", err)
+	}
+}
+
+func TestHealthCheckTimesOut(t *testing.T) {
+	addr := freeAddr(t)
+	err := healthCheck(context.Background(), &http.Client{}, "http://"+addr+"/", 200*time.Millisecond, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "wasn't ready after") {
+		t.Errorf("Expecting the check to time out, got %v", err)
+	}
+
+	// A server that accepts but never answers is no different
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-hang }))
+	defer ts.Close()
+	defer close(hang)
+	c := ts.Client()
+	c.Timeout = 50 * time.Millisecond
+	err = healthCheck(context.Background(), c, ts.URL, 200*time.Millisecond, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "wasn't ready after") {
+		t.Errorf("Expecting the check to time out, got %v", err)
+	}
+}
+
+func TestHealthURL(t *testing.T) {
+	i := &config.InstallConfig{Port: 8080}
+	if got := healthURL(i); got != "http://localhost:8080/login" {
+		t.Errorf("Expecting http://localhost:8080/login, got %s", got)
+	}
+	i = &config.InstallConfig{}
+	i.TLS.SelfSigned = true
+	if got := healthURL(i); got != "https://localhost:443/login" {
+		t.Errorf("Expecting https://localhost:443/login, got %s", got)
+	}
+}
+
+func TestHealthURLAllowed(t *testing.T) {
+	// Only the public name configured, localhost must still be let through for the health check
+	i := &config.InstallConfig{Port: 8080, AllowedHosts: []string{"dojo.example.com"}}
+	i.TLS.Hostname = "dojo.example.com"
+	u, err := url.Parse(healthURL(i))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, h := range allowedHosts(i) {
+		found = found || h == u.Hostname()
+	}
+	if !found {
+		t.Errorf("Expecting %s in the allowed hosts %v", u.Hostname(), allowedHosts(i))
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the PostInstallHook run once an install succeeds for site-specific follow-up like monitoring or a smoke test
+
+// hookEnv returns the VAR=value settings describing the install passed to the PostInstallHook, never secrets
+func hookEnv(i *config.InstallConfig) []string {
+	return []string{
+		"DOJO_VERSION=" + installedVersion(i),
+		"DOJO_ROOT=" + i.Root,
+		"DOJO_SOURCE=" + filepath.Join(i.Root, i.Source),
+		"DOJO_URL=" + dojoURL(i),
+		"DOJO_ADMIN_USER=" + i.Admin.User,
+		"DOJO_DB_ENGINE=" + i.DB.Engine,
+		"GODOJO_VERSION=" + version,
+	}
+}
+
+// runPostInstallHook runs the PostInstallHook with sh, passing hookEnv's settings through env so they're
+// logged with the command and redacted like any other. A failure only warns unless PostInstallHookFatal is set
+func runPostInstallHook(ctx context.Context, i *config.InstallConfig) error {
+	args := append(hookEnv(i), "sh", "-c", i.PostInstallHook)
+	err := runCmd(ctx, "env", args...)
+	if err == nil {
+		statusMsg("hook.done")
+		return nil
+	}
+	if i.PostInstallHookFatal {
+		return fmt.Errorf("The post-install hook failed, error was: %w", err)
+	}
+	statusMsg("hook.failed")
+	Warning.Printf("The post-install hook failed, continuing as PostInstallHookFatal isn't set. Error was: %+v", err)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestHookEnv(t *testing.T) {
+	i := &config.InstallConfig{Version: "1.5.3.1", Root: "/opt/dojo", Source: "django-DefectDojo", Port: 8080}
+	i.Admin.User = "admin"
+	i.Admin.Pass = "Ohseek4aiveeM3ai"
+	i.DB.Engine = "PostgreSQL"
+	i.DB.Pass = "vee0Thoanae1daePooz0ieka"
+	env := strings.Join(hookEnv(i), "\n")
+	for _, want := range []string{
+		"DOJO_VERSION=1.5.3.1",
+		"DOJO_ROOT=/opt/dojo",
+		"DOJO_SOURCE=/opt/dojo/django-DefectDojo",
+		"DOJO_URL=" + dojoURL(i),
+		"DOJO_ADMIN_USER=admin",
+		"DOJO_DB_ENGINE=PostgreSQL",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("Expecting %s in the hook's ENV, got:\n%s", want, env)
+		}
+	}
+	for _, secret := range []string{i.Admin.Pass, i.DB.Pass} {
+		if strings.Contains(env, secret) {
+			t.Errorf("Expecting no passwords in the hook's ENV, got:\n%s", env)
+		}
+	}
+}
+
+func TestRunPostInstallHook(t *testing.T) {
+	captureLogs(t)
+	saved := runCmd
+	defer func() { runCmd = saved }()
+	runCmd = func(ctx context.Context, name string, args ...string) error { return errors.New("exit status 3") }
+
+	i := &config.InstallConfig{Root: "/opt/dojo", Source: "django-DefectDojo", PostInstallHook: "/usr/local/bin/smoke-test"}
+	if err := runPostInstallHook(context.Background(), i); err != nil {
+		t.Errorf("Expecting a failed hook to only warn by default, got %v", err)
+	}
+	i.PostInstallHookFatal = true
+	if err := runPostInstallHook(context.Background(), i); err == nil {
+		t.Error("Expecting a failed hook to fail the install with PostInstallHookFatal set")
+	}
+}
+
+func TestRunPostInstallHookEnvironment(t *testing.T) {
+	buf := captureLogs(t)
+	saved, savedR := sensStr, Redact
+	defer func() { sensStr, Redact = saved, savedR }()
+	sensStr[3] = "Ohseek4aiveeM3ai"
+	Redact = true
+
+	// The hook really runs and sees the ENV, with the admin password in its command redacted from the log
+	out := filepath.Join(t.TempDir(), "env")
+	i := &config.InstallConfig{Version: "1.5.3.1", Root: "/opt/dojo", Source: "django-DefectDojo",
+		PostInstallHook: "echo $DOJO_SOURCE > " + out + " # admin:Ohseek4aiveeM3ai"}
+	if err := runPostInstallHook(context.Background(), i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil || string(b) != "/opt/dojo/django-DefectDojo\n" {
+		t.Errorf("Expecting the hook to see DOJO_SOURCE, got %q, %v", b, err)
+	}
+	if !strings.Contains(buf.String(), "DOJO_VERSION=1.5.3.1") || strings.Contains(buf.String(), "Ohseek4aiveeM3ai") {
+		t.Errorf("Expecting the hook's ENV logged with secrets redacted, got:\n%s", buf)
+	}
+}
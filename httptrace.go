@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"time"
+)
+
+// Handles wire-level tracing of HTTP requests for debugging downloads behind proxies and load balancers
+
+// withHTTPTrace returns a context that logs the lifecycle of an HTTP request to the trace log
+// if both --http-trace and trace logging are on, otherwise ctx is returned unchanged
+func withHTTPTrace(ctx context.Context) context.Context {
+	if !HTTPTrace || !TraceOn {
+		return ctx
+	}
+
+	start := time.Now()
+	since := func() string {
+		return time.Since(start).Round(time.Microsecond).String()
+	}
+	ct := &httptrace.ClientTrace{
+		DNSStart: func(i httptrace.DNSStartInfo) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] DNS lookup started for %+v", since(), i.Host))
+		},
+		DNSDone: func(i httptrace.DNSDoneInfo) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] DNS lookup done, addresses %+v, error %+v", since(), i.Addrs, i.Err))
+		},
+		ConnectStart: func(network, addr string) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] Connecting to %+v over %+v", since(), addr, network))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] Connected to %+v, error %+v", since(), addr, err))
+		},
+		GotConn: func(i httptrace.GotConnInfo) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] Got connection to %+v, reused %+v, was idle %+v",
+				since(), i.Conn.RemoteAddr(), i.Reused, i.WasIdle))
+		},
+		TLSHandshakeStart: func() {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] TLS handshake started", since()))
+		},
+		TLSHandshakeDone: func(s tls.ConnectionState, err error) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] TLS handshake done, version %x, server name %+v, error %+v",
+				since(), s.Version, s.ServerName, err))
+		},
+		WroteRequest: func(i httptrace.WroteRequestInfo) {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] Request written, error %+v", since(), i.Err))
+		},
+		GotFirstResponseByte: func() {
+			traceMsg(fmt.Sprintf("HTTP-TRACE: [%s] Time to first response byte", since()))
+		},
+	}
+	return httptrace.WithClientTrace(ctx, ct)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTTPTraceHooks(t *testing.T) {
+	savedTrace, savedOn, savedHTTP := Trace, TraceOn, HTTPTrace
+	defer func() { Trace, TraceOn, HTTPTrace = savedTrace, savedOn, savedHTTP }()
+
+	var buf bytes.Buffer
+	Trace = log.New(&buf, "TRACE:   ", 0)
+	TraceOn = true
+	HTTPTrace = true
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tarball"))
+	}))
+	defer ts.Close()
+
+	err := downloadFile(context.Background(), ts.Client(), ts.URL+"/archive/1.0.tar.gz", filepath.Join(t.TempDir(), "dojo.tar.gz"), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error downloading: %v", err)
+	}
+	for _, want := range []string{"Connecting to", "Got connection", "Request written", "Time to first response byte"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expecting trace log to contain '%s', got:\n%s", want, buf.String())
+		}
+	}
+
+	// Off by default
+	buf.Reset()
+	HTTPTrace = false
+	err = downloadFile(context.Background(), ts.Client(), ts.URL+"/archive/1.0.tar.gz", filepath.Join(t.TempDir(), "dojo.tar.gz"), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error downloading: %v", err)
+	}
+	if strings.Contains(buf.String(), "HTTP-TRACE") {
+		t.Errorf("Expecting no HTTP trace output without --http-trace, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import "fmt"
+
+// Handles the catalogs of user-facing messages so the console can be in the configured Lang
+// while the install log stays in English for support
+
+// defaultLang is the language of the log and the fallback for messages missing from a catalog
+const defaultLang = "en"
+
+// catalogs maps a language to its messages keyed by message ID, each message is a fmt format
+var catalogs = map[string]map[string]string{
+	"en": enMessages,
+	"es": esMessages,
+}
+
+// localize returns message id in lang formatted with args, falling back to English when lang's catalog
+// is missing id and to id itself when no catalog has it
+func localize(lang string, id string, args ...interface{}) string {
+	f, ok := catalogs[lang][id]
+	if !ok {
+		f, ok = catalogs[defaultLang][id]
+	}
+	if !ok {
+		if len(args) == 0 {
+			return id
+		}
+		f = id
+	}
+	return fmt.Sprintf(f, args...)
+}
+
+// knownLang returns true if there's a catalog for lang
+func knownLang(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// enMessages - the English messages, every message ID used by godojo needs to be here
+var enMessages = map[string]string{
+	"error": "%+v",
+
+	// Starting the install
+	"install.start":     "Starting the dojo install at %s",
+	"install.container": "Container mode detected, skipping service management and not requiring root",
+	"lang.unknown":      "WARNING: No messages in language %s, using English",
+	"log.syslog":        "WARNING: Unable to log to syslog, continuing with file logging only. Error was: %+v",
+	"runtime-config":    "Error from writing the runtime config was: %+v",
+	"root.create":       "Unable to create the Dojo root directory, error was: %+v",
+	"result.write":      "Unable to write the install result to %s, error was: %+v",
+	"metrics.write":     "Unable to write the install metrics to %s, error was: %+v",
+	"ports.busy":        "WARNING: %+v, DefectDojo may not start until they're free",
+
+	// Determining the OS
+	"os.section":        "Determining OS for installation",
+	"os.found":          "OS was determined to be %+v, %+v",
+	"os.ignore-compat":  "Use --ignore-compat to install anyway",
+	"os.supported":      "DefectDojo installation on this OS is supported, continuing",
+	"arch.unsupported":  "WARNING: Architecture %s is not officially supported by DefectDojo, continuing anyway",
+	"warning":           "WARNING: %s",
+	"compat.ignored":    "WARNING: Ignoring failed compatibility check per configuration: %+v",
+	"target.osx":        "OS X is not YET a supported installation platform",
+	"target.windows":    "Windows is not a supported installation platform",
+	"target.suse":       "Older versions of SuSe Linux are not suppported, quitting",
+	"target.redhat":     "Older versions of Redhat Linux are not suppported, quitting",
+	"target.unknown":    "Unable to determine the Linux install target, quitting",
+	"target.lsb-distro": "Unable to determine distro from lsb_release command, quitting.",
+	"target.lsb-rel":    "Unable to determine release from lsb_release command, quitting.",
+	"file.open":         "Unable to open file: %+v\nError was: %v",
+	"file.read":         "Unable to read file: %+v\nError was: %v",
+	"file.close":        "Unable to close file\nError was: %v",
+
+	// Install steps
+	"step.optional-failed": "WARNING: The optional %s step failed, continuing per --keep-going",
+	"step.done":            "The %s step is complete, the log is %s",
+	"bootstrap.section":    "Bootstrapping the godojo installer",
+	"bootstrap.done":       "Boostraping godojo installer complete",
+	"python.section":       "Checking for Python 3",
+	"python.done":          "Python 3 found, install can continue",
+	"download.section":     "Downloading the source for DefectDojo",
+	"packages.section":     "Installing OS packages needed for DefectDojo",
+	"packages.done":        "Installing OS packages complete",
+	"db.remote":            "Correct configuration or install remote DB before continuing",
+	"db.install.section":   "Installing database needed for DefectDojo",
+	"db.install.done":      "Installing Database complete",
+	"db.start.section":     "Starting the database needed for DefectDojo",
+	"db.prep.section":      "Preparing the database needed for DefectDojo",
+	"db.waiting":           "Waiting %s for the database to accept connections",
+	"creds.read":           "Unable to read file with defautl credentials, cannot continue",
+	"creds.scan":           "Unable to scan file with defautl credentials, cannot continue",
+	"prep-os.section":      "Preparing the OS for DefectDojo installation",
+	"prep-os.done":         "Preparing the OS complete",
+	"settings.section":     "Creating settings.py for DefectDojo",
+	"settings.done":        "Creating settings.py for DefectDojo complete",
+	"frontend.section":     "Building the frontend for DefectDojo",
+	"frontend.skip":        "Skipping the frontend build per configuration",
+	"frontend.node-ok":     "Node.js %s is already installed, skipping installing it",
+	"frontend.node-old":    "Node.js %s is too old, installing Node.js %d.x",
+	"frontend.done":        "Building the DefectDojo frontend complete",
+	"django.section":       "Setting up Django for DefectDojo",
+	"django.done":          "Setting up Django complete",
+	"static.section":       "Collecting DefectDojo's static files",
+	"static.done":          "Collecting static files complete",
+	"fixtures.section":     "Loading the configured Django fixtures",
+	"fixtures.none":        "No LoadFixtures configured, skipping loaddata",
+	"fixtures.done":        "Loaded %d Django fixture(s)",
+	"venv.reuse":           "Reusing existing virtualenv at %+v",
+	"venv.create":          "Creating virtualenv at %+v",
+	"superuser.section":    "Creating the DefectDojo admin user %s",
+	"superuser.done":       "Creating the admin user complete",
+	"tls.section":          "Generating a self-signed TLS certificate",
+	"tls.self-signed":      "WARNING: Self-signed certificates are for local and demo installs only, not for production",
+	"tls.wrote":            "Self-signed certificate for %s written to %s",
+	"app-server.section":   "Setting up the %s app server for DefectDojo",
+	"systemd.wrote":        "Wrote systemd unit %s",
+	"systemd.container":    "Container mode, not enabling %s with systemctl",
+	"nginx.section":        "Configuring nginx for DefectDojo",
+	"nginx.missing":        "nginx isn't installed, skipping writing its config",
+	"nginx.wrote":          "Wrote nginx config for DefectDojo to %s",
+	"nginx.container":      "Container mode, nginx checked but not reloaded, start it to serve DefectDojo",
+	"schedule.section":     "Scheduling DefectDojo's maintenance tasks",
+	"reinstall.refused":    "Not reinstalling without confirmation, use --yes to reinstall unattended",
+	"reinstall.no-drop":    "godojo can't reinstall over a %s database, its prep doesn't honour DB.Drop yet so the old data would stay - drop %s by hand then run godojo",
+	"reinstall.remove":     "Removing the DefectDojo install in %s",
+	"health.section":       "Checking DefectDojo is answering",
+	"health.ok":            "DefectDojo answered at %s after %d attempt(s) over %s",
+	"health.no-nginx":      "nginx isn't installed, skipping the health check",
+	"hook.section":         "Running the post-install hook",
+	"hook.done":            "The post-install hook finished successfully",
+	"hook.failed":          "WARNING: The post-install hook failed, see the install log for its output",
+	"schedule.none":        "No scheduler configured, DefectDojo's periodic tasks won't run",
+	"schedule.no-broker":   "WARNING: No Celery broker is configured, scheduled tasks will fail until one is set up",
+	"schedule.added":       "Added cron entry: %s",
+	"schedule.present":     "Cron entries for DefectDojo were already present",
+	"manifest.write":       "Unable to write the install manifest, error was: %+v",
+
+	// Getting the DefectDojo source
+	"release.download": "Downloading the configured release of DefectDojo => version %+v",
+	"release.done":     "Successfully downloaded and extracted the DefectDojo release file",
+	"github.throttled": "GitHub's secondary rate limit was hit, retrying in %s",
+	"gzip.close":       "Unable to close the gzip reader\nError was %v",
+	"source.none":      "No source for DefectDojo downloaded per configuration",
+	"source.remove":    "Removing the existing DefectDojo source at %s",
+	"source.backup":    "Moving the existing DefectDojo source at %s to %s",
+	"source.clone":     "Downloading DefectDojo source as a branch or commit from the repo directly",
+	"source.pr":        "DefectDojo will be installed from pull request #%d",
+	"source.commit":    "Dojo will be installed from commit %+v",
+	"source.branch":    "DefectDojo will be installed from %+v branch",
+	"download.done":    "The DefectDojo source is ready in %s, nothing was installed",
+	"source.done":      "Successfully checked out the configured DefectDojo source",
+}
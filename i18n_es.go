@@ -0,0 +1,96 @@
+package main
+
+// esMessages - the Spanish messages, IDs missing here are shown in English
+var esMessages = map[string]string{
+	// Starting the install
+	"install.start":     "Iniciando la instalación de dojo el %s",
+	"install.container": "Modo contenedor detectado, se omite la gestión de servicios y no se requiere root",
+	"lang.unknown":      "AVISO: No hay mensajes en el idioma %s, se usará inglés",
+	"log.syslog":        "AVISO: No se puede registrar en syslog, se continúa solo con el archivo de registro. El error fue: %+v",
+	"runtime-config":    "Error al escribir la configuración de ejecución: %+v",
+	"root.create":       "No se puede crear el directorio raíz de Dojo, el error fue: %+v",
+	"result.write":      "No se puede escribir el resultado de la instalación en %s, el error fue: %+v",
+	"metrics.write":     "No se pueden escribir las métricas de la instalación en %s, el error fue: %+v",
+	"ports.busy":        "AVISO: %+v, es posible que DefectDojo no arranque hasta que se liberen",
+
+	// Determining the OS
+	"os.section":       "Determinando el sistema operativo para la instalación",
+	"os.found":         "El sistema operativo es %+v, %+v",
+	"os.ignore-compat": "Use --ignore-compat para instalar de todos modos",
+	"os.supported":     "La instalación de DefectDojo en este sistema operativo es compatible, continuando",
+	"arch.unsupported": "AVISO: DefectDojo no admite oficialmente la arquitectura %s, se continúa de todos modos",
+	"warning":          "AVISO: %s",
+	"compat.ignored":   "AVISO: Se ignora la comprobación de compatibilidad fallida según la configuración: %+v",
+	"target.osx":       "OS X TODAVÍA no es una plataforma de instalación compatible",
+	"target.windows":   "Windows no es una plataforma de instalación compatible",
+	"target.unknown":   "No se puede determinar el destino de instalación Linux, saliendo",
+
+	// Install steps
+	"step.optional-failed": "AVISO: El paso opcional %s falló, se continúa por --keep-going",
+	"step.done":            "El paso %s ha terminado, el registro es %s",
+	"bootstrap.section":    "Preparando el instalador godojo",
+	"bootstrap.done":       "Preparación del instalador godojo completa",
+	"python.section":       "Buscando Python 3",
+	"python.done":          "Python 3 encontrado, la instalación puede continuar",
+	"download.section":     "Descargando el código fuente de DefectDojo",
+	"packages.section":     "Instalando los paquetes del sistema necesarios para DefectDojo",
+	"packages.done":        "Instalación de los paquetes del sistema completa",
+	"db.remote":            "Corrija la configuración o instale la base de datos remota antes de continuar",
+	"db.install.section":   "Instalando la base de datos necesaria para DefectDojo",
+	"db.install.done":      "Instalación de la base de datos completa",
+	"db.start.section":     "Iniciando la base de datos necesaria para DefectDojo",
+	"db.prep.section":      "Preparando la base de datos necesaria para DefectDojo",
+	"db.waiting":           "Esperando %s a que la base de datos acepte conexiones",
+	"prep-os.section":      "Preparando el sistema operativo para la instalación de DefectDojo",
+	"prep-os.done":         "Preparación del sistema operativo completa",
+	"settings.section":     "Creando settings.py para DefectDojo",
+	"settings.done":        "Creación de settings.py para DefectDojo completa",
+	"frontend.section":     "Compilando el frontend de DefectDojo",
+	"frontend.skip":        "Se omite la compilación del frontend según la configuración",
+	"frontend.done":        "Compilación del frontend de DefectDojo completa",
+	"django.section":       "Configurando Django para DefectDojo",
+	"django.done":          "Configuración de Django completa",
+	"static.section":       "Recopilando los archivos estáticos de DefectDojo",
+	"static.done":          "Recopilación de archivos estáticos completa",
+	"fixtures.section":     "Cargando los fixtures de Django configurados",
+	"fixtures.none":        "No hay LoadFixtures configurados, se omite loaddata",
+	"fixtures.done":        "Se cargaron %d fixture(s) de Django",
+	"venv.reuse":           "Reutilizando el virtualenv existente en %+v",
+	"venv.create":          "Creando el virtualenv en %+v",
+	"superuser.section":    "Creando el usuario administrador de DefectDojo %s",
+	"superuser.done":       "Creación del usuario administrador completa",
+	"tls.section":          "Generando un certificado TLS autofirmado",
+	"tls.self-signed":      "AVISO: Los certificados autofirmados son solo para instalaciones locales y de demostración, no para producción",
+	"tls.wrote":            "Certificado autofirmado para %s escrito en %s",
+	"app-server.section":   "Configurando el servidor de aplicaciones %s para DefectDojo",
+	"nginx.section":        "Configurando nginx para DefectDojo",
+	"nginx.missing":        "nginx no está instalado, no se escribe su configuración",
+	"nginx.wrote":          "Configuración de nginx para DefectDojo escrita en %s",
+	"nginx.container":      "Modo contenedor, nginx comprobado pero no recargado, inícielo para servir DefectDojo",
+	"schedule.section":     "Programando las tareas de mantenimiento de DefectDojo",
+	"reinstall.refused":    "No se reinstala sin confirmación, use --yes para reinstalar sin supervisión",
+	"reinstall.no-drop":    "godojo no puede reinstalar sobre una base de datos %s, su preparación aún no admite DB.Drop y se conservarían los datos antiguos - elimine %s a mano y ejecute godojo",
+	"reinstall.remove":     "Eliminando la instalación de DefectDojo en %s",
+	"health.section":       "Comprobando que DefectDojo responde",
+	"health.ok":            "DefectDojo respondió en %s tras %d intento(s) en %s",
+	"health.no-nginx":      "nginx no está instalado, se omite la comprobación de salud",
+	"hook.section":         "Ejecutando el hook posterior a la instalación",
+	"hook.done":            "El hook posterior a la instalación terminó correctamente",
+	"hook.failed":          "AVISO: El hook posterior a la instalación falló, vea el registro de la instalación para su salida",
+	"schedule.none":        "No hay programador configurado, las tareas periódicas de DefectDojo no se ejecutarán",
+	"manifest.write":       "No se puede escribir el manifiesto de la instalación, el error fue: %+v",
+
+	// Getting the DefectDojo source
+	"release.download": "Descargando la versión configurada de DefectDojo => versión %+v",
+	"release.done":     "Versión de DefectDojo descargada y extraída correctamente",
+	"github.throttled": "Se alcanzó el límite secundario de solicitudes de GitHub, reintentando en %s",
+	"source.none":      "No se descarga el código fuente de DefectDojo según la configuración",
+	"source.remove":    "Eliminando el código fuente existente de DefectDojo en %s",
+	"source.backup":    "Moviendo el código fuente existente de DefectDojo de %s a %s",
+	"source.clone":     "Descargando el código fuente de DefectDojo como rama o commit directamente del repositorio",
+	"source.pr":        "DefectDojo se instalará desde el pull request #%d",
+	"source.commit":    "Dojo se instalará desde el commit %+v",
+	"source.branch":    "DefectDojo se instalará desde la rama %+v",
+	"download.done":    "El código fuente de DefectDojo está listo en %s, no se instaló nada",
+	"source.done":      "Código fuente configurado de DefectDojo obtenido correctamente",
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// useLang sets the console language for the duration of the test
+func useLang(t *testing.T, lang string) {
+	saved := Lang
+	t.Cleanup(func() { Lang = saved })
+	Lang = lang
+}
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		lang string
+		id   string
+		args []interface{}
+		want string
+	}{
+		{"en", "python.section", nil, "Checking for Python 3"},
+		{"es", "python.section", nil, "Buscando Python 3"},
+		{"es", "venv.create", []interface{}{"/opt/dojo/venv"}, "Creando el virtualenv en /opt/dojo/venv"},
+		// Missing from the Spanish catalog so it falls back to English
+		{"es", "schedule.added", []interface{}{"0 3 * * * dojo"}, "Added cron entry: 0 3 * * * dojo"},
+		// No catalog for the language at all
+		{"xx", "python.done", nil, "Python 3 found, install can continue"},
+		// Not in any catalog so the ID is the message
+		{"es", "100% done", nil, "100% done"},
+	}
+	for _, tt := range tests {
+		if got := localize(tt.lang, tt.id, tt.args...); got != tt.want {
+			t.Errorf("localize(%q, %q) = %q, expecting %q", tt.lang, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestMessagesInLang(t *testing.T) {
+	r := &recordingReporter{}
+	useReporter(t, r)
+	useLang(t, "es")
+	var log bytes.Buffer
+	logSetup(&log, nil)
+	defer logSetup(ioutil.Discard, nil)
+
+	sectionMsg("superuser.section", "admin")
+	statusMsg("nginx.missing")
+	want := []string{
+		"section:Creando el usuario administrador de DefectDojo admin",
+		"status:nginx no está instalado, no se escribe su configuración",
+	}
+	if strings.Join(r.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expecting the console in Spanish %q, got %q", want, r.calls)
+	}
+	for _, l := range []string{"SECTION: Creating the DefectDojo admin user admin", "nginx isn't installed, skipping writing its config"} {
+		if !strings.Contains(log.String(), l) {
+			t.Errorf("Expecting the log to stay in English with %q, got\n%s", l, log.String())
+		}
+	}
+}
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	verbs := regexp.MustCompile(`%[+#]?[a-z]`)
+	for lang, c := range catalogs {
+		for id, m := range c {
+			en, ok := enMessages[id]
+			if !ok {
+				t.Errorf("%s message %q isn't in the English catalog", lang, id)
+				continue
+			}
+			if got, want := verbs.FindAllString(m, -1), verbs.FindAllString(en, -1); strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("%s message %q has verbs %v, the English has %v", lang, id, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the ordered steps of a DefectDojo install
+
+// installStep - a named part of the install which is reported on as it runs
+type installStep struct {
+	name     string
+	needs    []string     // Programs the step runs which must be on the PATH before it starts
+	provides []string     // Programs the step installs for the steps after it
+	skip     bool         // If true, the config means the step has nothing to do
+	optional bool         // If true, DefectDojo is usable without the step so --keep-going continues past its failure
+	ready    func() error // If set, checks what earlier steps provide is there before running the step alone with godojo step
+	run      func(ctx context.Context) error
+}
+
+// installContext returns ctx with a deadline of timeout from now, or just cancelable if timeout is 0
+func installContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// runSteps runs each step in order, reporting to r as steps start and finish, and stops at the first error
+// except for optional steps when KeepGoing is set. If ctx's deadline passes the returned error names the step that was running
+func runSteps(ctx context.Context, r ProgressReporter, steps []installStep) error {
+	for _, s := range steps {
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return fmt.Errorf("Install timed out before the %s step: %w", s.name, err)
+		}
+		if err != nil {
+			return err
+		}
+		if s.skip {
+			traceMsg(fmt.Sprintf("Skipping install step %s per configuration", s.name))
+			continue
+		}
+		r.StepStart(s.name)
+		start := time.Now()
+		err = s.run(ctx)
+		r.StepDone(s.name, time.Since(start), err)
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			// Steps killed by the deadline fail in their own way e.g. a command killed by a signal
+			traceMsg(fmt.Sprintf("The %s step failed after the install deadline passed, error was: %+v", s.name, err))
+			return fmt.Errorf("Install timed out during the %s step: %w", s.name, ctx.Err())
+		}
+		if err != nil && s.optional && KeepGoing && ctx.Err() == nil {
+			statusMsg("step.optional-failed", s.name)
+			Warning.Printf("Optional install step %s failed, error was: %+v", s.name, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCmds runs the shell commands in c with bash through runCmd with a spinner showing prefix, so their output
+// goes to the install log and ctx stops them. A failed hard command returns an error, others are only logged
+func runCmds(ctx context.Context, prefix string, c *osCmds) error {
+	s := newSpinner(prefix)
+	s.Start()
+	defer s.Stop()
+	for i := range c.cmds {
+		err := runCmd(ctx, "bash", "-c", c.cmds[i])
+		if err == nil {
+			continue
+		}
+		if c.hard[i] {
+			return fmt.Errorf("%s: %w", c.errmsg[i], err)
+		}
+		Warning.Printf("%s, continuing as it isn't fatal. Error was: %+v", c.errmsg[i], err)
+	}
+	return nil
+}
+
+// installSteps returns the steps of an install of DefectDojo on target in the order they run
+func installSteps(c *config.DojoConfig, target targetOS) []installStep {
+	return []installStep{
+		{name: "bootstrap", needs: []string{"apt-get"}, provides: []string{"python3", "git", "curl", "gpg"}, run: func(ctx context.Context) error {
+			// Bootstrap installer
+			sectionMsg("bootstrap.section")
+			bs := osCmds{}
+			initBootstrap(target.id, &bs)
+			err := runCmds(ctx, "Bootstrapping...", &bs)
+			if err != nil {
+				return err
+			}
+			statusMsg("bootstrap.done")
+			return nil
+		}},
+		{name: "python", run: func(ctx context.Context) error {
+			sectionMsg("python.section")
+			err := checkPython(c.Install.MinPython)
+			if err != nil {
+				return err
+			}
+			statusMsg("python.done")
+			return nil
+		}},
+		{name: "download", run: func(ctx context.Context) error {
+			// Download either a release or the Dojo source
+			sectionMsg("download.section")
+			return getDojo(ctx, &c.Install)
+		}},
+		{name: "os-packages", needs: []string{"apt-get", "apt-key", "curl"}, provides: []string{"yarn", "gcc", "expect"}, run: func(ctx context.Context) error {
+			// Gather OS commands to bootstrap the install
+			sectionMsg("packages.section")
+			osInst := osCmds{}
+			initOSInst(target.id, osPackages(&c.Install, target.distro), &osInst)
+			err := runCmds(ctx, "Installing OS packages...", &osInst)
+			if err != nil {
+				return err
+			}
+			statusMsg("packages.done")
+			return nil
+		}},
+		{name: "install-db", needs: []string{"apt-get"}, run: func(ctx context.Context) error {
+			if !c.Install.DB.Local && !c.Install.DB.Exists {
+				// Remote database that doesn't exist - godojo can't help you here
+				statusMsg("db.remote")
+				return errors.New("Remote database which doens't exist confgiured - unsupported option")
+			}
+			if c.Install.DB.Exists {
+				return nil
+			}
+			// Handle the case that the DB is local and doesn't exist
+			sectionMsg("db.install.section")
+			dbInst := osCmds{}
+			installDB(target.id, &c.Install.DB, &dbInst)
+			err := runCmds(ctx, "Installing "+c.Install.DB.Engine+" database for DefectDojo...", &dbInst)
+			if err != nil {
+				return err
+			}
+			statusMsg("db.install.done")
+			return nil
+		}},
+		{name: "start-db", needs: serviceTools("service"), skip: !c.Install.DB.Local || c.Install.DB.Exists, run: func(ctx context.Context) error {
+			// Start the database if local and didn't already exist
+			sectionMsg("db.start.section")
+			dbStart := osCmds{}
+			startDB(target.id, &c.Install.DB, &dbStart)
+			if ContainerMode {
+				traceMsg("Container mode, skipping starting the database as a service")
+				dbStart = osCmds{}
+			}
+			err := runCmds(ctx, "Starting "+c.Install.DB.Engine+" database for DefectDojo...", &dbStart)
+			if err != nil {
+				return err
+			}
+			statusMsg("db.install.done")
+			return nil
+		}},
+		{name: "prep-db", run: func(ctx context.Context) error {
+			// Preapare the database for DefectDojo by:
+			// (1) Checking connectivity to the DB, (2) checking that the configured Dojo database name doesn't exit already
+			// (3) Droping the existing database if Drop = true is configured (4) Create the DefectDojo database
+			// (5) Add the DB user for DefectDojo to use
+			sectionMsg("db.prep.section")
+			dbConf := &c.Install.DB
+			err := dbPrep(target.id, dbConf)
+			if err != nil {
+				return err
+			}
+			manifest.addDatabase(dbConf.Name)
+			manifest.addDBUser(dbConf.User)
+			return nil
+		}},
+		{name: "prep-os", needs: []string{"python3", "getent", "groupadd", "id", "useradd", "chown"}, run: func(ctx context.Context) error {
+			// Prep OS (user, virtualenv, chownership)
+			sectionMsg("prep-os.section")
+			err := setupVirtualenv(&c.Install)
+			if err != nil {
+				return fmt.Errorf("Unable to setup virtualenv for DefectDojo, error was: %w", err)
+			}
+			err = installRequirements(ctx, &c.Install)
+			if err != nil {
+				return err
+			}
+			prepCmds := osCmds{}
+			osPrep(target.id, &c.Install, &prepCmds)
+			err = runCmds(ctx, "Preparing the OS for DefectDojo...", &prepCmds)
+			if err != nil {
+				return err
+			}
+			manifest.addPath(filepath.Join(c.Install.Root, "bin"))
+			manifest.addPath(filepath.Join(c.Install.Root, "logs"))
+			manifest.addOSUser(c.Install.OS.User)
+			manifest.addOSUser(c.Install.OS.Group)
+			statusMsg("prep-os.done")
+			return nil
+		}},
+		{name: "settings", needs: []string{"cp", "chown"}, run: func(ctx context.Context) error {
+			// Create settings.py for DefectDojo
+			sectionMsg("settings.section")
+			settCmds := osCmds{}
+			err := createSettingsPy(target.id, c, &settCmds)
+			if err != nil {
+				return err
+			}
+			err = runCmds(ctx, "Creating settings.py for DefectDojo...", &settCmds)
+			if err != nil {
+				return err
+			}
+			manifest.addPath(settingsFile(&c.Install, ".env.prod"))
+			manifest.addPath(settingsFile(&c.Install, "settings.py"))
+			statusMsg("settings.done")
+			return nil
+		}},
+		{name: "frontend", optional: true, needs: []string{"bash", "curl", "yarn"}, skip: c.Install.SkipFrontend, run: func(ctx context.Context) error {
+			sectionMsg("frontend.section")
+			return setupFrontend(ctx, &c.Install)
+		}},
+		{name: "django", needs: []string{"bash", "chown"}, ready: func() error { return appReady(&c.Install) }, run: func(ctx context.Context) error {
+			// Django/Python installs
+			sectionMsg("django.section")
+			err := waitForDB(ctx, &c.Install, c.Install.DB.Wait)
+			if err != nil {
+				return err
+			}
+			setupDj := osCmds{}
+			setupDjango(target.id, c, &setupDj)
+			err = runCmds(ctx, "Setting up Django for DefectDojo...", &setupDj)
+			if err != nil {
+				return err
+			}
+			statusMsg("django.done")
+			return nil
+		}},
+		{name: "collectstatic", needs: []string{"sh", "chown", "chmod"}, ready: func() error { return appReady(&c.Install) }, run: func(ctx context.Context) error {
+			sectionMsg("static.section")
+			return runCollectStatic(ctx, &c.Install)
+		}},
+		{name: "load-fixtures", needs: []string{"sh"}, skip: len(c.Install.LoadFixtures) == 0, ready: func() error { return appReady(&c.Install) }, run: func(ctx context.Context) error {
+			sectionMsg("fixtures.section")
+			return loadInitialData(ctx, &c.Install)
+		}},
+		{name: "superuser", needs: []string{"bash", "expect"}, ready: func() error { return appReady(&c.Install) }, run: func(ctx context.Context) error {
+			sectionMsg("superuser.section", c.Install.Admin.User)
+			suCmds := osCmds{}
+			createSuperuser(target.id, c, &suCmds)
+			err := runCmds(ctx, "Creating the DefectDojo admin user...", &suCmds)
+			if err != nil {
+				return err
+			}
+			statusMsg("superuser.done")
+			return nil
+		}},
+		{name: "tls", skip: !c.Install.TLS.SelfSigned, run: func(ctx context.Context) error {
+			sectionMsg("tls.section")
+			return setupTLS(&c.Install)
+		}},
+		{name: "app-server", needs: serviceTools("systemctl"), run: func(ctx context.Context) error {
+			sectionMsg("app-server.section", appServerType(&c.Install))
+			return installUnit(ctx, appUnit(&c.Install))
+		}},
+		{name: "nginx", optional: true, needs: serviceTools("systemctl"), run: func(ctx context.Context) error {
+			sectionMsg("nginx.section")
+			return setupNginx(ctx, &c.Install)
+		}},
+		// Static items
+
+		// Celery / TODO: RabitMQ
+		{name: "schedule", optional: true, needs: scheduleNeeds(&c.Install), run: func(ctx context.Context) error {
+			sectionMsg("schedule.section")
+			return setupSchedule(ctx, c)
+		}},
+
+		{name: "health-check", skip: c.Install.HealthTimeout == 0 || ContainerMode, run: func(ctx context.Context) error {
+			sectionMsg("health.section")
+			return runHealthCheck(ctx, &c.Install)
+		}},
+
+		// Optional Installs
+
+		{name: "manifest", run: func(ctx context.Context) error {
+			// Record what the install created for later audit or uninstall
+			manifest.Version = installedVersion(&c.Install)
+			err := writeManifest(&manifest, c.Install.Root)
+			if err != nil {
+				// Not fatal, the install itself is done
+				errorMsg("manifest.write", err)
+			}
+			return nil
+		}},
+		{name: "post-install-hook", needs: []string{"env", "sh"}, skip: c.Install.PostInstallHook == "", run: func(ctx context.Context) error {
+			sectionMsg("hook.section")
+			return runPostInstallHook(ctx, &c.Install)
+		}},
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	"github.com/spf13/viper"
+)
+
+func TestRunStepsTimeout(t *testing.T) {
+	ctx, cancel := installContext(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	ran := []string{}
+	steps := []installStep{
+		{name: "download", run: func(ctx context.Context) error { ran = append(ran, "download"); return nil }},
+		{name: "pip", run: func(ctx context.Context) error {
+			ran = append(ran, "pip")
+			select {
+			case <-ctx.Done():
+				// Like a command killed once the context is done
+				return errors.New("signal: killed")
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		}},
+		{name: "django", run: func(ctx context.Context) error { ran = append(ran, "django"); return nil }},
+	}
+	start := time.Now()
+	err := runSteps(ctx, &recordingReporter{}, steps)
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expecting the timeout to cancel the slow step, took %s", time.Since(start))
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "pip") {
+		t.Fatalf("Expecting a deadline error naming the pip step, got %v", err)
+	}
+	if exitCode(err) != exitInterrupted {
+		t.Errorf("Expecting exit code %d, got %d", exitInterrupted, exitCode(err))
+	}
+	if strings.Join(ran, ",") != "download,pip" {
+		t.Errorf("Expecting no steps after the timeout, ran %v", ran)
+	}
+}
+
+func TestRunStepsTimeoutBetweenSteps(t *testing.T) {
+	ctx, cancel := installContext(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	steps := []installStep{
+		// Ignores the context and finishes late
+		{name: "slow", run: func(ctx context.Context) error { time.Sleep(50 * time.Millisecond); return nil }},
+		{name: "next", run: func(ctx context.Context) error { t.Errorf("Expecting next not to run"); return nil }},
+	}
+	err := runSteps(ctx, &recordingReporter{}, steps)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "before the next step") {
+		t.Errorf("Expecting a deadline error naming the next step, got %v", err)
+	}
+}
+
+func TestInstallContextUnlimited(t *testing.T) {
+	ctx, cancel := installContext(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("Expecting no deadline for an InstallTimeout of 0")
+	}
+}
+
+func TestInstallTimeoutConfig(t *testing.T) {
+	body := strings.Replace(sampleConfig, "Install:\n", "Install:\n  InstallTimeout: 45m\n", 1)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), installFlags(), &c)
+		if err != nil {
+			t.Fatalf("Unable to load config: %v", err)
+		}
+		if c.Install.InstallTimeout != 45*time.Minute {
+			t.Errorf("Expecting an InstallTimeout of 45m, got %s", c.Install.InstallTimeout)
+		}
+	})
+}
+
+func TestRunStepsKeepGoing(t *testing.T) {
+	saved := KeepGoing
+	defer func() { KeepGoing = saved }()
+	failing := errors.New("yarn build failed")
+	steps := func(ran *[]string, optional bool) []installStep {
+		return []installStep{
+			{name: "django", run: func(ctx context.Context) error { *ran = append(*ran, "django"); return nil }},
+			{name: "frontend", optional: optional, run: func(ctx context.Context) error { *ran = append(*ran, "frontend"); return failing }},
+			{name: "manifest", run: func(ctx context.Context) error { *ran = append(*ran, "manifest"); return nil }},
+		}
+	}
+
+	KeepGoing = true
+	ran := []string{}
+	rec := &resultReporter{ProgressReporter: &recordingReporter{}}
+	err := runSteps(context.Background(), rec, steps(&ran, true))
+	if err != nil || strings.Join(ran, ",") != "django,frontend,manifest" {
+		t.Errorf("Expecting every step run past the optional failure, got %v after %v", err, ran)
+	}
+	if f := rec.failed(); len(f) != 1 || f[0] != "frontend" {
+		t.Errorf("Expecting frontend recorded as failed, got %v", f)
+	}
+
+	// A required step still stops the install with --keep-going
+	ran = []string{}
+	err = runSteps(context.Background(), &recordingReporter{}, steps(&ran, false))
+	if err != failing || strings.Join(ran, ",") != "django,frontend" {
+		t.Errorf("Expecting the required failure to stop the install, got %v after %v", err, ran)
+	}
+
+	// Without --keep-going an optional failure stops it too
+	KeepGoing = false
+	ran = []string{}
+	err = runSteps(context.Background(), &recordingReporter{}, steps(&ran, true))
+	if err != failing || strings.Join(ran, ",") != "django,frontend" {
+		t.Errorf("Expecting the optional failure to stop the install without --keep-going, got %v after %v", err, ran)
+	}
+}
+
+func TestRunCmdsHardFailure(t *testing.T) {
+	saved, savedQuiet := runCmd, Quiet
+	t.Cleanup(func() { runCmd, Quiet = saved, savedQuiet })
+	Quiet = true
+	ran := []string{}
+	runCmd = func(ctx context.Context, name string, args ...string) error {
+		ran = append(ran, args[len(args)-1])
+		if strings.HasPrefix(args[len(args)-1], "fail") {
+			return &dojoerr.CmdError{Cmd: args[len(args)-1], ExitCode: 1}
+		}
+		return nil
+	}
+	c := osCmds{
+		cmds:   []string{"fail soft", "apt-get update", "fail hard", "never run"},
+		errmsg: []string{"Soft failure", "Unable to update", "Hard failure", "Never"},
+		hard:   []bool{false, true, true, true},
+	}
+	steps := []installStep{{name: "os-packages", run: func(ctx context.Context) error { return runCmds(ctx, "Installing...", &c) }}}
+	err := runSteps(context.Background(), &recordingReporter{}, steps)
+	var cErr *dojoerr.CmdError
+	if !errors.As(err, &cErr) || !strings.HasPrefix(err.Error(), "Hard failure: ") {
+		t.Errorf("Expecting the hard failure returned to runSteps, got %v", err)
+	}
+	if strings.Join(ran, ",") != "fail soft,apt-get update,fail hard" {
+		t.Errorf("Expecting the commands run up to the hard failure, ran %v", ran)
+	}
+}
+
+func TestRunStepsTimeoutStopsCommands(t *testing.T) {
+	captureLogs(t)
+	ctx, cancel := installContext(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c := osCmds{cmds: []string{"sleep 10"}, errmsg: []string{"Unable to install OS packages"}, hard: []bool{true}}
+	steps := []installStep{{name: "os-packages", run: func(ctx context.Context) error { return runCmds(ctx, "Installing...", &c) }}}
+	start := time.Now()
+	err := runSteps(ctx, &recordingReporter{}, steps)
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expecting InstallTimeout to kill the hung command, took %s", time.Since(start))
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "os-packages") {
+		t.Errorf("Expecting a deadline error naming the os-packages step, got %v", err)
+	}
+}
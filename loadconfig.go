@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles reading the net of the config file + ENV variables + command-line flags into a DojoConfig
+
+// configFormats are the config file extensions searched for, in order of preference
+var configFormats = []string{"yml", "yaml", "json", "toml"}
+
+// errNoConfig is returned by loadConfig when there is no config file or URL to read
+var errNoConfig = fmt.Errorf("Unable to find a godojo config file, looked for dojoConfig.%s",
+	strings.Join(configFormats, ", dojoConfig."))
+
+// envAliases are additional ENV variables accepted for a config key beyond the DD_ prefixed default
+var envAliases = map[string]string{
+	// DD_GITHUB_TOKEN is shorter and more familiar than DD_INSTALL_GITHUBTOKEN
+	"Install.GitHubToken": "DD_GITHUB_TOKEN",
+	// Kept out of config files since it protects the secrets written from them
+	"Install.ConfigPassphrase": "DD_CONFIG_PASSPHRASE",
+	// Matches DD_CONFIG_PASSPHRASE for keeping a secret out of config files
+	"Install.SSHKeyPass": "DD_SSH_KEY_PASS",
+}
+
+// setupViper configures v to read dojoConfig from the current directory, DD_ ENV variables, and the flags in fs
+func setupViper(v *viper.Viper, fs *pflag.FlagSet) error {
+	// Setup viper config - an explicit --config wins over searching the current directory
+	cfgFile := ""
+	if f := fs.Lookup("config"); f != nil {
+		cfgFile = f.Value.String()
+	}
+	if cfgFile == "" {
+		cfgFile = findConfig(".")
+	}
+	if cfgFile != "" {
+		v.SetConfigFile(cfgFile)
+	}
+
+	// Defaults for keys which may be missing from older config files
+	v.SetDefault("Install.WriteRuntimeConfig", true)
+	v.SetDefault("Install.Container", "auto")
+	v.SetDefault("Install.MinPython", "3.6")
+	v.SetDefault("Install.RepoOwner", defaultRepoOwner)
+	v.SetDefault("Install.RepoName", defaultRepoName)
+	v.SetDefault("Install.MinFreeInodes", defaultMinFreeInodes)
+	v.SetDefault("Install.Lang", defaultLang)
+	v.SetDefault("Install.Umask", defaultUmask)
+	v.SetDefault("Install.DB.Wait", defaultDBWait)
+	v.SetDefault("Install.ConnectTimeout", defaultConnectTimeout)
+	v.SetDefault("Install.TLSHandshakeTimeout", defaultTLSHandshakeTimeout)
+	v.SetDefault("Install.ResponseHeaderTimeout", defaultResponseHeaderTimeout)
+	v.SetDefault("Install.HealthTimeout", defaultHealthTimeout)
+	v.SetDefault("Install.HealthInterval", defaultHealthInterval)
+	v.SetDefault("Install.ReleaseCacheTTL", defaultReleaseCacheTTL)
+	v.SetDefault("Install.MaxRetryAfter", defaultMaxRetryAfter)
+
+	// Setup ENV variables
+	v.SetEnvPrefix("DD")
+	replace := strings.NewReplacer(".", "_")
+	v.SetEnvKeyReplacer(replace)
+	v.AutomaticEnv()
+	for key, env := range envAliases {
+		err := v.BindEnv(key, env, envName(key))
+		if err != nil {
+			return fmt.Errorf("Unable to setup the %s environmental variable: %w", env, err)
+		}
+	}
+
+	// Flags override the config file and ENV variables
+	err := bindFlags(v, fs)
+	if err != nil {
+		return fmt.Errorf("Unable to setup the command-line flags: %w", err)
+	}
+	return nil
+}
+
+// loadConfig reads the config file, ENV variables, and parsed flags in fs into c
+// If no config file is found, c holds just ENV variables, flags and defaults and errNoConfig is returned
+func loadConfig(v *viper.Viper, fs *pflag.FlagSet, c *config.DojoConfig) error {
+	err := setupViper(v, fs)
+	if err != nil {
+		return err
+	}
+
+	// A config from --config-url can stand in for a local config file
+	urlConfig = nil
+	cfgURL := ""
+	if f := fs.Lookup("config-url"); f != nil {
+		cfgURL = f.Value.String()
+	}
+
+	// Read the config file, the format is set by its extension
+	if v.ConfigFileUsed() == "" && cfgURL == "" {
+		// Still fill c from ENV variables, flags and defaults for interactive installs
+		err = v.Unmarshal(c)
+		if err != nil {
+			return fmt.Errorf("Unable to set the config values based on ENV variables: %w", err)
+		}
+		err = expandConfig(c)
+		if err != nil {
+			return err
+		}
+		return errNoConfig
+	}
+	if v.ConfigFileUsed() != "" {
+		err = v.ReadInConfig()
+		if err != nil {
+			return fmt.Errorf("Unable to read the godojo config file (%s): %w", v.ConfigFileUsed(), err)
+		}
+	}
+
+	// Merge the remote config over the config file
+	if cfgURL != "" {
+		auth := os.Getenv("DD_CONFIG_URL_AUTH")
+		if f := fs.Lookup("config-url-auth"); f != nil && f.Value.String() != "" {
+			auth = f.Value.String()
+		}
+		// Fetched with a client built from the config file, ENV variables and flags so CACertFile, the proxy
+		// and timeouts apply to it as they do to the downloads
+		local := config.DojoConfig{}
+		err = v.Unmarshal(&local)
+		if err != nil {
+			return fmt.Errorf("Unable to set the config values based on config file and ENV variables: %w", err)
+		}
+		err = local.ExpandPaths()
+		if err != nil {
+			return err
+		}
+		client, err := newHTTPClient(&local.Install)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), configURLTimeout)
+		defer cancel()
+		err = mergeConfigURL(ctx, client, v, cfgURL, auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Marshall the config values into the DojoConfig struct
+	err = v.Unmarshal(c)
+	if err != nil {
+		return fmt.Errorf("Unable to set the config values based on config file and ENV variables: %w", err)
+	}
+	return expandConfig(c)
+}
+
+// expandConfig expands the paths in c then reads the secrets given as files or secret:// values into it
+func expandConfig(c *config.DojoConfig) error {
+	err := c.ExpandPaths()
+	if err != nil {
+		return err
+	}
+	return config.ResolveSecrets(c)
+}
+
+// criticalKeys are config keys an install shouldn't pick up a default for by accident
+var criticalKeys = []string{"Install.Version", "Install.Root", "Install.DB.User", "Install.DB.Pass"}
+
+// secretFileKeys maps the criticalKeys which can be set with a *File field instead to that field
+var secretFileKeys = map[string]string{"Install.DB.Pass": "Install.DB.PassFile", "Install.Admin.Pass": "Install.Admin.PassFile"}
+
+// defaultedKeys returns the criticalKeys v didn't get from the config file, ENV variables or flags, either
+// directly, as a secret:// value or with their *File field
+// SQLite has no DB credentials so they're skipped for it
+func defaultedKeys(v *viper.Viper, i *config.InstallConfig) []string {
+	keys := []string{}
+	for _, k := range criticalKeys {
+		if strings.HasPrefix(k, "Install.DB.") && i.DB.Engine == "SQLite" {
+			continue
+		}
+		if f, ok := secretFileKeys[k]; ok && v.IsSet(f) {
+			continue
+		}
+		if !v.IsSet(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// defaultedWarning describes the defaulted keys with the value each ended up with, passwords are never shown
+func defaultedWarning(keys []string, i *config.InstallConfig) string {
+	values := map[string]string{"Install.Version": i.Version, "Install.Root": i.Root, "Install.DB.User": i.DB.User}
+	shown := make([]string, len(keys))
+	for n, k := range keys {
+		v, ok := values[k]
+		switch {
+		case !ok:
+			shown[n] = k
+		case v == "":
+			shown[n] = k + " (empty)"
+		default:
+			shown[n] = k + "=" + v
+		}
+	}
+	return "These critical values weren't set by the config file, ENV variables or flags: " + strings.Join(shown, ", ")
+}
+
+// envName returns the default DD_ ENV variable for a config key e.g. DD_INSTALL_DB_PASS for Install.DB.Pass
+func envName(key string) string {
+	return "DD_" + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+// findConfig returns the first dojoConfig file in dir by configFormats order or "" if there are none
+func findConfig(dir string) string {
+	for _, ext := range configFormats {
+		p := filepath.Join(dir, "dojoConfig."+ext)
+		_, err := os.Stat(p)
+		if err == nil {
+			return p
+		}
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+// Equivalent configs in each supported format
+var formatConfigs = map[string]string{
+	"dojoConfig.yml": sampleConfig,
+	"dojoConfig.json": `{
+  "Install": {
+    "Version": "1.5.3.1",
+    "Root": "/opt/dojo",
+    "DB": {"Name": "dojodb", "User": "dojodbusr", "Pass": "vee0Thoanae1daePooz0ieka"},
+    "Admin": {"User": "admin", "Pass": "Ohseek4aiveeM3ai"}
+  },
+  "Settings": {"Secret": {"Key": "uu6ahHei3ohquoh1"}}
+}`,
+	"dojoConfig.toml": `
+[Install]
+Version = "1.5.3.1"
+Root = "/opt/dojo"
+
+[Install.DB]
+Name = "dojodb"
+User = "dojodbusr"
+Pass = "vee0Thoanae1daePooz0ieka"
+
+[Install.Admin]
+User = "admin"
+Pass = "Ohseek4aiveeM3ai"
+
+[Settings.Secret]
+Key = "uu6ahHei3ohquoh1"
+`,
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	results := map[string]config.DojoConfig{}
+	for name, body := range formatConfigs {
+		inConfigDir(t, name, body, func() {
+			c := config.DojoConfig{}
+			v := viper.New()
+			err := loadConfig(v, installFlags(), &c)
+			if err != nil {
+				t.Fatalf("Unable to load %s: %v", name, err)
+			}
+			if !strings.HasSuffix(v.ConfigFileUsed(), name) {
+				t.Errorf("Expecting %s to be read, got %s", name, v.ConfigFileUsed())
+			}
+			results[name] = c
+		})
+	}
+
+	want := results["dojoConfig.yml"]
+	if want.Install.DB.Pass != "vee0Thoanae1daePooz0ieka" || want.Settings.Secret.Key != "uu6ahHei3ohquoh1" {
+		t.Fatalf("YAML config wasn't loaded as expected: %+v", want)
+	}
+	for name, c := range results {
+		if !reflect.DeepEqual(c, want) {
+			t.Errorf("Config from %s differs from YAML:\n got  %+v\n want %+v", name, c, want)
+		}
+	}
+}
+
+func TestLoadConfigFlag(t *testing.T) {
+	inConfigDir(t, "other.toml", formatConfigs["dojoConfig.toml"], func() {
+		fs := installFlags()
+		fs.Parse([]string{"--config", "other.toml"})
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), fs, &c)
+		if err != nil {
+			t.Fatalf("Unable to load --config file: %v", err)
+		}
+		if c.Install.Admin.Pass != "Ohseek4aiveeM3ai" {
+			t.Errorf("Expecting the --config file to be read, got %+v", c.Install.Admin)
+		}
+	})
+}
+
+func TestLoadConfigErrorNamesFile(t *testing.T) {
+	inConfigDir(t, "dojoConfig.json", "{ not json", func() {
+		err := loadConfig(viper.New(), installFlags(), &config.DojoConfig{})
+		if err == nil || !strings.Contains(err.Error(), "dojoConfig.json") {
+			t.Errorf("Expecting the error to name dojoConfig.json, got %v", err)
+		}
+	})
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	err := loadConfig(viper.New(), installFlags(), &config.DojoConfig{})
+	if err == nil || !strings.Contains(err.Error(), "dojoConfig.yml") {
+		t.Errorf("Expecting a missing config error listing the searched names, got %v", err)
+	}
+}
+
+func TestDefaultedKeys(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"Install:\n  DB:\n    Engine: MySQL\n", []string{"Install.Version", "Install.Root", "Install.DB.User", "Install.DB.Pass"}},
+		{"Install:\n  Version: 1.5.3.1\n  DB:\n    Engine: MySQL\n    User: dojodbusr\n", []string{"Install.Root", "Install.DB.Pass"}},
+		// SQLite doesn't need DB credentials
+		{"Install:\n  Version: 1.5.3.1\n  DB:\n    Engine: SQLite\n", []string{"Install.Root"}},
+		{sampleConfig, []string{}},
+	}
+	for _, tt := range tests {
+		inConfigDir(t, "dojoConfig.yml", tt.body, func() {
+			c := config.DojoConfig{}
+			v := viper.New()
+			if err := loadConfig(v, installFlags(), &c); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := defaultedKeys(v, &c.Install); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expecting defaulted keys %v for\n%s\ngot %v", tt.want, tt.body, got)
+			}
+		})
+	}
+
+	// Passwords from a *File field or a secret:// value count as set
+	pass := filepath.Join(t.TempDir(), "db_pass")
+	writeFile(t, pass, "vee0Thoanae1daePooz0ieka\n")
+	t.Setenv("DOJO_TEST_DB_PASS", "vee0Thoanae1daePooz0ieka")
+	for _, db := range []string{"PassFile: \"" + pass + "\"", "Pass: \"secret://env/DOJO_TEST_DB_PASS\""} {
+		body := "Install:\n  Version: 1.5.3.1\n  Root: /opt/dojo\n  DB:\n    Engine: MySQL\n    User: dojodbusr\n    " + db + "\n"
+		inConfigDir(t, "dojoConfig.yml", body, func() {
+			c := config.DojoConfig{}
+			v := viper.New()
+			if err := loadConfig(v, installFlags(), &c); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := defaultedKeys(v, &c.Install); len(got) != 0 {
+				t.Errorf("Expecting the DB password set with %s to not be defaulted, got %v", db, got)
+			}
+		})
+	}
+
+	// Set by an ENV variable counts as set
+	inConfigDir(t, "dojoConfig.yml", "Install:\n  Version: 1.5.3.1\n  DB:\n    Engine: SQLite\n", func() {
+		os.Setenv("DD_INSTALL_ROOT", "/srv/dojo")
+		defer os.Unsetenv("DD_INSTALL_ROOT")
+		c := config.DojoConfig{}
+		v := viper.New()
+		loadConfig(v, installFlags(), &c)
+		if got := defaultedKeys(v, &c.Install); len(got) != 0 {
+			t.Errorf("Expecting Root set by DD_INSTALL_ROOT to not be defaulted, got %v", got)
+		}
+	})
+
+	w := defaultedWarning([]string{"Install.Root", "Install.DB.Pass"}, &config.InstallConfig{})
+	if !strings.HasSuffix(w, ": Install.Root (empty), Install.DB.Pass") {
+		t.Errorf("Expecting the warning to list the keys without secrets, got %s", w)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Handles the lock file which stops two installs running against the same Root at once
+
+// lockName is the lock file created in the install root
+const lockName = ".godojo.lock"
+
+// lockGrace is how long a lock file without a PID in it is taken to be held, after that it's reclaimed
+var lockGrace = time.Minute
+
+// lockHeldError - returned when a live process already holds the install lock
+type lockHeldError struct {
+	path string
+	pid  int
+}
+
+func (e *lockHeldError) Error() string {
+	if e.pid == 0 {
+		return fmt.Sprintf("another install is in progress, remove %s if that's not the case", e.path)
+	}
+	return fmt.Sprintf("another install is in progress (pid %d), remove %s if that's not the case", e.pid, e.path)
+}
+
+// acquireLock creates the lock file in root holding this process's PID and returns a func removing it
+// A lock left by a process which is no longer running is reclaimed
+func acquireLock(root string) (func() error, error) {
+	p := filepath.Join(root, lockName)
+	for {
+		err := linkLock(p)
+		if err == nil {
+			return func() error { return os.Remove(p) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("Unable to create lock file %s: %w", p, err)
+		}
+
+		pid, err := lockPID(p)
+		if err == nil && processAlive(pid) {
+			return nil, &lockHeldError{path: p, pid: pid}
+		}
+		if err != nil && !os.IsNotExist(err) {
+			// Another install may be writing it, only a lock that has been without a PID for a while is stale
+			fi, serr := os.Stat(p)
+			if serr == nil && time.Since(fi.ModTime()) < lockGrace {
+				return nil, &lockHeldError{path: p}
+			}
+		}
+		traceMsg(fmt.Sprintf("Reclaiming stale lock file %s", p))
+		err = os.Remove(p)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("Unable to remove stale lock file %s: %w", p, err)
+		}
+	}
+}
+
+// linkLock writes this process's PID to a temporary file and hard links it to p so another install never
+// sees the lock before the PID is in it, the error is an os.IsExist one if p is already there
+func linkLock(p string) error {
+	f, err := ioutil.TempFile(filepath.Dir(p), lockName+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(f.Name(), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Link(f.Name(), p)
+}
+
+// lockPID returns the PID held in the lock file at p
+func lockPID(p string) (int, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// runStepsLocked runs steps with runSteps holding the install lock in root, which is removed however they end
+func runStepsLocked(ctx context.Context, root string, r ProgressReporter, steps []installStep) error {
+	unlock, err := acquireLock(root)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := unlock()
+		if err != nil {
+			Warning.Printf("Unable to remove the install lock file, error was: %+v", err)
+		}
+	}()
+	return runSteps(ctx, r, steps)
+}
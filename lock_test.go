@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	root := t.TempDir()
+	release, err := acquireLock(root)
+	if err != nil {
+		t.Fatalf("Unexpected error acquiring the lock: %v", err)
+	}
+	pid, err := lockPID(filepath.Join(root, lockName))
+	if err != nil || pid != os.Getpid() {
+		t.Errorf("Expecting the lock to hold pid %d, got %d, %v", os.Getpid(), pid, err)
+	}
+	if err := release(); err != nil {
+		t.Fatalf("Unexpected error releasing the lock: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, lockName)); !os.IsNotExist(err) {
+		t.Errorf("Expecting release to remove the lock file, got %v", err)
+	}
+}
+
+func TestAcquireLockBlocked(t *testing.T) {
+	root := t.TempDir()
+	// The test runner's parent is alive for the whole test
+	other := os.Getppid()
+	writeFile(t, filepath.Join(root, lockName), strconv.Itoa(other)+"\n")
+
+	_, err := acquireLock(root)
+	var held *lockHeldError
+	if !errors.As(err, &held) || held.pid != other {
+		t.Fatalf("Expecting the lock to be held by pid %d, got %v", other, err)
+	}
+	if !strings.Contains(err.Error(), "another install is in progress (pid "+strconv.Itoa(other)+")") {
+		t.Errorf("Expecting a message naming the other install, got %q", err.Error())
+	}
+}
+
+func TestAcquireLockStale(t *testing.T) {
+	// A process that has exited leaves behind a pid nothing is using
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("Unable to run true: %v", err)
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, lockName), strconv.Itoa(cmd.Process.Pid)+"\n")
+
+	release, err := acquireLock(root)
+	if err != nil {
+		t.Fatalf("Expecting the stale lock to be reclaimed, got %v", err)
+	}
+	defer release()
+	pid, _ := lockPID(filepath.Join(root, lockName))
+	if pid != os.Getpid() {
+		t.Errorf("Expecting the reclaimed lock to hold pid %d, got %d", os.Getpid(), pid)
+	}
+
+	// Garbage left in the lock file is stale too once it's older than lockGrace
+	release()
+	writeFile(t, filepath.Join(root, lockName), "not a pid")
+	old := time.Now().Add(-2 * lockGrace)
+	if err := os.Chtimes(filepath.Join(root, lockName), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireLock(root); err != nil {
+		t.Errorf("Expecting an old unreadable lock to be reclaimed, got %v", err)
+	}
+}
+
+func TestAcquireLockUnwritten(t *testing.T) {
+	// An empty lock file may be another install that hasn't written its PID yet
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, lockName), "")
+	_, err := acquireLock(root)
+	var held *lockHeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Expecting a fresh empty lock to be held, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "another install is in progress, remove") {
+		t.Errorf("Expecting a message without a pid, got %q", err.Error())
+	}
+}
+
+func TestAcquireLockRace(t *testing.T) {
+	// Installs starting together must never find a lock without its PID and reclaim it as stale
+	root := t.TempDir()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	got := 0
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := acquireLock(root)
+			var held *lockHeldError
+			if err != nil && !errors.As(err, &held) {
+				t.Errorf("Unexpected error acquiring the lock: %v", err)
+			}
+			if err == nil {
+				mu.Lock()
+				got++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if got != 1 {
+		t.Errorf("Expecting exactly one install to get the lock, %d did", got)
+	}
+	entries, _ := ioutil.ReadDir(root)
+	if len(entries) != 1 {
+		t.Errorf("Expecting only the lock file in root, got %v", entries)
+	}
+}
+
+func TestRunStepsLockedFailure(t *testing.T) {
+	captureLogs(t)
+	root := t.TempDir()
+	c := osCmds{cmds: []string{"exit 3"}, errmsg: []string{"Failed during database migrate"}, hard: []bool{true}}
+	held := false
+	steps := []installStep{{name: "django", run: func(ctx context.Context) error {
+		_, err := os.Stat(filepath.Join(root, lockName))
+		held = err == nil
+		return runCmds(ctx, "Setting up Django...", &c)
+	}}}
+	err := runStepsLocked(context.Background(), root, &recordingReporter{}, steps)
+	if err == nil || !held {
+		t.Fatalf("Expecting the step to fail while holding the lock, got %v and held %v", err, held)
+	}
+	if _, err := os.Stat(filepath.Join(root, lockName)); !os.IsNotExist(err) {
+		t.Errorf("Expecting a failed step to leave no lock file, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Handles the header at the top of each install log describing where and how godojo was run
+
+// hostDistro returns the distro and release from /etc/os-release e.g. ubuntu:18.04 or unknown
+// It never exits so it's safe to call before the OS is checked
+func hostDistro(osRelease string) string {
+	_, err := os.Stat(osRelease)
+	if err != nil {
+		return "unknown"
+	}
+	_, _, id := parseOSRelease(osRelease)
+	if id == ":" {
+		return "unknown"
+	}
+	return id
+}
+
+// secretFlags are flags whose values are secrets but aren't config keys registered with InitRedact
+var secretFlags = []string{"config-url-auth"}
+
+// redactArgs returns a copy of args with the values of secretFlags redacted
+func redactArgs(args []string) []string {
+	clean := make([]string, len(args))
+	copy(clean, args)
+	for i := range clean {
+		for _, f := range secretFlags {
+			switch {
+			case strings.HasPrefix(clean[i], "--"+f+"="):
+				clean[i] = "--" + f + "==[REDACTED]="
+			case clean[i] == "--"+f && i+1 < len(clean):
+				clean[i+1] = "=[REDACTED]="
+			}
+		}
+	}
+	return clean
+}
+
+// logHeader writes the godojo and Go versions, platform, host, command line and the Install config
+// held by v with secrets redacted so a log can be triaged on its own
+func logHeader(w io.Writer, v *viper.Viper, args []string) error {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	cfgFile := v.ConfigFileUsed()
+	if cfgFile == "" {
+		cfgFile = "none"
+	}
+	lines := []string{
+		"==============================================================================",
+		"godojo version: " + version,
+		"Go version:     " + runtime.Version(),
+		"Platform:       " + runtime.GOOS + "/" + runtime.GOARCH,
+		"Distro:         " + hostDistro("/etc/os-release"),
+		"Hostname:       " + host,
+		"Command line:   " + Redactatron(strings.Join(redactArgs(args), " "), true),
+		"Config file:    " + cfgFile,
+		"Install config:",
+	}
+	keys := []string{}
+	for _, k := range v.AllKeys() {
+		if strings.HasPrefix(k, "install.") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("  %s: %s", k, redactValue(k, v.Get(k))))
+	}
+	lines = append(lines, "==============================================================================")
+	_, err = fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+func TestLogHeader(t *testing.T) {
+	v := viper.New()
+	v.Set("Install.Version", "1.5.3.1")
+	v.Set("Install.DB.Engine", "MySQL")
+	v.Set("Install.DB.Pass", "Sekrit-DB-Pass-1")
+	v.Set("Install.Admin.Pass", "Sekrit-Admin-Pass-2")
+	v.Set("Settings.Secret.Key", "Sekrit-Django-Key-3")
+	c := config.DojoConfig{}
+	if err := v.Unmarshal(&c); err != nil {
+		t.Fatalf("Unable to unmarshal config: %v", err)
+	}
+	InitRedact(&c)
+	defer InitRedact(&config.DojoConfig{})
+
+	var buf bytes.Buffer
+	args := []string{"godojo", "--dry-run", "--config-url-auth", "Bearer Sekrit-Token-4"}
+	err := logHeader(&buf, v, args)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"godojo version: " + version,
+		"Go version:",
+		"Platform:",
+		"Distro:",
+		"Hostname:",
+		"Command line:   godojo --dry-run --config-url-auth =[REDACTED]=",
+		"install.version: 1.5.3.1",
+		"install.db.engine: MySQL",
+		"install.db.pass: =[REDACTED]=",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expecting the header to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Sekrit") {
+		t.Errorf("Expecting no clear-text secrets in the header, got:\n%s", out)
+	}
+	if strings.Contains(out, "settings.") {
+		t.Errorf("Expecting only the Install config in the header, got:\n%s", out)
+	}
+}
+
+func TestHostDistro(t *testing.T) {
+	p := t.TempDir() + "/os-release"
+	writeFile(t, p, "NAME=\"Ubuntu\"\nID=ubuntu\nVERSION_ID=\"18.04\"\n")
+	if got := hostDistro(p); got != "ubuntu:18.04" {
+		t.Errorf("Expecting ubuntu:18.04, got %q", got)
+	}
+	if got := hostDistro(p + ".missing"); got != "unknown" {
+		t.Errorf("Expecting unknown for a missing file, got %q", got)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Handles 'godojo logs' which lists, prints or follows the install logs
+
+// logStamp is the layout of the time in install log names, it sorts in time order as a string
+const logStamp = "2006-01-02_15-04-05.000000000"
+
+// installLogName returns the name of the install log for an install started at t
+func installLogName(t time.Time) string {
+	return "dojo-install_" + t.Format(logStamp) + ".log"
+}
+
+// logTime returns when the install which wrote the log called name started
+// Logs named by installers before the readable timestamp used Unix nanoseconds
+func logTime(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, "dojo-install_") || !strings.HasSuffix(name, ".log") {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, "dojo-install_"), ".log")
+	t, err := time.ParseInLocation(logStamp, stamp, time.Local)
+	if err == nil {
+		return t, true
+	}
+	ns, err := strconv.ParseInt(stamp, 10, 64)
+	if err == nil {
+		return time.Unix(0, ns), true
+	}
+	return time.Time{}, false
+}
+
+// installLogs returns the paths of the install logs in dir, newest first
+func installLogs(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type dated struct {
+		path string
+		when time.Time
+	}
+	logs := []dated{}
+	for _, f := range files {
+		t, ok := logTime(f.Name())
+		if ok && !f.IsDir() {
+			logs = append(logs, dated{path: filepath.Join(dir, f.Name()), when: t})
+		}
+	}
+	sort.SliceStable(logs, func(a, b int) bool { return logs[a].when.After(logs[b].when) })
+	paths := make([]string, len(logs))
+	for n, l := range logs {
+		paths[n] = l.path
+	}
+	return paths, nil
+}
+
+// newestLog returns the path of the most recent install log in dir
+func newestLog(dir string) (string, error) {
+	logs, err := installLogs(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(logs) == 0 {
+		return "", fmt.Errorf("no install logs were found in %s", dir)
+	}
+	return logs[0], nil
+}
+
+// levelFilter passes through log lines of one level, TRACE, INFO, WARNING or ERROR
+// Lines without a level prefix continue the line before them e.g. multi-line command output
+type levelFilter struct {
+	level    string // Upper case level or empty to pass every line
+	matching bool
+}
+
+// keep returns true if line should be written
+func (f *levelFilter) keep(line string) bool {
+	if f.level == "" {
+		return true
+	}
+	for _, l := range []string{"TRACE:", "INFO:", "WARNING:", "ERROR:"} {
+		if strings.HasPrefix(line, l) {
+			f.matching = l == f.level+":"
+			return f.matching
+		}
+	}
+	return f.matching
+}
+
+// copyLog writes the lines of r which f keeps to w, a trailing partial line is returned unwritten
+func copyLog(w io.Writer, r io.Reader, f *levelFilter) (string, error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			return line, nil
+		}
+		if err != nil {
+			return line, err
+		}
+		if f.keep(line) {
+			_, err = io.WriteString(w, line)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// followLog writes the log at path to w then keeps writing what's appended, checking every poll, until ctx is done
+func followLog(ctx context.Context, w io.Writer, path string, f *levelFilter, poll time.Duration) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	partial := ""
+	for {
+		rest, err := copyLog(w, io.MultiReader(strings.NewReader(partial), fh), f)
+		if err != nil {
+			return err
+		}
+		partial = rest
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(poll):
+		}
+	}
+}
+
+// logsCmd implements 'godojo logs'
+func logsCmd(ctx context.Context, args []string) int {
+	fs := pflag.NewFlagSet("logs", pflag.ContinueOnError)
+	dir := fs.String("dir", logLocation, "Directory holding the install logs")
+	last := fs.Bool("last", false, "Print the most recent install log")
+	follow := fs.BoolP("follow", "f", false, "Print the most recent install log and keep printing what's added to it")
+	level := fs.String("grep", "", "Only print lines of this level - trace, info, warning or error")
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	f := &levelFilter{level: strings.ToUpper(*level)}
+	switch f.level {
+	case "", "TRACE", "INFO", "WARNING", "ERROR":
+	default:
+		fmt.Printf("Unknown log level %s, use trace, info, warning or error\n", *level)
+		return exitConfig
+	}
+
+	if !*last && !*follow {
+		logs, err := installLogs(*dir)
+		if err != nil {
+			fmt.Printf("Unable to list the install logs: %+v\n", err)
+			return exitFailure
+		}
+		for _, l := range logs {
+			fmt.Println(l)
+		}
+		return 0
+	}
+
+	path, err := newestLog(*dir)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitFailure
+	}
+	if *follow {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		go func() {
+			<-sigs
+			cancel()
+		}()
+		err = followLog(ctx, os.Stdout, path, f, 500*time.Millisecond)
+	} else {
+		var fh *os.File
+		fh, err = os.Open(path)
+		if err == nil {
+			var rest string
+			rest, err = copyLog(os.Stdout, fh, f)
+			if err == nil && rest != "" && f.keep(rest) {
+				fmt.Println(rest)
+			}
+			fh.Close()
+		}
+	}
+	if err != nil {
+		fmt.Printf("Unable to read %s: %+v\n", path, err)
+		return exitFailure
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewestLog(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2020, 3, 14, 9, 26, 53, 0, time.Local)
+	writeFile(t, filepath.Join(dir, installLogName(base)), "old\n")
+	writeFile(t, filepath.Join(dir, installLogName(base.Add(36*time.Hour))), "newest\n")
+	writeFile(t, filepath.Join(dir, installLogName(base.Add(time.Hour))), "middle\n")
+	// A log named by an older installer and files which aren't install logs
+	writeFile(t, filepath.Join(dir, "dojo-install_1584000000000000000.log"), "legacy\n")
+	writeFile(t, filepath.Join(dir, "cmd-output_2021-01-01_00-00-00.000000000.log"), "commands\n")
+	writeFile(t, filepath.Join(dir, "runtime-install-config.yml"), "Install:\n")
+
+	got, err := newestLog(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, installLogName(base.Add(36*time.Hour))); got != want {
+		t.Errorf("Expecting %s, got %s", want, got)
+	}
+	logs, _ := installLogs(dir)
+	if len(logs) != 4 || !strings.HasSuffix(logs[3], "_1584000000000000000.log") {
+		t.Errorf("Expecting the 4 install logs newest first, got %q", logs)
+	}
+
+	_, err = newestLog(t.TempDir())
+	if err == nil {
+		t.Error("Expecting an error for a directory without logs")
+	}
+}
+
+// sampleLog has each level plus a continuation line of a multi-line warning
+const sampleLog = `godojo version: 1.1.2
+TRACE:   2020/03/14 09:26:53 Logging established
+INFO:    2020/03/14 09:26:54 Downloading release
+WARNING: 2020/03/14 09:26:55 Scheduler configured without a Celery broker
+  check the Celery settings
+ERROR:   2020/03/14 09:26:56 Unable to start the database
+INFO:    2020/03/14 09:26:57 Exiting
+`
+
+func TestCopyLogGrep(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{"", sampleLog},
+		{"ERROR", "ERROR:   2020/03/14 09:26:56 Unable to start the database\n"},
+		{"WARNING", "WARNING: 2020/03/14 09:26:55 Scheduler configured without a Celery broker\n  check the Celery settings\n"},
+		{"INFO", "INFO:    2020/03/14 09:26:54 Downloading release\nINFO:    2020/03/14 09:26:57 Exiting\n"},
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		_, err := copyLog(out, strings.NewReader(sampleLog), &levelFilter{level: tt.level})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("Expecting for level %q:\n%s\ngot:\n%s", tt.level, tt.want, out)
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to write from followLog while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFollowLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), installLogName(time.Now()))
+	writeFile(t, path, "INFO:    first\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() { done <- followLog(ctx, out, path, &levelFilter{}, 10*time.Millisecond) }()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("INFO:    sec")
+	time.Sleep(30 * time.Millisecond)
+	f.WriteString("ond\n")
+	f.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "second\n") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := out.String(); got != "INFO:    first\nINFO:    second\n" {
+		t.Errorf("Expecting both lines once each, got %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// Handles recording everything godojo created during an install so it can be audited or cleanly removed later
+
+// installManifest - record of the artifacts created by an install, never holds secrets
+type installManifest struct {
+	Version   string    `json:"version"`   // Installed DefectDojo version, branch or commit
+	Installer string    `json:"installer"` // Version of godojo that did the install
+	Finished  time.Time `json:"finished"`  // When the manifest was written
+	Paths     []string  `json:"paths"`     // Files and directories created
+	Databases []string  `json:"databases"` // Databases created
+	DBUsers   []string  `json:"db_users"`  // Database users created
+	OSUsers   []string  `json:"os_users"`  // OS users and groups created
+}
+
+// Manifest for the current install, added to as the install progresses
+var manifest installManifest
+
+// addPath records a file or directory created by the install
+func (m *installManifest) addPath(p string) {
+	m.Paths = appendUniq(m.Paths, p)
+}
+
+// addDatabase records a database created by the install
+func (m *installManifest) addDatabase(n string) {
+	m.Databases = appendUniq(m.Databases, n)
+}
+
+// addDBUser records a database user created by the install
+func (m *installManifest) addDBUser(u string) {
+	m.DBUsers = appendUniq(m.DBUsers, u)
+}
+
+// addOSUser records an OS user or group created by the install
+func (m *installManifest) addOSUser(u string) {
+	m.OSUsers = appendUniq(m.OSUsers, u)
+}
+
+// writeManifest writes the manifest as manifest.json in the install root
+func writeManifest(m *installManifest, root string) error {
+	m.Installer = version
+	m.Finished = time.Now()
+
+	// Redact anything that happens to contain a sensitive string e.g. a password used in a path
+	clean := *m
+	clean.Version = Redactatron(m.Version, true)
+	clean.Paths = redactList(m.Paths)
+	clean.Databases = redactList(m.Databases)
+	clean.DBUsers = redactList(m.DBUsers)
+	clean.OSUsers = redactList(m.OSUsers)
+
+	b, err := json.MarshalIndent(clean, "", "  ")
+	if err != nil {
+		return err
+	}
+	mPath := filepath.Join(root, "manifest.json")
+	traceMsg(fmt.Sprintf("Writing install manifest to %+v", mPath))
+	return ioutil.WriteFile(mPath, b, 0600)
+}
+
+// readManifest reads a previously written manifest.json from the install root
+func readManifest(root string) (*installManifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(root, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	m := installManifest{}
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// appendUniq appends s to l if it isn't already present
+func appendUniq(l []string, s string) []string {
+	if contains(l, s) {
+		return l
+	}
+	return append(l, s)
+}
+
+// redactList returns a copy of l with Redactatron applied to each entry
+func redactList(l []string) []string {
+	r := make([]string, 0, len(l))
+	for _, v := range l {
+		r = append(r, Redactatron(v, true))
+	}
+	return r
+}
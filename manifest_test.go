@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestWriteManifest(t *testing.T) {
+	saved := sensStr
+	defer func() { sensStr = saved }()
+
+	root := t.TempDir()
+	c := config.DojoConfig{}
+	c.Install.Root = root
+	c.Install.Source = "django-DefectDojo"
+	c.Install.Version = "1.5.3.1"
+	c.Install.DB.Name = "dojodb"
+	c.Install.DB.User = "dojodbusr"
+	c.Install.DB.Pass = "vee0Thoanae1daePooz0ieka"
+	c.Install.OS.User = "dojo-srv"
+	InitRedact(&c)
+
+	// Fake install run recording what it created
+	m := installManifest{Version: c.Install.Version}
+	m.addPath(filepath.Join(root, c.Install.Source))
+	m.addPath(filepath.Join(root, c.Install.Source))
+	m.addPath(filepath.Join(root, "bin"))
+	m.addDatabase(c.Install.DB.Name)
+	m.addDBUser(c.Install.DB.User)
+	m.addOSUser(c.Install.OS.User)
+	m.addPath("/tmp/" + c.Install.DB.Pass)
+
+	err := writeManifest(&m, root)
+	if err != nil {
+		t.Fatalf("Unexpected error writing manifest: %v", err)
+	}
+	got, err := readManifest(root)
+	if err != nil {
+		t.Fatalf("Unexpected error reading manifest: %v", err)
+	}
+
+	if got.Version != "1.5.3.1" {
+		t.Errorf("Expecting version 1.5.3.1, got %s", got.Version)
+	}
+	if len(got.Paths) != 3 || got.Paths[0] != filepath.Join(root, "django-DefectDojo") || got.Paths[1] != filepath.Join(root, "bin") {
+		t.Errorf("Expecting source dir and venv in the manifest paths, got %v", got.Paths)
+	}
+	if len(got.Databases) != 1 || got.Databases[0] != "dojodb" {
+		t.Errorf("Expecting dojodb in the manifest databases, got %v", got.Databases)
+	}
+	if len(got.DBUsers) != 1 || got.DBUsers[0] != "dojodbusr" {
+		t.Errorf("Expecting dojodbusr in the manifest DB users, got %v", got.DBUsers)
+	}
+	if len(got.OSUsers) != 1 || got.OSUsers[0] != "dojo-srv" {
+		t.Errorf("Expecting dojo-srv in the manifest OS users, got %v", got.OSUsers)
+	}
+
+	raw, _ := ioutil.ReadFile(filepath.Join(root, "manifest.json"))
+	if strings.Contains(string(raw), c.Install.DB.Pass) {
+		t.Errorf("Expecting no clear-text secrets in the manifest, got %s", raw)
+	}
+}
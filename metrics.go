@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Handles the MetricsFile written for node_exporter's textfile collector so a fleet's installs can be graphed
+
+// promLabel escapes v for use as a label value in the Prometheus text format
+var promLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promMetric writes the HELP and TYPE lines of a gauge called name to b
+func promMetric(b *bytes.Buffer, name string, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// promValue formats v as a sample value
+func promValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// renderMetrics returns res, the install's duration d and when it finished in the Prometheus text format,
+// steps which never ran have no duration
+func renderMetrics(res installResult, d time.Duration, finished time.Time) []byte {
+	b := &bytes.Buffer{}
+	success := 0.0
+	if res.Success {
+		success = 1
+	}
+	promMetric(b, "godojo_install_success", "1 if the last install succeeded, 0 if it failed")
+	fmt.Fprintf(b, "godojo_install_success %s\n", promValue(success))
+	promMetric(b, "godojo_install_info", "The DefectDojo version and godojo version of the last install")
+	fmt.Fprintf(b, "godojo_install_info{version=\"%s\",installer=\"%s\"} 1\n", promLabel.Replace(res.Version), promLabel.Replace(res.Installer))
+	promMetric(b, "godojo_install_duration_seconds", "How long the last install ran")
+	fmt.Fprintf(b, "godojo_install_duration_seconds %s\n", promValue(d.Seconds()))
+	promMetric(b, "godojo_install_finished_timestamp_seconds", "When the last install finished as a Unix time")
+	fmt.Fprintf(b, "godojo_install_finished_timestamp_seconds %d\n", finished.Unix())
+	promMetric(b, "godojo_step_duration_seconds", "How long each step of the last install ran")
+	for _, s := range res.Steps {
+		if s.Status == "skipped" {
+			continue
+		}
+		fmt.Fprintf(b, "godojo_step_duration_seconds{step=\"%s\",status=\"%s\"} %s\n", promLabel.Replace(s.Name), s.Status, promValue(s.Duration))
+	}
+	return b.Bytes()
+}
+
+// writeMetrics writes the install's metrics to path, through a temp file renamed into place so the textfile
+// collector never reads half a file. The temp file's name doesn't end in .prom so the collector ignores it
+func writeMetrics(path string, res installResult, d time.Duration, finished time.Time) error {
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, renderMetrics(res, d, finished), 0644)
+	if err != nil {
+		return err
+	}
+	// The node exporter reads the file as another user, so don't let the Umask take its read bit
+	err = os.Chmod(tmp, 0644)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
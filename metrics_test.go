@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// promSample matches a sample line of the Prometheus text format, capturing its name, labels and value
+var promSample = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*",?)*\})? (\S+)$`)
+
+// parseProm checks every line of b is a HELP, TYPE or sample line, with each sample's metric declared by
+// HELP and TYPE before it, returning the samples as name{labels} to value
+func parseProm(t *testing.T, b []byte) map[string]float64 {
+	samples := map[string]float64{}
+	typed := map[string]bool{}
+	for _, l := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		if f := strings.Fields(l); len(f) >= 4 && f[0] == "#" && f[1] == "TYPE" {
+			typed[f[2]] = true
+			continue
+		}
+		if strings.HasPrefix(l, "# HELP ") {
+			continue
+		}
+		m := promSample.FindStringSubmatch(l)
+		if m == nil {
+			t.Fatalf("Expecting a valid sample line, got %q in\n%s", l, b)
+		}
+		if !typed[m[1]] {
+			t.Errorf("Expecting a TYPE line before the samples of %s", m[1])
+		}
+		v, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			t.Errorf("Expecting a number as the value of %s, got %q", m[1], m[3])
+		}
+		samples[m[1]+m[2]] = v
+	}
+	return samples
+}
+
+// metricsRun runs three fake steps, the second failing with failWith if it's not nil, and returns the metrics written
+func metricsRun(t *testing.T, failWith error) map[string]float64 {
+	steps := []installStep{
+		{name: "download", run: func(ctx context.Context) error { return nil }},
+		{name: "prep-db", run: func(ctx context.Context) error { return failWith }},
+		{name: "django", run: func(ctx context.Context) error { return nil }},
+	}
+	rec := &resultReporter{ProgressReporter: &recordingReporter{}}
+	err := runSteps(context.Background(), rec, steps)
+
+	p := filepath.Join(t.TempDir(), "godojo.prom")
+	res := newResult(steps, rec, &installManifest{}, "1.5.3.1", err)
+	if werr := writeMetrics(p, res, 90*time.Second, time.Unix(1700000000, 0)); werr != nil {
+		t.Fatalf("Unable to write the metrics: %v", werr)
+	}
+	if _, serr := os.Stat(p + ".tmp"); serr == nil {
+		t.Error("Expecting the temp file to be renamed into place")
+	}
+	b, _ := ioutil.ReadFile(p)
+	return parseProm(t, b)
+}
+
+func TestMetricsSuccess(t *testing.T) {
+	got := metricsRun(t, nil)
+	want := map[string]float64{
+		"godojo_install_success":                                             1,
+		"godojo_install_duration_seconds":                                    90,
+		"godojo_install_finished_timestamp_seconds":                          1700000000,
+		`godojo_install_info{version="1.5.3.1",installer="` + version + `"}`: 1,
+	}
+	for k, v := range want {
+		if g, ok := got[k]; !ok || g != v {
+			t.Errorf("Expecting %s %v, got %v", k, v, got)
+		}
+	}
+	for _, s := range []string{"download", "prep-db", "django"} {
+		if _, ok := got[`godojo_step_duration_seconds{step="`+s+`",status="ok"}`]; !ok {
+			t.Errorf("Expecting a duration for the %s step, got %v", s, got)
+		}
+	}
+}
+
+func TestMetricsFailure(t *testing.T) {
+	got := metricsRun(t, errors.New("Unable to connect to the database"))
+	if got["godojo_install_success"] != 0 {
+		t.Errorf("Expecting godojo_install_success 0, got %v", got)
+	}
+	if _, ok := got[`godojo_step_duration_seconds{step="prep-db",status="failed"}`]; !ok {
+		t.Errorf("Expecting the failed step's duration, got %v", got)
+	}
+	for k := range got {
+		if strings.Contains(k, `step="django"`) {
+			t.Errorf("Expecting no duration for a step that never ran, got %s", k)
+		}
+	}
+}
+
+func TestMetricsEscapesLabels(t *testing.T) {
+	res := installResult{Version: `feature/"quoted"\branch`, Installer: version}
+	got := parseProm(t, renderMetrics(res, time.Second, time.Unix(0, 0)))
+	k := `godojo_install_info{version="feature/\"quoted\"\\branch",installer="` + version + `"}`
+	if _, ok := got[k]; !ok {
+		t.Errorf("Expecting the version label escaped as %s, got %v", k, got)
+	}
+}
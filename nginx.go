@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles writing the nginx site config which fronts DefectDojo
+
+// nginxSites is where the DefectDojo site config is written if nginx is installed
+var nginxSites = "/etc/nginx/sites-available"
+
+// nginxEnabled is where the site config is linked for nginx to serve it
+var nginxEnabled = "/etc/nginx/sites-enabled"
+
+// nginxTmpl is the nginx site config for DefectDojo, redirecting port 80 to HTTPS when TLS is configured
+var nginxTmpl = template.Must(template.New("nginx").Parse(`# DefectDojo site config written by godojo
+upstream defectdojo {
+    server {{ .Upstream }};
+}
+{{ if .TLS }}
+server {
+    listen 80;
+    server_name {{ .Names }};
+    return 301 https://$host{{ if ne .Port 443 }}:{{ .Port }}{{ end }}$request_uri;
+}
+
+server {
+    listen {{ .Port }} ssl;
+    server_name {{ .Names }};
+    ssl_certificate {{ .Cert }};
+    ssl_certificate_key {{ .Key }};
+{{ else }}
+server {
+    listen {{ .Port }};
+    server_name {{ .Names }};
+{{ end }}
+    location /static/ {
+        alias {{ .Static }}/;
+    }
+
+    location / {
+        proxy_pass http://defectdojo;
+        proxy_set_header Host $host;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto $scheme;
+    }
+}
+`))
+
+// renderNginx writes the nginx site config for the install to w
+func renderNginx(w io.Writer, i *config.InstallConfig) error {
+	cert, key := tlsPaths(i)
+	return nginxTmpl.Execute(w, struct {
+		TLS      bool
+		Port     int
+		Host     string
+		Names    string
+		Cert     string
+		Key      string
+		Static   string
+		Upstream string
+	}{
+		Upstream: appUpstream(i),
+		TLS:      i.TLS.SelfSigned,
+		Port:     listenPort(i),
+		Host:     tlsHostname(i),
+		Names:    strings.Join(allowedHosts(i), " "),
+		Cert:     cert,
+		Key:      key,
+		Static:   filepath.Join(i.Root, i.Source, "static"),
+	})
+}
+
+// setupNginx writes the nginx site config when nginx is installed, links it into nginxEnabled then checks
+// it with nginx -t before reloading nginx so the site is served
+func setupNginx(ctx context.Context, i *config.InstallConfig) error {
+	_, err := os.Stat(nginxSites)
+	if err != nil {
+		statusMsg("nginx.missing")
+		return nil
+	}
+	p := filepath.Join(nginxSites, "defectdojo")
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	err = renderNginx(f, i)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	manifest.addPath(p)
+	statusMsg("nginx.wrote", p)
+
+	link := filepath.Join(nginxEnabled, "defectdojo")
+	err = enableSite(p, link)
+	if err != nil {
+		return err
+	}
+	manifest.addPath(link)
+	err = runCmd(ctx, "nginx", "-t")
+	if err != nil {
+		return fmt.Errorf("The nginx config for DefectDojo failed nginx -t: %w", err)
+	}
+	if ContainerMode {
+		statusMsg("nginx.container")
+		return nil
+	}
+	return runCmd(ctx, "systemctl", "reload-or-restart", "nginx")
+}
+
+// enableSite links the site config at p into sites-enabled as link, replacing one left by an earlier install
+func enableSite(p string, link string) error {
+	err := os.MkdirAll(filepath.Dir(link), 0755)
+	if err != nil {
+		return fmt.Errorf("Unable to create %s: %w", filepath.Dir(link), err)
+	}
+	err = os.Remove(link)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to replace %s: %w", link, err)
+	}
+	err = os.Symlink(p, link)
+	if err != nil {
+		return fmt.Errorf("Unable to enable the nginx site %s: %w", link, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// fakeNginx points nginxSites and nginxEnabled at temp dirs for the test
+func fakeNginx(t *testing.T) (string, string) {
+	savedSites, savedEnabled := nginxSites, nginxEnabled
+	t.Cleanup(func() { nginxSites, nginxEnabled = savedSites, savedEnabled })
+	dir := t.TempDir()
+	nginxSites = filepath.Join(dir, "sites-available")
+	nginxEnabled = filepath.Join(dir, "sites-enabled")
+	if err := os.MkdirAll(nginxSites, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return nginxSites, nginxEnabled
+}
+
+func TestSetupNginxEnablesSite(t *testing.T) {
+	sites, enabled := fakeNginx(t)
+	ran := recordCmds(t)
+	// A link left by an earlier install is replaced
+	if err := os.MkdirAll(enabled, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/nowhere", filepath.Join(enabled, "defectdojo")); err != nil {
+		t.Fatal(err)
+	}
+
+	i := &config.InstallConfig{Root: "/opt/dojo", Source: "django-DefectDojo"}
+	if err := setupNginx(context.Background(), i); err != nil {
+		t.Fatal(err)
+	}
+	target, err := os.Readlink(filepath.Join(enabled, "defectdojo"))
+	if err != nil || target != filepath.Join(sites, "defectdojo") {
+		t.Errorf("Expecting the site linked into sites-enabled, got %q, %v", target, err)
+	}
+	if strings.Join(*ran, ",") != "nginx -t,systemctl reload-or-restart nginx" {
+		t.Errorf("Expecting nginx checked then reloaded, ran %v", *ran)
+	}
+}
+
+func TestSetupNginxMissing(t *testing.T) {
+	_, enabled := fakeNginx(t)
+	nginxSites = filepath.Join(t.TempDir(), "missing")
+	ran := recordCmds(t)
+	if err := setupNginx(context.Background(), &config.InstallConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(enabled, "defectdojo")); !os.IsNotExist(err) || len(*ran) != 0 {
+		t.Errorf("Expecting nothing done without nginx, got %v and ran %v", err, *ran)
+	}
+}
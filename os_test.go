@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestOSPackages(t *testing.T) {
+	saved := builtinOSPackages
+	defer func() { builtinOSPackages = saved }()
+	builtinOSPackages = config.PackageLists{
+		"ubuntu":  {"gcc", "libssl-dev", "expect"},
+		"default": {"gcc"},
+	}
+
+	tests := []struct {
+		name       string
+		distro     string
+		configured config.PackageLists
+		want       []string
+	}{
+		{"built-in for the distro", "ubuntu", nil, []string{"gcc", "libssl-dev", "expect"}},
+		{"built-in default fallback", "debian", nil, []string{"gcc"}},
+		{"configured added to built-in", "ubuntu", config.PackageLists{"ubuntu": {"libxml2-dev", "gcc"}},
+			[]string{"gcc", "libssl-dev", "expect", "libxml2-dev"}},
+		{"configured default for an unlisted distro", "debian", config.PackageLists{"ubuntu": {"libxml2-dev"}, "default": {"git"}},
+			[]string{"gcc", "git"}},
+		{"configured distro over configured default", "ubuntu", config.PackageLists{"ubuntu": {"libxml2-dev"}, "default": {"git"}},
+			[]string{"gcc", "libssl-dev", "expect", "libxml2-dev"}},
+		{"leading - drops a built-in", "ubuntu", config.PackageLists{"ubuntu": {"-expect"}}, []string{"gcc", "libssl-dev"}},
+	}
+	for _, tt := range tests {
+		got := osPackages(&config.InstallConfig{OSPackages: tt.configured}, tt.distro)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expecting %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestInitOSInstPackages(t *testing.T) {
+	b := osCmds{}
+	initOSInst("ubuntu:18.04", []string{"gcc", "libxml2-dev"}, &b)
+	want := "DEBIAN_FRONTEND=noninteractive apt-get install -y gcc libxml2-dev"
+	if len(b.cmds) == 0 || b.cmds[len(b.cmds)-1] != want {
+		t.Errorf("Expecting the package step to run %q, got %v", want, b.cmds)
+	}
+}
+
+func TestCreateSettingsPyEnvError(t *testing.T) {
+	c := config.DojoConfig{}
+	c.Install.Root = t.TempDir()
+	c.Install.DB.Engine = "SQLite"
+	cmds := osCmds{}
+	// The settings directory is missing so .env.prod can't be written
+	err := createSettingsPy("ubuntu:18.04", &c, &cmds)
+	var fErr *dojoerr.FileError
+	if !errors.As(err, &fErr) {
+		t.Fatalf("Expecting the .env.prod *dojoerr.FileError, got %v", err)
+	}
+	if len(cmds.cmds) != 0 {
+		t.Errorf("Expecting no settings.py commands after the error, got %v", cmds.cmds)
+	}
+}
+
+func TestCreateSettingsPySource(t *testing.T) {
+	c := config.DojoConfig{}
+	c.Install.Root = t.TempDir()
+	c.Install.Source = "dojo-src"
+	c.Install.DB.Engine = "SQLite"
+	c.Install.OS.User = "dojo"
+	c.Install.OS.Group = "dojo"
+	settings := filepath.Join(c.Install.Root, "dojo-src", "dojo", "settings")
+	if err := os.MkdirAll(settings, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmds := osCmds{}
+	if err := createSettingsPy("ubuntu:18.04", &c, &cmds); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settings, ".env.prod")); err != nil {
+		t.Errorf("Expecting .env.prod in the configured Source, got %v", err)
+	}
+	want := []string{
+		"cp " + settings + "/settings.dist.py " + settings + "/settings.py",
+		"chown dojo.dojo " + settings + "/settings.py",
+	}
+	if !reflect.DeepEqual(cmds.cmds, want) {
+		t.Errorf("Expecting %v, got %v", want, cmds.cmds)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles checking the ports the app server and nginx will listen on are free before installing
+
+// PortFree returns true if nothing is listening on port at host, found by briefly listening on it itself
+// An empty host checks every address like a server listening on :port would
+func PortFree(host string, port int) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// listenAddr - a host and port a service of the install listens on
+type listenAddr struct {
+	service string
+	host    string
+	port    int
+}
+
+// installPorts returns what the app server and nginx listen on, a unix socket app server has no port
+func installPorts(i *config.InstallConfig) []listenAddr {
+	addrs := []listenAddr{}
+	b := appBind(i)
+	if !strings.HasPrefix(b, "/") {
+		host, p, err := net.SplitHostPort(b)
+		port, perr := strconv.Atoi(p)
+		if err == nil && perr == nil {
+			addrs = append(addrs, listenAddr{service: appServerType(i), host: host, port: port})
+		}
+	}
+	addrs = append(addrs, listenAddr{service: "nginx", port: listenPort(i)})
+	if i.TLS.SelfSigned && listenPort(i) != 80 {
+		// Plain HTTP is redirected to HTTPS
+		addrs = append(addrs, listenAddr{service: "nginx", port: 80})
+	}
+	return addrs
+}
+
+// checkPorts returns an error naming each port in addrs already in use, ports below 1024 can only be
+// checked as root so they're reported as unchecked otherwise
+func checkPorts(addrs []listenAddr) error {
+	root := os.Geteuid() == 0
+	problems := []string{}
+	for _, a := range addrs {
+		hp := net.JoinHostPort(a.host, strconv.Itoa(a.port))
+		switch {
+		case a.port < 1024 && !root:
+			problems = append(problems, fmt.Sprintf("port %d for %s can only be checked as root", a.port, a.service))
+		case !PortFree(a.host, a.port):
+			problems = append(problems, fmt.Sprintf("%s for %s is already in use", hp, a.service))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, ", "))
+}
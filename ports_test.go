@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPortFree(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	if PortFree("127.0.0.1", port) {
+		t.Errorf("Expecting port %d to be in use while listening on it", port)
+	}
+	err = checkPorts([]listenAddr{{service: "uwsgi", host: "127.0.0.1", port: port}})
+	if err == nil || !strings.Contains(err.Error(), "127.0.0.1:"+strconv.Itoa(port)+" for uwsgi is already in use") {
+		t.Errorf("Expecting the occupied port named in the error, got %v", err)
+	}
+	l.Close()
+	if !PortFree("127.0.0.1", port) {
+		t.Errorf("Expecting port %d to be free once closed", port)
+	}
+}
+
+func TestInstallPorts(t *testing.T) {
+	i := scheduleInstall()
+	got := installPorts(i)
+	if len(got) != 2 || got[0] != (listenAddr{service: "uwsgi", host: "127.0.0.1", port: 8000}) || got[1].port != 80 {
+		t.Errorf("Expecting the app server on 8000 and nginx on 80, got %+v", got)
+	}
+
+	i.AppServer.Bind = "/run/dojo/app.sock"
+	i.TLS.SelfSigned = true
+	got = installPorts(i)
+	if len(got) != 2 || got[0].port != 443 || got[1].port != 80 {
+		t.Errorf("Expecting only nginx on 443 and 80 with a socket app server, got %+v", got)
+	}
+}
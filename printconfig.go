@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Handles printing the effective configuration from the config file + ENV variables + flags
+
+// sensitiveKeys are the config keys whose values are always redacted when the config is shown or written
+var sensitiveKeys = []string{
+	"install.db.rpass",
+	"install.db.pass",
+	"install.os.pass",
+	"install.admin.pass",
+	"install.githubtoken",
+	"install.configpassphrase",
+	"install.sshkey",
+	"install.sshkeypass",
+	"settings.celery.broker.password",
+	"settings.database.password",
+	"settings.secret.key",
+	"settings.credential.aes.b256.key",
+	"settings.social.auth.google.oauth2.key",
+	"settings.social.auth.google.oauth2.secret",
+	"settings.social.auth.okta.oauth2.key",
+	"settings.social.auth.okta.oauth2.secret",
+	"settings.email.url",
+}
+
+// printConfigCmd implements 'godojo config' which prints the fully-resolved config as YAML
+func printConfigCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	fs.Bool("show-sources", false, "Show where each value came from - file, url, env, flag or default")
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+
+	c := config.DojoConfig{}
+	v := viper.GetViper()
+	err = loadConfig(v, fs, &c)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	InitRedact(&c)
+
+	show, _ := fs.GetBool("show-sources")
+	if show {
+		err = printSources(os.Stdout, v, fs)
+	} else {
+		err = printConfig(os.Stdout, v)
+	}
+	if err != nil {
+		fmt.Printf("Unable to print the config: %+v\n", err)
+		return exitFailure
+	}
+	return 0
+}
+
+// printConfig writes the effective config held by v as YAML with secrets redacted
+func printConfig(w io.Writer, v *viper.Viper) error {
+	out, err := yaml.Marshal(redactSettings(v.AllSettings(), ""))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, string(out))
+	return err
+}
+
+// printSources writes each config key, its redacted value, and where the value came from
+func printSources(w io.Writer, v *viper.Viper, fs *pflag.FlagSet) error {
+	// A viper reading only the config file tells which keys were set there
+	fileV := viper.New()
+	if v.ConfigFileUsed() != "" {
+		fileV.SetConfigFile(v.ConfigFileUsed())
+		err := fileV.ReadInConfig()
+		if err != nil {
+			return err
+		}
+	}
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		val := redactValue(k, v.Get(k))
+		_, err := fmt.Fprintf(w, "%s: %s (%s)\n", k, val, valueSource(fileV, fs, k))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valueSource reports if key was set by a flag, an ENV variable, a config URL, the config file, or is a default
+func valueSource(fileV *viper.Viper, fs *pflag.FlagSet, key string) string {
+	for name, k := range flagKeys {
+		if strings.EqualFold(k, key) {
+			if f := fs.Lookup(name); f != nil && f.Changed {
+				return "flag"
+			}
+		}
+	}
+	for k, env := range envAliases {
+		if _, ok := os.LookupEnv(env); ok && strings.EqualFold(k, key) {
+			return "env"
+		}
+	}
+	if _, ok := os.LookupEnv(envName(key)); ok {
+		return "env"
+	}
+	if urlConfig != nil && urlConfig.IsSet(key) {
+		return "url"
+	}
+	if fileV.IsSet(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// redactSettings returns a copy of the nested settings map from viper with sensitive keys redacted
+func redactSettings(s map[string]interface{}, prefix string) map[string]interface{} {
+	clean := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		key := strings.ToLower(prefix + k)
+		switch val := v.(type) {
+		case map[string]interface{}:
+			clean[k] = redactSettings(val, key+".")
+		default:
+			if isSensitive(key) {
+				clean[k] = redactValue(key, val)
+				continue
+			}
+			clean[k] = val
+		}
+	}
+	return clean
+}
+
+// redactValue returns the value of key as a string, passed through Redactatron for sensitive keys
+// so secrets never show up even if they weren't registered with InitRedact
+func redactValue(key string, val interface{}) string {
+	str := fmt.Sprintf("%v", val)
+	if !isSensitive(key) || str == "" {
+		return str
+	}
+	clean := Redactatron(str, true)
+	if clean == str {
+		clean = "=[REDACTED]="
+	}
+	return clean
+}
+
+// isSensitive returns true if key holds a secret
+func isSensitive(key string) bool {
+	return contains(sensitiveKeys, strings.ToLower(key))
+}
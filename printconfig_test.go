@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+const sampleConfig = `
+Install:
+  Version: "1.5.3.1"
+  Root: "/opt/dojo"
+  DB:
+    Name: "dojodb"
+    User: "dojodbusr"
+    Pass: "vee0Thoanae1daePooz0ieka"
+  Admin:
+    User: "admin"
+    Pass: "Ohseek4aiveeM3ai"
+Settings:
+  Secret:
+    Key: "uu6ahHei3ohquoh1"
+`
+
+// inConfigDir runs f with the current directory set to a temp dir holding a dojoConfig file
+func inConfigDir(t *testing.T, name string, body string, f func()) {
+	t.Helper()
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0600)
+	if err != nil {
+		t.Fatalf("Unable to write test config: %v", err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	f()
+}
+
+func TestPrintConfigRedacts(t *testing.T) {
+	saved := sensStr
+	defer func() { sensStr = saved }()
+
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		v := viper.New()
+		fs := installFlags()
+		c := config.DojoConfig{}
+		if err := loadConfig(v, fs, &c); err != nil {
+			t.Fatalf("Unexpected error loading config: %v", err)
+		}
+		InitRedact(&c)
+
+		var out bytes.Buffer
+		if err := printConfig(&out, v); err != nil {
+			t.Fatalf("Unexpected error printing config: %v", err)
+		}
+		for _, secret := range []string{"vee0Thoanae1daePooz0ieka", "Ohseek4aiveeM3ai", "uu6ahHei3ohquoh1"} {
+			if strings.Contains(out.String(), secret) {
+				t.Errorf("Expecting secret %s to be redacted, got:\n%s", secret, out.String())
+			}
+		}
+		for _, shown := range []string{"1.5.3.1", "/opt/dojo", "dojodbusr"} {
+			if !strings.Contains(out.String(), shown) {
+				t.Errorf("Expecting non-secret value %s to be shown, got:\n%s", shown, out.String())
+			}
+		}
+
+		out.Reset()
+		os.Setenv("DD_INSTALL_ROOT", "/srv/dojo")
+		defer os.Unsetenv("DD_INSTALL_ROOT")
+		if err := printSources(&out, v, fs); err != nil {
+			t.Fatalf("Unexpected error printing sources: %v", err)
+		}
+		for _, want := range []string{"install.root: /srv/dojo (env)", "install.version: 1.5.3.1 (file)", "install.db.pass: =[REDACTED]= (file)"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("Expecting '%s' in the sources, got:\n%s", want, out.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,9 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+// processAlive can't check for a process on this platform so assumes any pid is running
+func processAlive(pid int) bool {
+	return pid > 0
+}
@@ -0,0 +1,16 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import "syscall"
+
+// processAlive returns true if a process with pid is running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	// Signal 0 checks the process exists without signaling it, EPERM means it exists but isn't ours
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
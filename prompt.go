@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+	"golang.org/x/crypto/ssh/terminal"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Handles prompting for the required config values when run interactively
+
+// prompter - reads answers from in and writes questions to out
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	// readPass reads a line without echoing it
+	readPass func() (string, error)
+}
+
+// newTermPrompter returns a prompter on stdin and stdout which reads passwords without echo
+func newTermPrompter() *prompter {
+	return &prompter{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stdout,
+		readPass: func() (string, error) {
+			b, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+			fmt.Println("")
+			return string(b), err
+		},
+	}
+}
+
+var (
+	// stdPrompter returns the prompter on the terminal - a var so tests can script the answers
+	stdPrompter = sharedTermPrompter
+	// stdinTerminal reports if stdin is a terminal - a var so tests can pretend either way
+	stdinTerminal = stdinIsTerminal
+	// termPrompter is shared by every prompt so input buffered by one isn't lost to the next
+	termPrompter *prompter
+)
+
+// sharedTermPrompter returns the terminal prompter, creating it on first use
+func sharedTermPrompter() *prompter {
+	if termPrompter == nil {
+		termPrompter = newTermPrompter()
+	}
+	return termPrompter
+}
+
+// confirm asks prompt as a yes or no question on the terminal defaulting to no
+// With AssumeYes it answers yes without asking and without a terminal it answers no so unattended runs never block
+func confirm(prompt string) bool {
+	if !AssumeYes && !stdinTerminal() {
+		traceMsg(fmt.Sprintf("Not a terminal so answering no to: %s, use --yes to answer yes", prompt))
+		return false
+	}
+	return stdPrompter().confirm(prompt, false)
+}
+
+// confirm asks label as a yes or no question, answering yes without asking if AssumeYes is set
+// An unreadable answer is taken as no
+func (p *prompter) confirm(label string, def bool) bool {
+	if AssumeYes {
+		fmt.Fprintf(p.out, "%s (y/n): y (--yes)\n", label)
+		return true
+	}
+	yes, err := p.askYesNo(label, def)
+	if err != nil {
+		fmt.Fprintf(p.out, "  %+v, taking that as no\n", err)
+		return false
+	}
+	return yes
+}
+
+// stdinIsTerminal returns true if stdin is a TTY so prompting won't block a script
+func stdinIsTerminal() bool {
+	return terminal.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ask prompts for label showing def in brackets, re-asking until valid accepts the answer
+func (p *prompter) ask(label string, def string, valid func(string) error) (string, error) {
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		line, err := p.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("Unable to read answer for %s: %w", label, err)
+		}
+		ans := strings.TrimSpace(line)
+		if ans == "" {
+			ans = def
+		}
+		err = valid(ans)
+		if err == nil {
+			return ans, nil
+		}
+		fmt.Fprintf(p.out, "  %+v, please try again\n", err)
+	}
+}
+
+// askPass prompts for a password without echo, an empty answer keeps def if it's set
+func (p *prompter) askPass(label string, def string) (string, error) {
+	for {
+		hint := "none set"
+		if def != "" {
+			hint = "Enter to keep current"
+		}
+		fmt.Fprintf(p.out, "%s [%s]: ", label, hint)
+		ans, err := p.readPass()
+		if err != nil {
+			return "", fmt.Errorf("Unable to read answer for %s: %w", label, err)
+		}
+		ans = strings.TrimSpace(ans)
+		if ans == "" {
+			ans = def
+		}
+		if ans != "" {
+			return ans, nil
+		}
+		fmt.Fprintln(p.out, "  A password is required, please try again")
+	}
+}
+
+// askYesNo prompts for a yes or no answer
+func (p *prompter) askYesNo(label string, def bool) (bool, error) {
+	d := "n"
+	if def {
+		d = "y"
+	}
+	ans, err := p.ask(label+" (y/n)", d, func(s string) error {
+		switch strings.ToLower(s) {
+		case "y", "yes", "n", "no":
+			return nil
+		}
+		return errors.New("Answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(strings.ToLower(ans), "y"), nil
+}
+
+// validVersion matches release versions like 1.5.3.1
+var validVersion = regexp.MustCompile(`^[0-9]+(\.[0-9]+)+$`)
+
+// dbEngines are the database engines godojo can install
+var dbEngines = []string{"SQLite", "MySQL", "PostgreSQL", "MariaDB"}
+
+// promptConfig asks for the values needed for an install using what's already in c as the defaults
+func promptConfig(p *prompter, c *config.DojoConfig) error {
+	var err error
+	i := &c.Install
+	i.Version, err = p.ask("DefectDojo release version", orDefault(i.Version, "1.5.3.1"), func(s string) error {
+		if !validVersion.MatchString(s) {
+			return fmt.Errorf("%s isn't a release version like 1.5.3.1", s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	i.Root, err = p.ask("Install root directory", orDefault(i.Root, "/opt/dojo"), func(s string) error {
+		if !filepath.IsAbs(s) || (len(s) > 1 && strings.HasSuffix(s, "/")) {
+			return fmt.Errorf("%s must be an absolute path without a trailing /", s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	i.DB.Engine, err = p.ask("Database engine - "+strings.Join(dbEngines, ", "), orDefault(i.DB.Engine, "MySQL"), func(s string) error {
+		if !contains(dbEngines, s) {
+			return fmt.Errorf("%s isn't a supported database engine", s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	i.DB.Name, err = p.ask("Database name", orDefault(i.DB.Name, "dojodb"), notEmpty)
+	if err != nil {
+		return err
+	}
+	i.DB.User, err = p.ask("Database user", orDefault(i.DB.User, "dojodbusr"), notEmpty)
+	if err != nil {
+		return err
+	}
+	i.DB.Pass, err = p.askPass("Database password", i.DB.Pass)
+	return err
+}
+
+// savePrompted writes the values collected by promptConfig to path as a YAML config file
+func savePrompted(path string, c *config.DojoConfig) error {
+	i := c.Install
+	out, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "Install", Value: yaml.MapSlice{
+			{Key: "Version", Value: i.Version},
+			{Key: "Root", Value: i.Root},
+			{Key: "DB", Value: yaml.MapSlice{
+				{Key: "Engine", Value: i.DB.Engine},
+				{Key: "Name", Value: i.DB.Name},
+				{Key: "User", Value: i.DB.User},
+				{Key: "Pass", Value: i.DB.Pass},
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	// Holds a password so only readable by its owner
+	return ioutil.WriteFile(path, out, 0600)
+}
+
+// interactiveConfig prompts for the required values and, if there was no config file, offers to save them
+func interactiveConfig(p *prompter, c *config.DojoConfig, haveFile bool) error {
+	fmt.Fprintln(p.out, "")
+	fmt.Fprintln(p.out, "Answer the questions below to configure the install, press Enter to accept the [default]")
+	err := promptConfig(p, c)
+	if err != nil {
+		return err
+	}
+	if haveFile {
+		return nil
+	}
+	if !p.confirm("Save these answers as dojoConfig.yml", true) {
+		return nil
+	}
+	err = savePrompted("dojoConfig.yml", c)
+	if err != nil {
+		return fmt.Errorf("Unable to save dojoConfig.yml: %w", err)
+	}
+	fmt.Fprintln(p.out, "Saved answers to dojoConfig.yml")
+	return nil
+}
+
+// notEmpty requires an answer
+func notEmpty(s string) error {
+	if s == "" {
+		return errors.New("A value is required")
+	}
+	return nil
+}
+
+// orDefault returns s or def if s is empty
+func orDefault(s string, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+// scriptedPrompter returns a prompter answering from answers and passwords in order
+func scriptedPrompter(answers string, passwords ...string) (*prompter, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &prompter{
+		in:  bufio.NewReader(strings.NewReader(answers)),
+		out: out,
+		readPass: func() (string, error) {
+			p := passwords[0]
+			passwords = passwords[1:]
+			return p, nil
+		},
+	}, out
+}
+
+func TestPromptConfig(t *testing.T) {
+	// Bad version then a good one, default root, bad engine then PostgreSQL, default name, a user
+	p, out := scriptedPrompter("1.x\n1.6.0\n\nOracle\nPostgreSQL\n\ndojo\n", "", "s3cret")
+	c := config.DojoConfig{}
+	err := promptConfig(p, &c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	i := c.Install
+	if i.Version != "1.6.0" || i.Root != "/opt/dojo" || i.DB.Engine != "PostgreSQL" ||
+		i.DB.Name != "dojodb" || i.DB.User != "dojo" || i.DB.Pass != "s3cret" {
+		t.Errorf("Unexpected config collected: %+v", i)
+	}
+	if !strings.Contains(out.String(), "[/opt/dojo]") {
+		t.Errorf("Expecting defaults to be shown in brackets, got:\n%s", out)
+	}
+	if strings.Count(out.String(), "please try again") != 3 {
+		t.Errorf("Expecting the bad version, engine and empty password to be re-asked, got:\n%s", out)
+	}
+}
+
+func TestPromptConfigKeepsExisting(t *testing.T) {
+	p, _ := scriptedPrompter("\n\n\n\n\n", "")
+	c := config.DojoConfig{}
+	c.Install.Version = "1.5.3.1"
+	c.Install.Root = "/srv/dojo"
+	c.Install.DB.Pass = "existing"
+	err := promptConfig(p, &c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Install.Root != "/srv/dojo" || c.Install.DB.Pass != "existing" {
+		t.Errorf("Expecting existing values to be the defaults, got %+v", c.Install)
+	}
+}
+
+func TestInteractiveConfigSaves(t *testing.T) {
+	p, _ := scriptedPrompter("\n\n\n\n\ny\n", "s3cret")
+	inConfigDir(t, "unused", "", func() {
+		c := config.DojoConfig{}
+		err := interactiveConfig(p, &c, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(".", "dojoConfig.yml"))
+		if err != nil {
+			t.Fatalf("Expecting dojoConfig.yml to be saved: %v", err)
+		}
+		// The saved file must load back to the same answers
+		saved := config.DojoConfig{}
+		err = loadConfig(viper.New(), installFlags(), &saved)
+		if err != nil {
+			t.Fatalf("Unable to load saved config: %v\n%s", err, b)
+		}
+		if saved.Install.DB.Pass != "s3cret" || saved.Install.Root != "/opt/dojo" || saved.Install.DB.Engine != "MySQL" {
+			t.Errorf("Unexpected saved config:\n%s", b)
+		}
+	})
+}
+
+// fakeTerminal makes confirm ask p and see stdin as a terminal if tty is true
+func fakeTerminal(t *testing.T, p *prompter, tty bool) {
+	savedP, savedT, savedYes := stdPrompter, stdinTerminal, AssumeYes
+	t.Cleanup(func() { stdPrompter, stdinTerminal, AssumeYes = savedP, savedT, savedYes })
+	stdPrompter = func() *prompter { return p }
+	stdinTerminal = func() bool { return tty }
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name      string
+		answers   string
+		tty       bool
+		assumeYes bool
+		want      bool
+	}{
+		{"assume yes without a terminal", "", false, true, true},
+		{"assume yes ignores the answer", "n\n", true, true, true},
+		{"interactive yes", "yes\n", true, false, true},
+		{"interactive no", "n\n", true, false, false},
+		{"interactive default is no", "\n", true, false, false},
+		{"interactive closed stdin", "", true, false, false},
+		{"no terminal denies", "y\n", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := scriptedPrompter(tt.answers)
+			fakeTerminal(t, p, tt.tty)
+			AssumeYes = tt.assumeYes
+			if got := confirm("Remove /opt/dojo"); got != tt.want {
+				t.Errorf("Expecting %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAssumeYesFlag(t *testing.T) {
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		fs := installFlags()
+		fs.Parse([]string{"-y"})
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), fs, &c)
+		if err != nil {
+			t.Fatalf("Unable to load config: %v", err)
+		}
+		if !c.Install.AssumeYes {
+			t.Errorf("Expecting -y to set AssumeYes")
+		}
+	})
+}
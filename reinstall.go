@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles 'godojo reinstall' which removes an install recorded in its manifest then installs DefectDojo again
+// e.g. to reset a test install to a known state
+
+// installer runs a full install with the command-line flags in args, tests replace it to check what reinstall runs
+var installer = install
+
+// dropEngines are the database engines reinstall can start over with, MySQL's prep drops the database with
+// DB.Drop and SQLite's database file is in the source removeInstall deletes - the MariaDB and PostgreSQL prep
+// don't create or drop the database so DB.Drop would be ignored there
+var dropEngines = map[string]bool{"MySQL": true, "SQLite": true}
+
+// reinstallCmd implements 'godojo reinstall [flags]'
+func reinstallCmd(ctx context.Context, args []string) int {
+	return runReinstall(ctx, args)
+}
+
+// runReinstall confirms the reinstall, removes the install in Root and runs the install with args, returning its exit code
+// The database is dropped and recreated by the install with DB.Drop and the source is replaced with ExistingSource overwrite
+func runReinstall(ctx context.Context, args []string) int {
+	fs := installFlags()
+	fs.Bool("reset-admin", false, "Set a new random admin password, shown once the install starts, instead of the configured one")
+	fs.Usage = func() {
+		fmt.Println("Usage: godojo reinstall [flags]")
+		fmt.Println("Removes the DefectDojo install in Root, drops its database and installs it again - asks first unless --yes is given")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	c := config.DojoConfig{}
+	err = loadConfig(viper.New(), fs, &c)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	setOutput(&c.Install)
+	Lang = c.Install.Lang
+	AssumeYes = c.Install.AssumeYes
+	if !dropEngines[c.Install.DB.Engine] {
+		// The install would keep the old database and its data rather than start over
+		errorMsg("reinstall.no-drop", c.Install.DB.Engine, c.Install.DB.Name)
+		return exitConfig
+	}
+	if !confirm(fmt.Sprintf("Remove the DefectDojo install in %s, drop its %s database and install it again", c.Install.Root, c.Install.DB.Name)) {
+		statusMsg("reinstall.refused")
+		return exitConfig
+	}
+
+	statusMsg("reinstall.remove", c.Install.Root)
+	err = removeInstall(&c.Install)
+	if err != nil {
+		errorMsg("error", err)
+		return exitFailure
+	}
+
+	// Set values win over the config file, ENV variables and flags the install reads
+	viper.Set("Install.DB.Drop", true)
+	viper.Set("Install.ExistingSource", "overwrite")
+	resetAdmin, _ := fs.GetBool("reset-admin")
+	if resetAdmin {
+		pass, err := newAdminPass()
+		if err != nil {
+			errorMsg("error", err)
+			return exitFailure
+		}
+		viper.Set("Install.Admin.Pass", pass)
+		// Only shown on the console, the install log has it redacted
+		fmt.Printf("New password for the DefectDojo admin %s: %s\n", c.Install.Admin.User, pass)
+	}
+	return installer(ctx, withoutFlag(args, "reset-admin"))
+}
+
+// removeInstall removes the files and directories the manifest in Root records, other than those outside Root
+// and VenvPath like nginx and systemd configs which the install replaces
+func removeInstall(i *config.InstallConfig) error {
+	m, err := readManifest(i.Root)
+	if os.IsNotExist(err) {
+		traceMsg("No install manifest in " + i.Root + ", nothing to remove")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to read the install manifest in %s: %w", i.Root, err)
+	}
+	keep := []string{filepath.Clean(i.Root)}
+	if i.VenvPath != "" {
+		keep = append(keep, filepath.Clean(i.VenvPath))
+	}
+
+	// Longest first so the contents of a directory go before it
+	paths := append([]string{}, m.Paths...)
+	sort.Slice(paths, func(a, b int) bool { return len(paths[a]) > len(paths[b]) })
+	for _, p := range paths {
+		// Paths holding a redacted secret can't be removed as written
+		if strings.Contains(p, "=[REDACTED]=") || !withinAny(p, keep) {
+			traceMsg("Leaving " + p + " for the install to replace")
+			continue
+		}
+		traceMsg("Removing " + p)
+		err := os.RemoveAll(p)
+		if err != nil {
+			return fmt.Errorf("Unable to remove %s from the earlier install: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// withinAny returns true if p is one of dirs or inside one of them
+func withinAny(p string, dirs []string) bool {
+	p = filepath.Clean(p)
+	for _, d := range dirs {
+		if p == d || strings.HasPrefix(p, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutFlag returns args without the boolean flag name e.g. --reset-admin or --reset-admin=true
+func withoutFlag(args []string, name string) []string {
+	out := []string{}
+	for _, a := range args {
+		if a == "--"+name || strings.HasPrefix(a, "--"+name+"=") {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// newAdminPass returns a random admin password strong enough for Validate
+func newAdminPass() (string, error) {
+	b := make([]byte, 18)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
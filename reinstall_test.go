@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+// fakeInstall replaces installer with one recording the flags it's run with and whether Root's source existed,
+// exiting with code
+func fakeInstall(t *testing.T, src string, code int) (*[]string, *bool) {
+	saved, savedYes := installer, AssumeYes
+	t.Cleanup(func() { installer, AssumeYes = saved, savedYes; viper.Reset() })
+	var ran []string
+	var srcLeft bool
+	installer = func(ctx context.Context, args []string) int {
+		ran = append([]string{"install"}, args...)
+		_, err := os.Stat(src)
+		srcLeft = err == nil
+		return code
+	}
+	return &ran, &srcLeft
+}
+
+// reinstallFixture returns a config for an install in a temp Root with a manifest recording its source
+func reinstallFixture(t *testing.T) (string, string) {
+	root := t.TempDir()
+	src := filepath.Join(root, "django-DefectDojo")
+	writeFile(t, filepath.Join(src, "manage.py"), "# manage\n")
+	m := installManifest{Paths: []string{filepath.Join(root, "dojo-v1.5.3.1.tar.gz"), src, "/etc/nginx/sites-enabled/defectdojo"}}
+	writeFile(t, filepath.Join(root, "dojo-v1.5.3.1.tar.gz"), "release")
+	if err := writeManifest(&m, root); err != nil {
+		t.Fatal(err)
+	}
+	body := "Install:\n  Version: \"1.5.3.1\"\n  Root: \"" + root + "\"\n  Source: \"django-DefectDojo\"\n" +
+		"  DB:\n    Engine: \"SQLite\"\n    Name: \"dojodb\"\n  Admin:\n    User: \"admin\"\n    Pass: \"Ohseek4aiveeM3ai\"\n"
+	return body, src
+}
+
+func TestReinstallRefusesWithoutConfirmation(t *testing.T) {
+	body, src := reinstallFixture(t)
+	ran, _ := fakeInstall(t, src, 0)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		// Tests don't run on a terminal so without --yes there's no one to confirm
+		if code := runReinstall(context.Background(), nil); code == 0 {
+			t.Error("Expecting a non-zero exit code without confirmation")
+		}
+	})
+	if *ran != nil {
+		t.Errorf("Expecting no install without confirmation, got %v", *ran)
+	}
+	if _, err := os.Stat(filepath.Join(src, "manage.py")); err != nil {
+		t.Errorf("Expecting the source to be left alone without confirmation, got %v", err)
+	}
+}
+
+func TestReinstallCleansThenInstalls(t *testing.T) {
+	body, src := reinstallFixture(t)
+	ran, srcLeft := fakeInstall(t, src, 0)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		if code := runReinstall(context.Background(), []string{"--yes"}); code != 0 {
+			t.Errorf("Expecting exit code 0, got %d", code)
+		}
+	})
+	if strings.Join(*ran, " ") != "install --yes" {
+		t.Errorf("Expecting the full install to run with the same flags, got %v", *ran)
+	}
+	if *srcLeft {
+		t.Error("Expecting the source to be removed before the install runs")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(src), "dojo-v1.5.3.1.tar.gz")); err == nil {
+		t.Error("Expecting the tarball recorded in the manifest to be removed")
+	}
+	if !viper.GetBool("Install.DB.Drop") || viper.GetString("Install.ExistingSource") != "overwrite" {
+		t.Error("Expecting the install to drop the database and overwrite the source")
+	}
+	if viper.IsSet("Install.Admin.Pass") {
+		t.Error("Expecting the configured admin password to be kept")
+	}
+}
+
+func TestReinstallResetAdmin(t *testing.T) {
+	body, src := reinstallFixture(t)
+	ran, _ := fakeInstall(t, src, 0)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		if code := runReinstall(context.Background(), []string{"--yes", "--reset-admin"}); code != 0 {
+			t.Errorf("Expecting exit code 0, got %d", code)
+		}
+	})
+	if strings.Join(*ran, " ") != "install --yes" {
+		t.Errorf("Expecting --reset-admin not to be passed to the install, got %v", *ran)
+	}
+	if p := viper.GetString("Install.Admin.Pass"); p == "" || p == "Ohseek4aiveeM3ai" {
+		t.Errorf("Expecting a new admin password, got %q", p)
+	}
+}
+
+func TestReinstallReturnsInstallExitCode(t *testing.T) {
+	body, src := reinstallFixture(t)
+	fakeInstall(t, src, exitFailure)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		if code := runReinstall(context.Background(), []string{"--yes"}); code != exitFailure {
+			t.Errorf("Expecting the install's exit code %d, got %d", exitFailure, code)
+		}
+	})
+}
+
+func TestReinstallRefusesEnginesWithoutDrop(t *testing.T) {
+	for _, engine := range []string{"PostgreSQL", "MariaDB"} {
+		body, src := reinstallFixture(t)
+		body = strings.Replace(body, `Engine: "SQLite"`, `Engine: "`+engine+`"`, 1) + "    Host: \"localhost\"\n    Port: 5432\n    User: \"dojo\"\n    Pass: \"Ohseek4aiveeM3ai\"\n"
+		ran, _ := fakeInstall(t, src, 0)
+		inConfigDir(t, "dojoConfig.yml", body, func() {
+			if code := runReinstall(context.Background(), []string{"--yes"}); code != exitConfig {
+				t.Errorf("Expecting exit code %d for %s, got %d", exitConfig, engine, code)
+			}
+		})
+		if *ran != nil {
+			t.Errorf("Expecting no install for %s, got %v", engine, *ran)
+		}
+		if _, err := os.Stat(filepath.Join(src, "manage.py")); err != nil {
+			t.Errorf("Expecting the source to be left alone for %s, got %v", engine, err)
+		}
+	}
+}
+
+// fakeAccounts puts groupadd, useradd, getent, id and chown on the PATH keeping the groups and users they
+// add in a temp dir, groupadd and useradd failing for ones that exist like the real commands
+func fakeAccounts(t *testing.T) string {
+	bin, state := t.TempDir(), t.TempDir()
+	scripts := map[string]string{
+		"groupadd": `[ -e "$STATE/group.$1" ] && exit 9; touch "$STATE/group.$1"`,
+		"useradd":  `for u; do :; done; [ -e "$STATE/user.$u" ] && exit 9; touch "$STATE/user.$u"`,
+		"getent":   `[ -e "$STATE/group.$2" ]`,
+		"id":       `[ -e "$STATE/user.$2" ]`,
+		"chown":    `exit 0`,
+	}
+	for name, body := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\nSTATE="+state+"\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return state
+}
+
+func TestReinstallRunsPrepOSAgain(t *testing.T) {
+	captureLogs(t)
+	useReporter(t, &recordingReporter{})
+	stubCreateVenv(t)
+	fakeLookPath(t, "python3", "getent", "groupadd", "id", "useradd", "chown")
+	state := fakeAccounts(t)
+	// Only the shell lines of osCmds run for real, pip is recorded
+	saved := runCmd
+	t.Cleanup(func() { runCmd = saved; manifest = installManifest{} })
+	runCmd = func(ctx context.Context, name string, args ...string) error {
+		if name == "bash" {
+			return RunCmd(ctx, name, args...)
+		}
+		return nil
+	}
+
+	c := config.DojoConfig{}
+	c.Install.Root = t.TempDir()
+	c.Install.Source = "django-DefectDojo"
+	c.Install.OS.User, c.Install.OS.Group = "dojo", "dojo"
+	writeFile(t, filepath.Join(c.Install.Root, c.Install.Source, "requirements.txt"), "Django\n")
+	steps := installSteps(&c, targetOS{id: "ubuntu:18.04"})
+
+	manifest = installManifest{}
+	if err := runStep(context.Background(), c.Install.Root, &recordingReporter{}, steps, "prep-os"); err != nil {
+		t.Fatalf("Unexpected error from the first install's prep-os: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(state, "user.dojo")); err != nil {
+		t.Fatalf("Expecting the first install to add the dojo user, got %v", err)
+	}
+	if err := writeManifest(&manifest, c.Install.Root); err != nil {
+		t.Fatal(err)
+	}
+
+	// What reinstall does between the two installs
+	if err := removeInstall(&c.Install); err != nil {
+		t.Fatalf("Unexpected error removing the install: %v", err)
+	}
+	manifest = installManifest{}
+	if err := runStep(context.Background(), c.Install.Root, &recordingReporter{}, steps, "prep-os"); err != nil {
+		t.Errorf("Expecting prep-os to run again over the existing user and group, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(c.Install.Root, "logs")); err != nil {
+		t.Errorf("Expecting the logs directory made again, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles 'godojo render' which prints the systemd units, nginx site or env.prod an install would write
+// so they can be reviewed before godojo is let loose on /etc
+
+// renderers maps each thing 'godojo render' can show to the func rendering it for a config, showing
+// secrets in it as they are if show is set
+var renderers = map[string]func(w io.Writer, c *config.DojoConfig, show bool) error{
+	"systemd": func(w io.Writer, c *config.DojoConfig, show bool) error { return renderSystemd(w, &c.Install) },
+	"nginx":   func(w io.Writer, c *config.DojoConfig, show bool) error { return renderNginx(w, &c.Install) },
+	"env":     renderEnvPreview,
+}
+
+// renderCmd implements 'godojo render <systemd|nginx|env> [flags]'
+func renderCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	fs.StringP("out-file", "o", "", "Write the rendered config to this file instead of stdout")
+	fs.Bool("show-secrets", false, "Show the secrets in the env render instead of redacting them")
+	fs.Usage = func() {
+		fmt.Println("Usage: godojo render <systemd|nginx|env> [flags]")
+		fmt.Println("Prints a config the install would write, from the config file, ENV variables and flags - nothing is installed and root isn't needed")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	render, ok := renderers[fs.Arg(0)]
+	if fs.NArg() != 1 || !ok {
+		fs.Usage()
+		return exitConfig
+	}
+
+	c := config.DojoConfig{}
+	err = loadConfig(viper.GetViper(), fs, &c)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	InitRedact(&c)
+
+	var out bytes.Buffer
+	show, _ := fs.GetBool("show-secrets")
+	err = render(&out, &c, show)
+	if err != nil {
+		fmt.Printf("Unable to render the %s config: %+v\n", fs.Arg(0), Redactatron(err.Error(), true))
+		return exitFailure
+	}
+	rendered := out.String()
+	if !show {
+		rendered = Redactatron(rendered, true)
+	}
+
+	file, _ := fs.GetString("out-file")
+	if file == "" {
+		fmt.Print(rendered)
+		return 0
+	}
+	// Even redacted, the env holds the database user and host so keep it private
+	err = ioutil.WriteFile(file, []byte(rendered), 0600)
+	if err != nil {
+		fmt.Printf("Unable to write %s: %+v\n", file, err)
+		return exitFailure
+	}
+	return 0
+}
+
+// renderSystemd writes each systemd unit the install would write for i, headed by where it would go
+func renderSystemd(w io.Writer, i *config.InstallConfig) error {
+	units := []systemdUnit{appUnit(i)}
+	if i.Scheduler == "celery-beat" {
+		units = append(units, beatUnit(i))
+	}
+	for n, u := range units {
+		if n > 0 {
+			fmt.Fprintln(w, "")
+		}
+		fmt.Fprintf(w, "# %s\n", filepath.Join(systemdDir, u.Name+".service"))
+		err := renderUnit(w, u)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderEnvPreview writes the env.prod the install would write for c, keys left to be generated at install
+// time are shown as such rather than as a random value the install won't use
+// The database URL is redacted unless show is set as its escaped password can slip past Redactatron
+func renderEnvPreview(w io.Writer, c *config.DojoConfig, show bool) error {
+	dbURL, err := config.RedactedDatabaseURL(&c.Install)
+	if show {
+		dbURL, err = config.DatabaseURL(&c.Install)
+	}
+	if err != nil {
+		return err
+	}
+	key := func(k string) string {
+		if k == "." {
+			return "<generated at install>"
+		}
+		return k
+	}
+	return renderEnv(w, envValues(c, dbURL, key(c.Settings.Secret.Key), key(c.Settings.Credential.AES.B256.Key)))
+}
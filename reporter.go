@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles reporting install progress so godojo can be embedded in tools with their own UI
+
+// ProgressReporter - receives the progress of an install as it happens
+type ProgressReporter interface {
+	Section(s string)
+	Status(s string)
+	StepStart(name string)
+	StepDone(name string, d time.Duration, err error)
+	Download(pct float64)
+	Extract(pct float64)
+}
+
+// reporter is where all install progress is sent, defaults to the console
+var reporter ProgressReporter = consoleReporter{}
+
+// SetReporter replaces the default console reporter with r
+func SetReporter(r ProgressReporter) {
+	reporter = r
+}
+
+// setOutput sets Output and Quiet from i, everything but progress bars and the summary is left to the log
+// unless the output is verbose
+func setOutput(i *config.InstallConfig) {
+	Output = i.OutputMode()
+	Quiet = Output != "verbose"
+	setColor(i)
+}
+
+// showSummary returns true if errors and the closing message are printed, progress output keeps them
+func showSummary() bool {
+	return !Quiet || Output == "progress"
+}
+
+// newSpinner returns a spinner showing prefix which only writes to the console if Quiet isn't set
+func newSpinner(prefix string) *spinner.Spinner {
+	s := spinner.New(spinner.CharSets[34], 100*time.Millisecond)
+	s.Prefix = prefix
+	if Quiet {
+		s.Writer = ioutil.Discard
+	}
+	return s
+}
+
+// consoleReporter - the default ProgressReporter which prints to stdout unless Quiet is set
+type consoleReporter struct{}
+
+// Section prints a section banner
+func (consoleReporter) Section(s string) {
+	if Quiet {
+		return
+	}
+	fmt.Println("")
+	fmt.Println("==============================================================================")
+	fmt.Printf("  %s\n", colorize(colorBold, s))
+	fmt.Println("==============================================================================")
+	fmt.Println("")
+}
+
+// Status prints a status line
+func (consoleReporter) Status(s string) {
+	if Quiet {
+		return
+	}
+	fmt.Printf("%s\n", s)
+}
+
+// StepStart only traces as sections already show the install's progress on the console
+func (consoleReporter) StepStart(name string) {
+	traceMsg(fmt.Sprintf("Starting install step %s", name))
+}
+
+// StepDone traces how long a step took
+func (consoleReporter) StepDone(name string, d time.Duration, err error) {
+	if err != nil {
+		traceMsg(fmt.Sprintf("Install step %s failed after %s: %+v", name, d, err))
+		return
+	}
+	traceMsg(fmt.Sprintf("Install step %s finished in %s", name, d))
+}
+
+// Download draws the download's progress bar with progress output, otherwise the console shows a spinner
+func (consoleReporter) Download(pct float64) {
+	if Output == "progress" {
+		downloadBar.draw(os.Stdout, pct)
+	}
+}
+
+// Extract draws the extract's progress bar with progress output
+func (consoleReporter) Extract(pct float64) {
+	if Output == "progress" {
+		extractBar.draw(os.Stdout, pct)
+	}
+}
+
+// progressBar - a single line bar redrawn in place as the whole percentage done changes
+type progressBar struct {
+	mu    sync.Mutex
+	label string
+	last  int
+}
+
+// The bars for progress output
+var (
+	downloadBar = &progressBar{label: "Downloading", last: -1}
+	extractBar  = &progressBar{label: "Extracting", last: -1}
+)
+
+// barWidth is the number of characters in a full progress bar
+const barWidth = 40
+
+// draw writes the bar at pct to w ending the line at 100 so the next bar starts fresh
+// Downloads run in parallel so calls can come from more than one goroutine
+func (b *progressBar) draw(w io.Writer, pct float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := int(pct)
+	if p > 100 {
+		p = 100
+	}
+	if p == b.last {
+		return
+	}
+	b.last = p
+	done := p * barWidth / 100
+	fmt.Fprintf(w, "\r%-12s [%s%s] %3d%%", b.label, strings.Repeat("=", done), strings.Repeat(" ", barWidth-done), p)
+	if p == 100 {
+		fmt.Fprintln(w)
+		b.last = -1
+	}
+}
+
+// progressReader - passes the percentage of total read from r to report as it's read
+type progressReader struct {
+	r      io.Reader
+	total  int64
+	read   int64
+	report func(pct float64)
+}
+
+// Read reads from the underlying reader and reports progress
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 && p.total > 0 {
+		p.report(float64(p.read) / float64(p.total) * 100)
+	}
+	return n, err
+}
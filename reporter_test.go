@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// recordingReporter - a ProgressReporter which records each callback it receives
+type recordingReporter struct {
+	calls []string
+}
+
+func (r *recordingReporter) Section(s string)      { r.calls = append(r.calls, "section:"+s) }
+func (r *recordingReporter) Status(s string)       { r.calls = append(r.calls, "status:"+s) }
+func (r *recordingReporter) StepStart(name string) { r.calls = append(r.calls, "start:"+name) }
+func (r *recordingReporter) StepDone(name string, d time.Duration, err error) {
+	r.calls = append(r.calls, fmt.Sprintf("done:%s:%v", name, err))
+}
+func (r *recordingReporter) Download(pct float64) {
+	r.calls = append(r.calls, fmt.Sprintf("download:%.0f", pct))
+}
+func (r *recordingReporter) Extract(pct float64) {
+	r.calls = append(r.calls, fmt.Sprintf("extract:%.0f", pct))
+}
+
+// useReporter swaps in r for the duration of the test
+func useReporter(t *testing.T, r ProgressReporter) {
+	saved := reporter
+	t.Cleanup(func() { reporter = saved })
+	SetReporter(r)
+}
+
+func TestRunStepsReports(t *testing.T) {
+	r := &recordingReporter{}
+	useReporter(t, r)
+
+	steps := []installStep{
+		{name: "bootstrap", run: func(ctx context.Context) error {
+			sectionMsg("Bootstrapping")
+			statusMsg("Bootstrapped")
+			return nil
+		}},
+		{name: "django", run: func(ctx context.Context) error {
+			return errors.New("boom")
+		}},
+		{name: "never", run: func(ctx context.Context) error {
+			t.Errorf("Expecting steps after a failure to not run")
+			return nil
+		}},
+	}
+	err := runSteps(context.Background(), r, steps)
+	if err == nil || err.Error() != "boom" {
+		t.Errorf("Expecting the failed step's error, got %v", err)
+	}
+
+	want := []string{
+		"start:bootstrap",
+		"section:Bootstrapping",
+		"status:Bootstrapped",
+		"done:bootstrap:<nil>",
+		"start:django",
+		"done:django:boom",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("Unexpected callbacks:\n got  %q\n want %q", r.calls, want)
+	}
+}
+
+func TestDownloadReportsProgress(t *testing.T) {
+	r := &recordingReporter{}
+	useReporter(t, r)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "4")
+		w.Write([]byte("dojo"))
+	}))
+	defer srv.Close()
+
+	err := downloadFile(context.Background(), srv.Client(), srv.URL, filepath.Join(t.TempDir(), "dl"), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(r.calls) == 0 || r.calls[len(r.calls)-1] != "download:100" {
+		t.Errorf("Expecting download progress to end at 100, got %q", r.calls)
+	}
+}
+
+// captureStdout returns what f printed to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = saved }()
+	f()
+	w.Close()
+	out, _ := ioutil.ReadAll(r)
+	return string(out)
+}
+
+func TestSetOutput(t *testing.T) {
+	savedQ, savedO := Quiet, Output
+	defer func() { Quiet, Output = savedQ, savedO }()
+	tests := []struct {
+		i     config.InstallConfig
+		mode  string
+		quiet bool
+	}{
+		{config.InstallConfig{}, "verbose", false},
+		{config.InstallConfig{Quiet: true}, "quiet", true},
+		{config.InstallConfig{Output: "progress"}, "progress", true},
+		{config.InstallConfig{Quiet: true, Output: "verbose"}, "verbose", false},
+	}
+	for _, tt := range tests {
+		setOutput(&tt.i)
+		if Output != tt.mode || Quiet != tt.quiet {
+			t.Errorf("%+v: expecting output %s with Quiet %v, got %s and %v", tt.i, tt.mode, tt.quiet, Output, Quiet)
+		}
+	}
+}
+
+func TestProgressOutput(t *testing.T) {
+	buf := captureLogs(t)
+	useReporter(t, consoleReporter{})
+	savedQ, savedO := Quiet, Output
+	defer func() { Quiet, Output = savedQ, savedO }()
+	setOutput(&config.InstallConfig{Output: "progress"})
+
+	out := captureStdout(t, func() {
+		sectionMsg("bootstrap.section")
+		statusMsg("release.done")
+		r := &progressReader{r: strings.NewReader("dojo"), total: 4, report: reporter.Download}
+		ioutil.ReadAll(r)
+	})
+	if strings.Contains(out, "Successfully downloaded") || strings.Contains(out, "Bootstrapping") {
+		t.Errorf("Expecting no sections or status on stdout with progress output, got %q", out)
+	}
+	if !strings.Contains(out, "Downloading") || !strings.Contains(out, "100%") {
+		t.Errorf("Expecting the download's progress bar on stdout, got %q", out)
+	}
+	if !strings.Contains(buf.String(), "Successfully downloaded") {
+		t.Errorf("Expecting status messages in the log, got:\n%s", buf)
+	}
+}
+
+func TestExtractReportsProgress(t *testing.T) {
+	dir := t.TempDir()
+	entries := [][2]string{{"django-DefectDojo-1.5.3.1/manage.py", "# manage"}, {"django-DefectDojo-1.5.3.1/dojo/urls.py", "# urls"}}
+	for name, b := range map[string][]byte{"dojo.tar.gz": fixtureTarball(t, entries), "dojo.zip": fixtureZip(t, entries)} {
+		r := &recordingReporter{}
+		useReporter(t, r)
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := extractArchive(path, filepath.Join(dir, name+"-src"), "", false); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(r.calls) == 0 || r.calls[len(r.calls)-1] != "extract:100" {
+			t.Errorf("%s: expecting extract progress to end at 100, got %q", name, r.calls)
+		}
+	}
+}
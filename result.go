@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the JSON result file describing how an install went for automation wrapping godojo
+
+// stepResult - how one install step went
+type stepResult struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`           // ok, failed or skipped if the install stopped before it
+	Duration float64 `json:"duration_seconds"` // How long the step ran
+	Error    string  `json:"error,omitempty"`  // Why the step failed, secrets redacted
+}
+
+// installResult - the outcome of an install, artifacts are those recorded in the install manifest
+type installResult struct {
+	Success   bool         `json:"success"`
+	Version   string       `json:"version"`   // DefectDojo version, branch or commit installed
+	Installer string       `json:"installer"` // Version of godojo that did the install
+	Steps     []stepResult `json:"steps"`
+	Paths     []string     `json:"paths"`
+	Databases []string     `json:"databases"`
+	DBUsers   []string     `json:"db_users"`
+	OSUsers   []string     `json:"os_users"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// resultReporter - passes progress on to its ProgressReporter while recording each step's outcome
+type resultReporter struct {
+	ProgressReporter
+	steps []stepResult
+}
+
+// StepDone records how the step went then reports it
+func (r *resultReporter) StepDone(name string, d time.Duration, err error) {
+	s := stepResult{Name: name, Status: "ok", Duration: d.Seconds()}
+	if err != nil {
+		s.Status = "failed"
+		s.Error = Redactatron(err.Error(), Redact)
+	}
+	r.steps = append(r.steps, s)
+	r.ProgressReporter.StepDone(name, d, err)
+}
+
+// failed returns the names of the steps which failed, only optional steps once the install succeeds
+func (r *resultReporter) failed() []string {
+	names := []string{}
+	for _, s := range r.steps {
+		if s.Status == "failed" {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// installedVersion returns the release, pull request, branch or commit being installed
+func installedVersion(i *config.InstallConfig) string {
+	if !i.SourceInstall {
+		return i.Version
+	}
+	if i.SourcePR > 0 {
+		return fmt.Sprintf("pull/%d", i.SourcePR)
+	}
+	if len(i.SourceCommit) > 0 {
+		return i.SourceCommit
+	}
+	return i.SourceBranch
+}
+
+// newResult builds the result of running steps from what rec recorded, steps which never ran or were skipped by the config are skipped
+func newResult(steps []installStep, rec *resultReporter, m *installManifest, ver string, err error) installResult {
+	res := installResult{
+		Success:   err == nil,
+		Version:   ver,
+		Installer: version,
+		Paths:     redactList(m.Paths),
+		Databases: redactList(m.Databases),
+		DBUsers:   redactList(m.DBUsers),
+		OSUsers:   redactList(m.OSUsers),
+	}
+	ran := map[string]stepResult{}
+	for _, r := range rec.steps {
+		ran[r.Name] = r
+	}
+	for _, s := range steps {
+		r, ok := ran[s.name]
+		if !ok {
+			r = stepResult{Name: s.name, Status: "skipped"}
+		}
+		res.Steps = append(res.Steps, r)
+	}
+	if err != nil {
+		res.Error = Redactatron(err.Error(), Redact)
+	}
+	return res
+}
+
+// writeResult writes res to path as JSON
+func writeResult(path string, res installResult) error {
+	out, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, append(out, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+	// Keep the result readable by whatever polls it whatever the Umask or an earlier file's mode
+	return os.Chmod(path, 0644)
+}
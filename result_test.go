@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// fakeRun runs three fake steps, the second failing with failWith if it's not nil, and returns the result file
+func fakeRun(t *testing.T, failWith error) map[string]interface{} {
+	m := installManifest{}
+	steps := []installStep{
+		{name: "download", run: func(ctx context.Context) error { m.addPath("/opt/dojo/django-DefectDojo"); return nil }},
+		{name: "prep-db", run: func(ctx context.Context) error { m.addDatabase("dojodb"); return failWith }},
+		{name: "django", run: func(ctx context.Context) error { return nil }},
+	}
+	rec := &resultReporter{ProgressReporter: &recordingReporter{}}
+	err := runSteps(context.Background(), rec, steps)
+
+	p := filepath.Join(t.TempDir(), "result.json")
+	if werr := writeResult(p, newResult(steps, rec, &m, "1.5.3.1", err)); werr != nil {
+		t.Fatalf("Unable to write the result: %v", werr)
+	}
+	b, _ := ioutil.ReadFile(p)
+	res := map[string]interface{}{}
+	if jerr := json.Unmarshal(b, &res); jerr != nil {
+		t.Fatalf("Expecting valid JSON, got %v:\n%s", jerr, b)
+	}
+	return res
+}
+
+// stepStatuses returns the status of each step in res
+func stepStatuses(res map[string]interface{}) []string {
+	out := []string{}
+	for _, s := range res["steps"].([]interface{}) {
+		step := s.(map[string]interface{})
+		if _, ok := step["duration_seconds"]; !ok {
+			return nil
+		}
+		out = append(out, step["name"].(string)+":"+step["status"].(string))
+	}
+	return out
+}
+
+func TestResultSuccess(t *testing.T) {
+	res := fakeRun(t, nil)
+	if res["success"] != true || res["version"] != "1.5.3.1" || res["installer"] != version {
+		t.Errorf("Expecting a successful 1.5.3.1 install, got %v", res)
+	}
+	if _, ok := res["error"]; ok {
+		t.Errorf("Expecting no error on success, got %v", res["error"])
+	}
+	got := stepStatuses(res)
+	if len(got) != 3 || got[0] != "download:ok" || got[2] != "django:ok" {
+		t.Errorf("Expecting every step ok, got %v", got)
+	}
+	paths := res["paths"].([]interface{})
+	if len(paths) != 1 || paths[0] != "/opt/dojo/django-DefectDojo" {
+		t.Errorf("Expecting the created paths from the manifest, got %v", paths)
+	}
+}
+
+func TestResultFailure(t *testing.T) {
+	res := fakeRun(t, errors.New("Unable to connect to the database"))
+	if res["success"] != false || res["error"] != "Unable to connect to the database" {
+		t.Errorf("Expecting a failed install with its error, got %v", res)
+	}
+	got := stepStatuses(res)
+	want := []string{"download:ok", "prep-db:failed", "django:skipped"}
+	if len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Expecting %v, got %v", want, got)
+	}
+	if dbs := res["databases"].([]interface{}); len(dbs) != 1 {
+		t.Errorf("Expecting the database created before the failure, got %v", dbs)
+	}
+}
+
+func TestResultFailedMigrationTail(t *testing.T) {
+	captureLogs(t)
+	c := osCmds{
+		cmds:   []string{"echo Applying dojo.0001_initial...; echo django.db.utils.OperationalError: no such table >&2; exit 1"},
+		errmsg: []string{"Failed during database migrate"},
+		hard:   []bool{true},
+	}
+	steps := []installStep{{name: "django", run: func(ctx context.Context) error { return runCmds(ctx, "Setting up Django...", &c) }}}
+	rec := &resultReporter{ProgressReporter: &recordingReporter{}}
+	err := runSteps(context.Background(), rec, steps)
+
+	var cErr *dojoerr.CmdError
+	if !errors.As(err, &cErr) || len(cErr.Tail) != 2 {
+		t.Fatalf("Expecting a CmdError with the migration's output, got %v", err)
+	}
+	res := newResult(steps, rec, &installManifest{}, "1.5.3.1", err)
+	for _, e := range []string{res.Error, res.Steps[0].Error} {
+		if !strings.HasPrefix(e, "Failed during database migrate: ") || !strings.Contains(e, "OperationalError: no such table") {
+			t.Errorf("Expecting the result to hold the migration's last output, got %q", e)
+		}
+	}
+}
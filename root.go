@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the check that the installer is run as root
+
+// errNotRoot is returned by rootCheck when the install needs root and isn't running as root
+var errNotRoot = errors.New("This program must be run as root or with sudo\n  Please correct and run installer again or use --skip-root-check")
+
+// rootCheck returns errNotRoot unless running as root, in container mode or with SkipRootCheck set,
+// the latter two return a warning as commands needing root may fail
+func rootCheck(i *config.InstallConfig, container bool) (string, error) {
+	u, err := currentUser()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case u.Uid == "0":
+		return "", nil
+	case i.SkipRootCheck:
+		return "Not running as root and the root check is skipped per SkipRootCheck, commands needing root will fail", nil
+	case container:
+		return "Not running as root in container mode, commands needing root may fail", nil
+	}
+	return "", errNotRoot
+}
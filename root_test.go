@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// runAs replaces the current user with one having uid for the duration of the test
+func runAs(t *testing.T, uid string) {
+	saved := currentUser
+	t.Cleanup(func() { currentUser = saved })
+	currentUser = func() (*user.User, error) { return &user.User{Uid: uid, Username: "dojo"}, nil }
+}
+
+func TestRootCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		uid       string
+		skip      bool
+		container bool
+		warn      string
+		err       error
+	}{
+		{"root", "0", false, false, "", nil},
+		{"root skipping the check", "0", true, false, "", nil},
+		{"not root", "1000", false, false, "", errNotRoot},
+		{"not root skipping the check", "1000", true, false, "root check is skipped per SkipRootCheck", nil},
+		{"not root in a container", "1000", false, true, "in container mode", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runAs(t, tt.uid)
+			warn, err := rootCheck(&config.InstallConfig{SkipRootCheck: tt.skip}, tt.container)
+			if err != tt.err {
+				t.Errorf("Expecting error %v, got %v", tt.err, err)
+			}
+			if (tt.warn == "") != (warn == "") || !strings.Contains(warn, tt.warn) {
+				t.Errorf("Expecting a warning with %q, got %q", tt.warn, warn)
+			}
+		})
+	}
+}
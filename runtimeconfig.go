@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Handles writing out the runtime config based on the net of the config file + ENV variables + flags
+
+// writeRuntimeConfig writes the effective config held by v to path as YAML, readable only by the
+// owner and with every sensitive key encrypted with passphrase, or redacted if passphrase is empty,
+// so no secrets end up on disk in clear text. ConfigPassphrase itself is left out entirely
+func writeRuntimeConfig(v *viper.Viper, path string, passphrase string) error {
+	all := omitPassphrase(v.AllSettings())
+	settings := redactSettings(all, "")
+	if passphrase != "" {
+		var err error
+		settings, err = encryptSettings(all, "", passphrase)
+		if err != nil {
+			return err
+		}
+	}
+	out, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("Unable to convert the runtime config to YAML: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("Unable to create the directory for the runtime config %s: %w", path, err)
+	}
+	err = ioutil.WriteFile(path, out, 0600)
+	if err != nil {
+		return fmt.Errorf("Unable to write the runtime config to %s: %w", path, err)
+	}
+	traceMsg(fmt.Sprintf("Wrote runtime config with secrets protected to %+v", path))
+	return nil
+}
+
+// omitPassphrase removes ConfigPassphrase from viper's settings s, encrypted with itself it would only
+// protect the other secrets from someone who doesn't have the passphrase anyway
+func omitPassphrase(s map[string]interface{}) map[string]interface{} {
+	if i, ok := s["install"].(map[string]interface{}); ok {
+		delete(i, "configpassphrase")
+	}
+	return s
+}
+
+// runtimeConfigPath returns where the runtime config will be written, defaulting to the log directory
+// so all the artifacts of an install run stay together
+func runtimeConfigPath(i *config.InstallConfig) string {
+	if i.RuntimeConfigPath != "" {
+		return i.RuntimeConfigPath
+	}
+	return filepath.Join(logLocation, "runtime-install-config.yml")
+}
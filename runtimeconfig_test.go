@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+func TestWriteRuntimeConfigRedacts(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "runtime-install-config.yml")
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		v := viper.New()
+		c := config.DojoConfig{}
+		if err := loadConfig(v, installFlags(), &c); err != nil {
+			t.Fatalf("Unexpected error loading config: %v", err)
+		}
+		if !c.Install.WriteRuntimeConfig {
+			t.Errorf("Expecting WriteRuntimeConfig to default to true")
+		}
+		if err := writeRuntimeConfig(v, out, ""); err != nil {
+			t.Fatalf("Unexpected error writing runtime config: %v", err)
+		}
+	})
+
+	raw, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Unable to read runtime config: %v", err)
+	}
+	for _, secret := range []string{"vee0Thoanae1daePooz0ieka", "Ohseek4aiveeM3ai", "uu6ahHei3ohquoh1"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("Expecting secret %s to be redacted from the runtime config, got:\n%s", secret, raw)
+		}
+	}
+	if !strings.Contains(string(raw), "dojodbusr") {
+		t.Errorf("Expecting non-secret values in the runtime config, got:\n%s", raw)
+	}
+	fi, _ := os.Stat(out)
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("Expecting runtime config mode 0600, got %v", fi.Mode().Perm())
+	}
+}
+
+func TestRuntimeConfigPath(t *testing.T) {
+	i := config.InstallConfig{}
+	if got := runtimeConfigPath(&i); got != filepath.Join(logLocation, "runtime-install-config.yml") {
+		t.Errorf("Expecting the runtime config in the log directory by default, got %s", got)
+	}
+
+	// Parent directories are created as needed
+	i.RuntimeConfigPath = filepath.Join(t.TempDir(), "nested", "dir", "runtime.yml")
+	v := viper.New()
+	v.Set("Install.Version", "1.5.3.1")
+	if err := writeRuntimeConfig(v, runtimeConfigPath(&i), ""); err != nil {
+		t.Fatalf("Unexpected error writing runtime config: %v", err)
+	}
+	raw, err := ioutil.ReadFile(i.RuntimeConfigPath)
+	if err != nil {
+		t.Fatalf("Expecting the runtime config at %s, got %v", i.RuntimeConfigPath, err)
+	}
+	if !strings.Contains(string(raw), "1.5.3.1") {
+		t.Errorf("Expecting the config values in the runtime config, got:\n%s", raw)
+	}
+}
+
+func TestRuntimeConfigOmitsResolvedSecrets(t *testing.T) {
+	pass := filepath.Join(t.TempDir(), "db_pass")
+	if err := ioutil.WriteFile(pass, []byte("vee0Thoanae1daePooz0ieka\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOJO_TEST_ADMIN_PASS", "Ohseek4aiveeM3ai")
+	body := "Install:\n  Version: \"1.5.3.1\"\n  DB:\n    Name: \"dojodb\"\n    PassFile: \"" + pass + "\"\n" +
+		"  Admin:\n    Pass: \"secret://env/DOJO_TEST_ADMIN_PASS\"\n"
+	out := filepath.Join(t.TempDir(), "runtime-install-config.yml")
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		v := viper.New()
+		c := config.DojoConfig{}
+		if err := loadConfig(v, installFlags(), &c); err != nil {
+			t.Fatalf("Unexpected error loading config: %v", err)
+		}
+		if c.Install.DB.Pass != "vee0Thoanae1daePooz0ieka" || c.Install.Admin.Pass != "Ohseek4aiveeM3ai" {
+			t.Errorf("Expecting the secrets resolved when loading the config, got %q and %q", c.Install.DB.Pass, c.Install.Admin.Pass)
+		}
+		if err := writeRuntimeConfig(v, out, ""); err != nil {
+			t.Fatalf("Unexpected error writing runtime config: %v", err)
+		}
+	})
+	raw, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Unable to read runtime config: %v", err)
+	}
+	if strings.Contains(string(raw), "vee0Thoanae1daePooz0ieka") || strings.Contains(string(raw), "Ohseek4aiveeM3ai") {
+		t.Errorf("Expecting no resolved secrets in the runtime config, got:\n%s", raw)
+	}
+	if !strings.Contains(string(raw), pass) {
+		t.Errorf("Expecting the secret file's path in the runtime config, got:\n%s", raw)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles scheduling DefectDojo's periodic maintenance tasks with Celery beat or cron
+
+// cronFile is where cron entries for DefectDojo are written
+var cronFile = "/etc/cron.d/defectdojo"
+
+// scheduledTasks are DefectDojo's periodic Celery tasks and how often cron runs each
+var scheduledTasks = []struct {
+	task string
+	cron string
+}{
+	{task: "dojo.tasks.add_alerts", cron: "0 * * * *"},
+	{task: "dojo.tasks.cleanup_alerts", cron: "30 * * * *"},
+	{task: "dojo.tasks.async_dupe_delete", cron: "*/5 * * * *"},
+}
+
+// beatUnit returns the systemd unit running Celery beat for the install
+func beatUnit(i *config.InstallConfig) systemdUnit {
+	return systemdUnit{
+		Name:        "defectdojo-celerybeat",
+		Description: "DefectDojo Celery beat scheduler",
+		After:       "network.target rabbitmq-server.service redis-server.service",
+		User:        i.OS.User,
+		Group:       i.OS.Group,
+		WorkDir:     filepath.Join(i.Root, i.Source),
+		ExecStart: fmt.Sprintf("%s beat -A dojo -l info --schedule %s",
+			filepath.Join(venvPath(i), "bin", "celery"), filepath.Join(i.Root, "celerybeat-schedule")),
+	}
+}
+
+// cronEntries returns the cron.d lines which queue each scheduled task
+func cronEntries(i *config.InstallConfig) []string {
+	celery := filepath.Join(venvPath(i), "bin", "celery")
+	lines := make([]string, len(scheduledTasks))
+	for n, t := range scheduledTasks {
+		lines[n] = fmt.Sprintf("%s %s cd %s && %s -A dojo call %s",
+			t.cron, i.OS.User, filepath.Join(i.Root, i.Source), celery, t.task)
+	}
+	return lines
+}
+
+// addCronEntries adds each of lines to the cron file at path unless it's already there
+// returning the lines added so re-running an install never duplicates entries
+func addCronEntries(path string, lines []string) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	current := string(b)
+	if current == "" {
+		current = "# DefectDojo maintenance tasks written by godojo\nSHELL=/bin/bash\n"
+	}
+	existing := strings.Split(current, "\n")
+
+	added := []string{}
+	for _, l := range lines {
+		if contains(existing, l) {
+			continue
+		}
+		if !strings.HasSuffix(current, "\n") {
+			current += "\n"
+		}
+		current += l + "\n"
+		added = append(added, l)
+	}
+	if len(added) == 0 {
+		return added, nil
+	}
+	// cron ignores files in cron.d which are writable by group or other
+	return added, ioutil.WriteFile(path, []byte(current), 0644)
+}
+
+// scheduleNeeds returns the programs the configured Scheduler runs
+func scheduleNeeds(i *config.InstallConfig) []string {
+	if i.Scheduler == "celery-beat" {
+		return serviceTools("systemctl")
+	}
+	return nil
+}
+
+// setupSchedule sets up DefectDojo's periodic tasks with the configured scheduler - celery-beat, cron or none
+func setupSchedule(ctx context.Context, c *config.DojoConfig) error {
+	i := &c.Install
+	switch i.Scheduler {
+	case "", "none":
+		statusMsg("schedule.none")
+		return nil
+	case "celery-beat", "cron":
+	default:
+		return fmt.Errorf("Unknown Scheduler %s, use celery-beat, cron or none", i.Scheduler)
+	}
+
+	// Both ways of scheduling queue tasks for Celery workers through the broker
+	if c.Settings.Celery.Broker.Host == "" && c.Settings.Celery.Broker.URL == "" {
+		statusMsg("schedule.no-broker")
+		Warning.Println("Scheduler configured without a Celery broker")
+	}
+
+	if i.Scheduler == "celery-beat" {
+		return installUnit(ctx, beatUnit(i))
+	}
+
+	added, err := addCronEntries(cronFile, cronEntries(i))
+	if err != nil {
+		return fmt.Errorf("Unable to update %s: %w", cronFile, err)
+	}
+	manifest.addPath(cronFile)
+	for _, l := range added {
+		statusMsg("schedule.added", l)
+	}
+	if len(added) == 0 {
+		statusMsg("schedule.present")
+	}
+	return nil
+}
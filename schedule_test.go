@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// scheduleInstall is a sample install config for the scheduler tests
+func scheduleInstall() *config.InstallConfig {
+	i := &config.InstallConfig{Root: "/opt/dojo", Source: "django-DefectDojo"}
+	i.OS.User = "dojo-srv"
+	i.OS.Group = "dojo-srv"
+	return i
+}
+
+func TestAddCronEntriesIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "defectdojo")
+	lines := cronEntries(scheduleInstall())
+
+	added, err := addCronEntries(path, lines)
+	if err != nil || len(added) != len(lines) {
+		t.Fatalf("Expecting all entries added on the first run, got %d, %v", len(added), err)
+	}
+	added, err = addCronEntries(path, lines)
+	if err != nil || len(added) != 0 {
+		t.Errorf("Expecting nothing added on the second run, got %q, %v", added, err)
+	}
+
+	b, _ := ioutil.ReadFile(path)
+	for _, l := range lines {
+		if n := strings.Count(string(b), l); n != 1 {
+			t.Errorf("Expecting one entry for %q, got %d in:\n%s", l, n, b)
+		}
+	}
+}
+
+func TestRenderBeatUnit(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := renderUnit(out, beatUnit(scheduleInstall()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"Description=DefectDojo Celery beat scheduler",
+		"User=dojo-srv",
+		"WorkingDirectory=/opt/dojo/django-DefectDojo",
+		"ExecStart=/opt/dojo/bin/celery beat -A dojo",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expecting %q in unit, got:\n%s", want, out)
+		}
+	}
+}
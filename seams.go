@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/user"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// Small interfaces over the network and git so tests can substitute fakes for the real thing
+
+// httpDoer - the part of *http.Client used by the installer
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// gitCloner - the part of go-git used by the installer to clone DefectDojo
+type gitCloner interface {
+	PlainCloneContext(ctx context.Context, path string, isBare bool, o *git.CloneOptions) (*git.Repository, error)
+}
+
+// goGitCloner clones with go-git
+type goGitCloner struct{}
+
+func (goGitCloner) PlainCloneContext(ctx context.Context, path string, isBare bool, o *git.CloneOptions) (*git.Repository, error) {
+	return git.PlainCloneContext(ctx, path, isBare, o)
+}
+
+var (
+	// Client used for all downloads, replaced by one with the configured timeouts once the config is loaded
+	httpClient httpDoer = &http.Client{Transport: newTransport(&config.InstallConfig{
+		ConnectTimeout:        defaultConnectTimeout,
+		TLSHandshakeTimeout:   defaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: defaultResponseHeaderTimeout,
+	}), CheckRedirect: checkRedirect}
+	// Cloner used for source installs
+	cloner gitCloner = goGitCloner{}
+	// Runs OS commands, tests replace it to check what would be run
+	runCmd func(ctx context.Context, name string, args ...string) error = RunCmd
+	// Finds programs in $PATH, tests replace it to pretend programs are or aren't installed
+	lookPath = exec.LookPath
+	// Checks the install's database accepts connections, tests replace it to simulate a database starting up
+	pingDB = dbReachable
+	// Compares the filesystems of two paths, tests replace it to simulate TempDir on another filesystem
+	onSameDevice = sameDevice
+	// Returns the user godojo is running as, tests replace it to check the root requirement
+	currentUser = user.Current
+	// Moves a file or directory, tests replace it to simulate a move across filesystems
+	rename = os.Rename
+)
+
+// maxRedirects is how many redirects a download follows before giving up
+const maxRedirects = 10
+
+// Download timeouts used until the config is loaded and when it doesn't set them
+const (
+	defaultConnectTimeout        = 10 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultResponseHeaderTimeout = 30 * time.Second
+)
+
+// newHTTPClient returns the client used for downloads with redirects handled by checkRedirect, trusting
+// CACertFile as well as the system CAs if it's set. There's no overall timeout so a slow but healthy
+// download isn't killed part way through
+func newHTTPClient(i *config.InstallConfig) (*http.Client, error) {
+	t := newTransport(i)
+	if i.CACertFile != "" {
+		pool, err := caPool(i.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	if i.InsecureSkipVerify {
+		// Validate warns about this so it's never a surprise
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true
+	}
+	return &http.Client{Transport: t, CheckRedirect: checkRedirect}, nil
+}
+
+// caPool returns the system CAs plus those in the PEM file at path e.g. a TLS-inspecting proxy's CA
+func caPool(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read CACertFile %s: %w", path, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		// Built before logging is setup, without system CAs only path is trusted
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CACertFile %s doesn't hold any PEM encoded certificates", path)
+	}
+	return pool, nil
+}
+
+// newTransport returns the default transport with the connect, TLS handshake and response header
+// timeouts from i so an unreachable or stalled host fails fast
+func newTransport(i *config.InstallConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	d := &net.Dialer{Timeout: i.ConnectTimeout, KeepAlive: 30 * time.Second}
+	t.DialContext = d.DialContext
+	t.TLSHandshakeTimeout = i.TLSHandshakeTimeout
+	t.ResponseHeaderTimeout = i.ResponseHeaderTimeout
+	return t
+}
+
+// checkRedirect caps the redirects followed, keeps the godojo User-Agent on each hop, and drops the
+// Authorization header once a redirect leaves the original host e.g. GitHub to objects.githubusercontent.com
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	traceMsg(fmt.Sprintf("Following redirect from %s to %s", via[len(via)-1].URL.Redacted(), req.URL.Redacted()))
+	req.Header.Set("User-Agent", userAgent())
+	if req.URL.Host != via[0].URL.Host && req.Header.Get("Authorization") != "" {
+		traceMsg(fmt.Sprintf("Redirect left %s, not sending the Authorization header to %s", via[0].URL.Host, req.URL.Host))
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+// userAgent identifies godojo in the logs of GitHub and any proxies between
+func userAgent() string {
+	return fmt.Sprintf("godojo/%s (+%s)", version, HelpURL)
+}
+
+// newRequest builds every outbound HTTP request so all share the godojo User-Agent and HTTP tracing
+func newRequest(ctx context.Context, method string, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(withHTTPTrace(ctx), method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	return req, nil
+}
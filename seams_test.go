@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+// testClient returns a download client like the installer's with a short connect timeout
+func testClient(t *testing.T) *http.Client {
+	c, err := newHTTPClient(&config.InstallConfig{ConnectTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestUserAgent(t *testing.T) {
+	agents := make(chan string, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.UserAgent()
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	want := "godojo/" + version + " (+" + HelpURL + ")"
+	err := downloadFile(context.Background(), srv.Client(), srv.URL, filepath.Join(t.TempDir(), "dl"), 0)
+	if err != nil {
+		t.Fatalf("Unexpected download error: %v", err)
+	}
+	_, err = githubGet(context.Background(), srv.Client(), srv.URL, "")
+	if err != nil {
+		t.Fatalf("Unexpected GitHub API error: %v", err)
+	}
+	_, _, err = fetchConfigURL(context.Background(), srv.Client(), srv.URL, "")
+	if err != nil {
+		t.Fatalf("Unexpected config URL error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got := <-agents; got != want {
+			t.Errorf("Expecting User-Agent %q, got %q", want, got)
+		}
+	}
+}
+
+func TestRedirectDropsAuth(t *testing.T) {
+	auths := make(chan string, 2)
+	objects := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths <- r.Header.Get("Authorization")
+		if r.UserAgent() != userAgent() {
+			t.Errorf("Expecting the godojo User-Agent after a redirect, got %q", r.UserAgent())
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer objects.Close()
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths <- r.Header.Get("Authorization")
+		http.Redirect(w, r, objects.URL+"/release", http.StatusFound)
+	}))
+	defer github.Close()
+
+	_, err := githubGet(context.Background(), testClient(t), github.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("Unexpected error following the redirect: %v", err)
+	}
+	if got := <-auths; got == "" {
+		t.Errorf("Expecting the original host to get the Authorization header")
+	}
+	if got := <-auths; got != "" {
+		t.Errorf("Expecting no Authorization header on the other host, got %q", got)
+	}
+}
+
+func TestRedirectLimit(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL+r.URL.Path+"x", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	err := downloadFile(context.Background(), testClient(t), srv.URL+"/", filepath.Join(t.TempDir(), "dl"), 0)
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("Expecting the download to stop after 10 redirects, got %v", err)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	i := config.InstallConfig{ConnectTimeout: 3 * time.Second, TLSHandshakeTimeout: 4 * time.Second, ResponseHeaderTimeout: time.Minute}
+	c, err := newHTTPClient(&i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Timeout != 0 {
+		t.Errorf("Expecting no overall client timeout, got %s", c.Timeout)
+	}
+	tr := c.Transport.(*http.Transport)
+	if tr.TLSHandshakeTimeout != 4*time.Second || tr.ResponseHeaderTimeout != time.Minute {
+		t.Errorf("Expecting the TLS and response header timeouts from the config, got %s and %s", tr.TLSHandshakeTimeout, tr.ResponseHeaderTimeout)
+	}
+	if tr.DialContext == nil || tr.Proxy == nil {
+		t.Errorf("Expecting a dialer and the default proxy settings")
+	}
+}
+
+func TestTransportTimeoutsConfig(t *testing.T) {
+	// Older config files without the timeouts get the defaults
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), installFlags(), &c)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if c.Install.ConnectTimeout != defaultConnectTimeout || c.Install.ResponseHeaderTimeout != defaultResponseHeaderTimeout {
+			t.Errorf("Expecting the default timeouts, got %s and %s", c.Install.ConnectTimeout, c.Install.ResponseHeaderTimeout)
+		}
+	})
+	body := strings.Replace(sampleConfig, "Install:\n", "Install:\n  ConnectTimeout: 2s\n  ResponseHeaderTimeout: 0\n", 1)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		c := config.DojoConfig{}
+		err := loadConfig(viper.New(), installFlags(), &c)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if c.Install.ConnectTimeout != 2*time.Second || c.Install.ResponseHeaderTimeout != 0 {
+			t.Errorf("Expecting 2s and no response header timeout, got %s and %s", c.Install.ConnectTimeout, c.Install.ResponseHeaderTimeout)
+		}
+	})
+}
+
+func TestCACertFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("release"))
+	}))
+	defer srv.Close()
+	// Like a TLS-inspecting proxy the test server's certificate isn't signed by a system CA
+	err := downloadFile(context.Background(), testClient(t), srv.URL+"/", filepath.Join(t.TempDir(), "dl"), 0)
+	if err == nil {
+		t.Fatal("Expecting the test server's certificate to be rejected without its CA")
+	}
+
+	ca := filepath.Join(t.TempDir(), "proxy-ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	writeFile(t, ca, string(pemBytes))
+	c, err := newHTTPClient(&config.InstallConfig{CACertFile: ca})
+	if err != nil {
+		t.Fatalf("Unexpected error loading the CA: %v", err)
+	}
+	if tc := c.Transport.(*http.Transport).TLSClientConfig; tc == nil || tc.RootCAs == nil {
+		t.Fatal("Expecting root CAs set on the transport")
+	}
+	err = downloadFile(context.Background(), c, srv.URL+"/", filepath.Join(t.TempDir(), "dl"), 0)
+	if err != nil {
+		t.Errorf("Expecting the download to succeed trusting the CA, got %v", err)
+	}
+
+	writeFile(t, ca, "not a certificate")
+	if _, err := newHTTPClient(&config.InstallConfig{CACertFile: ca}); err == nil {
+		t.Error("Expecting an error for a CACertFile without certificates")
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	c, err := newHTTPClient(&config.InstallConfig{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tc := c.Transport.(*http.Transport).TLSClientConfig; tc == nil || !tc.InsecureSkipVerify {
+		t.Errorf("Expecting the transport to skip verification, got %+v", tc)
+	}
+	d := config.DojoConfig{}
+	d.Install.InsecureSkipVerify = true
+	warns, _ := d.Validate()
+	found := false
+	for _, w := range warns {
+		found = found || strings.Contains(w, "InsecureSkipVerify")
+	}
+	if !found {
+		t.Errorf("Expecting a warning about InsecureSkipVerify, got %q", warns)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/ssh/terminal"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Handles encrypting secrets in the runtime config with a passphrase so they're usable but not in clear text
+
+// encPrefix marks an encrypted value in the runtime config
+const encPrefix = "godojo-enc:v1:"
+
+// Sizes for the scrypt derived AES-256-GCM key
+const (
+	saltLen = 16
+	keyLen  = 32
+)
+
+// encryptValue encrypts s with a key derived from passphrase returning encPrefix + base64(salt + nonce + ciphertext)
+func encryptValue(s string, passphrase string) (string, error) {
+	salt := make([]byte, saltLen)
+	_, err := io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+	out := append(salt, nonce...)
+	out = gcm.Seal(out, nonce, []byte(s), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+// decryptValue reverses encryptValue, values without encPrefix are returned unchanged
+func decryptValue(s string, passphrase string) (string, error) {
+	if !strings.HasPrefix(s, encPrefix) {
+		return s, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, encPrefix))
+	if err != nil {
+		return "", fmt.Errorf("Encrypted value is corrupt: %w", err)
+	}
+	if len(raw) < saltLen {
+		return "", errors.New("Encrypted value is too short")
+	}
+	gcm, err := newGCM(passphrase, raw[:saltLen])
+	if err != nil {
+		return "", err
+	}
+	raw = raw[saltLen:]
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("Encrypted value is too short")
+	}
+	clear, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("Unable to decrypt value, the passphrase is wrong or the value was changed")
+	}
+	return string(clear), nil
+}
+
+// newGCM derives a key from passphrase and salt with scrypt and returns an AES-GCM cipher using it
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	blk, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(blk)
+}
+
+// encryptSettings returns a copy of the nested settings map from viper with sensitive values encrypted
+func encryptSettings(s map[string]interface{}, prefix string, passphrase string) (map[string]interface{}, error) {
+	enc := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		key := strings.ToLower(prefix + k)
+		switch val := v.(type) {
+		case map[string]interface{}:
+			m, err := encryptSettings(val, key+".", passphrase)
+			if err != nil {
+				return nil, err
+			}
+			enc[k] = m
+		default:
+			str := fmt.Sprintf("%v", val)
+			if !isSensitive(key) || str == "" {
+				enc[k] = val
+				continue
+			}
+			e, err := encryptValue(str, passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to encrypt %s: %w", key, err)
+			}
+			enc[k] = e
+		}
+	}
+	return enc, nil
+}
+
+// decryptYAML decrypts every encrypted value in the YAML doc
+func decryptYAML(doc []byte, passphrase string) ([]byte, error) {
+	var m yaml.MapSlice
+	err := yaml.Unmarshal(doc, &m)
+	if err != nil {
+		return nil, err
+	}
+	err = decryptMapSlice(m, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(m)
+}
+
+// decryptMapSlice decrypts the encrypted values of m in place
+func decryptMapSlice(m yaml.MapSlice, passphrase string) error {
+	for i := range m {
+		switch val := m[i].Value.(type) {
+		case yaml.MapSlice:
+			err := decryptMapSlice(val, passphrase)
+			if err != nil {
+				return err
+			}
+		case string:
+			clear, err := decryptValue(val, passphrase)
+			if err != nil {
+				return fmt.Errorf("%v: %w", m[i].Key, err)
+			}
+			m[i].Value = clear
+		}
+	}
+	return nil
+}
+
+// decryptConfigCmd implements 'godojo decrypt-config [file]' which prints a runtime config with secrets decrypted
+// The passphrase is read from DD_CONFIG_PASSPHRASE or prompted for
+func decryptConfigCmd(ctx context.Context, args []string) int {
+	fs := pflag.NewFlagSet("decrypt-config", pflag.ContinueOnError)
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	path := runtimeConfigPath(&conf.Install)
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	doc, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Unable to read %s: %+v\n", path, err)
+		return exitConfig
+	}
+	pass := os.Getenv("DD_CONFIG_PASSPHRASE")
+	if pass == "" {
+		if !stdinIsTerminal() {
+			fmt.Println("Set DD_CONFIG_PASSPHRASE to decrypt the runtime config")
+			return exitConfig
+		}
+		fmt.Print("Passphrase: ")
+		b, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println("")
+		if err != nil {
+			fmt.Printf("Unable to read the passphrase: %+v\n", err)
+			return exitConfig
+		}
+		pass = string(bytes.TrimSpace(b))
+	}
+
+	clear, err := decryptYAML(doc, pass)
+	if err != nil {
+		fmt.Printf("Unable to decrypt %s: %+v\n", path, err)
+		return exitFailure
+	}
+	fmt.Print(string(clear))
+	return 0
+}
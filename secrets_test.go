@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	enc, err := encryptValue("vee0Thoanae1daePooz0ieka", "correct horse")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(enc, encPrefix) || strings.Contains(enc, "vee0Thoanae1daePooz0ieka") {
+		t.Errorf("Expecting a prefixed, non clear text value, got %s", enc)
+	}
+	clear, err := decryptValue(enc, "correct horse")
+	if err != nil || clear != "vee0Thoanae1daePooz0ieka" {
+		t.Errorf("Expecting the round trip to return the secret, got %q, %v", clear, err)
+	}
+	_, err = decryptValue(enc, "wrong")
+	if err == nil {
+		t.Errorf("Expecting a wrong passphrase to fail")
+	}
+}
+
+func TestRuntimeConfigEncrypted(t *testing.T) {
+	inConfigDir(t, "dojoConfig.yml", sampleConfig, func() {
+		v := viper.New()
+		v.SetConfigFile("dojoConfig.yml")
+		if err := v.ReadInConfig(); err != nil {
+			t.Fatalf("Unable to read config: %v", err)
+		}
+		v.Set("Install.ConfigPassphrase", "correct horse")
+		out := filepath.Join(t.TempDir(), "runtime-install-config.yml")
+		if err := writeRuntimeConfig(v, out, "correct horse"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		b, _ := ioutil.ReadFile(out)
+		if strings.Contains(string(b), "vee0Thoanae1daePooz0ieka") || !strings.Contains(string(b), encPrefix) {
+			t.Errorf("Expecting secrets encrypted on disk, got:\n%s", b)
+		}
+		if strings.Contains(strings.ToLower(string(b)), "configpassphrase") {
+			t.Errorf("Expecting the passphrase left out of the runtime config, got:\n%s", b)
+		}
+		// Non-secret values stay readable
+		if !strings.Contains(string(b), "dojodbusr") {
+			t.Errorf("Expecting non-secret values in clear text, got:\n%s", b)
+		}
+
+		clear, err := decryptYAML(b, "correct horse")
+		if err != nil {
+			t.Fatalf("Unable to decrypt: %v", err)
+		}
+		if !strings.Contains(string(clear), "vee0Thoanae1daePooz0ieka") || !strings.Contains(string(clear), "uu6ahHei3ohquoh1") {
+			t.Errorf("Expecting decrypted secrets, got:\n%s", clear)
+		}
+	})
+}
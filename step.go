@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles 'godojo step <name>' which runs a single install step against an existing install
+
+// appReady returns an error if the source, virtualenv or database an app step runs against is missing
+func appReady(i *config.InstallConfig) error {
+	_, err := os.Stat(filepath.Join(i.Root, i.Source, "manage.py"))
+	if err != nil {
+		return fmt.Errorf("the DefectDojo source isn't in %s, run a full install first", filepath.Join(i.Root, i.Source))
+	}
+	_, err = os.Stat(filepath.Join(venvPath(i), "bin", "activate"))
+	if err != nil {
+		return fmt.Errorf("the virtualenv isn't in %s, run the prep-os step first", venvPath(i))
+	}
+	return pingDB(context.Background(), &i.DB)
+}
+
+// stepNames returns the names of steps in order
+func stepNames(steps []installStep) []string {
+	names := make([]string, len(steps))
+	for n, s := range steps {
+		names[n] = s.name
+	}
+	return names
+}
+
+// runStep runs only the step called name from steps once its prerequisites are met, holding the install lock in root
+func runStep(ctx context.Context, root string, r ProgressReporter, steps []installStep, name string) error {
+	for _, s := range steps {
+		if s.name != name {
+			continue
+		}
+		if s.skip {
+			return fmt.Errorf("The %s step has nothing to do with this config", name)
+		}
+		err := RequireBinaries(s.needs...)
+		if err != nil {
+			return err
+		}
+		if s.ready != nil {
+			err = s.ready()
+			if err != nil {
+				return fmt.Errorf("Unable to run the %s step: %w", name, err)
+			}
+		}
+		return runStepsLocked(ctx, root, r, []installStep{s})
+	}
+	return &unknownStepError{name: name, steps: stepNames(steps)}
+}
+
+// unknownStepError - a step name godojo step doesn't know
+type unknownStepError struct {
+	name  string
+	steps []string
+}
+
+func (e *unknownStepError) Error() string {
+	return fmt.Sprintf("Unknown install step %s, the steps are %s", e.name, strings.Join(e.steps, ", "))
+}
+
+// stepCmd implements 'godojo step <name>'
+func stepCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	fs.Usage = func() {
+		fmt.Println("Usage: godojo step <name> [flags]")
+		fmt.Printf("Steps: %s\n", strings.Join(stepNames(installSteps(&conf, targetOS{})), ", "))
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitConfig
+	}
+	err = loadConfig(viper.GetViper(), fs, &conf)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	err = applyConfig(&conf)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	_, err = validateConfig(&conf)
+	if err != nil {
+		fmt.Printf("%+v\n", Redactatron(err.Error(), Redact))
+		return exitConfig
+	}
+	ContainerMode = containerMode(&conf.Install)
+	rootWarn, err := rootCheck(&conf.Install, ContainerMode)
+	if err != nil {
+		fmt.Printf("ERROR: %s\n", err)
+		return exitFailure
+	}
+	if rootWarn != "" {
+		fmt.Printf("WARNING: %s\n", rootWarn)
+	}
+
+	// Log the step like an install so it's there with the rest of the install's logs
+	n := time.Now()
+	err = os.MkdirAll(logLocation, 0755)
+	if err != nil {
+		fmt.Printf("Unable to create the log directory %s: %+v\n", logLocation, err)
+		return exitFailure
+	}
+	logPath := filepath.Join(logLocation, installLogName(n))
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		fmt.Printf("Unable to open the log file %s: %+v\n", logPath, err)
+		return exitFailure
+	}
+	defer logFile.Close()
+	logHeader(logFile, viper.GetViper(), os.Args)
+	logSetup(logFile, nil)
+	if rootWarn != "" {
+		Warning.Println(rootWarn)
+	}
+
+	target := targetOS{}
+	determineOS(&target)
+	ctx, cancel := installContext(ctx, conf.Install.InstallTimeout)
+	defer cancel()
+	err = runStep(ctx, conf.Install.Root, reporter, installSteps(&conf, target), fs.Arg(0))
+	if err != nil {
+		errorMsg("error", err)
+		return exitCode(err)
+	}
+	statusMsg("step.done", fs.Arg(0), logPath)
+	return 0
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/viper"
+)
+
+func TestRunStep(t *testing.T) {
+	fakeLookPath(t, "expect")
+	ran := []string{}
+	step := func(name string) installStep {
+		return installStep{name: name, run: func(ctx context.Context) error { ran = append(ran, name); return nil }}
+	}
+	steps := []installStep{step("django"), step("superuser"), step("nginx")}
+	steps[1].needs = []string{"expect"}
+	root := t.TempDir()
+
+	err := runStep(context.Background(), root, &recordingReporter{}, steps, "superuser")
+	if err != nil || strings.Join(ran, ",") != "superuser" {
+		t.Errorf("Expecting only the superuser step run, got %v after %v", err, ran)
+	}
+
+	err = runStep(context.Background(), root, &recordingReporter{}, steps, "createSuperuser")
+	var uErr *unknownStepError
+	if !errors.As(err, &uErr) || !strings.Contains(err.Error(), "django, superuser, nginx") {
+		t.Errorf("Expecting an unknown step error listing the steps, got %v", err)
+	}
+}
+
+func TestRunStepPrerequisites(t *testing.T) {
+	fakeLookPath(t)
+	ran := false
+	steps := []installStep{{
+		name:  "superuser",
+		ready: func() error { return errors.New("the DefectDojo source isn't in /opt/dojo/django-DefectDojo") },
+		run:   func(ctx context.Context) error { ran = true; return nil },
+	}}
+	root := t.TempDir()
+	err := runStep(context.Background(), root, &recordingReporter{}, steps, "superuser")
+	if err == nil || !strings.Contains(err.Error(), "source isn't in") || ran {
+		t.Errorf("Expecting the missing source to stop the step, got %v, ran %v", err, ran)
+	}
+
+	steps[0].ready = nil
+	steps[0].needs = []string{"expect"}
+	err = runStep(context.Background(), root, &recordingReporter{}, steps, "superuser")
+	if err == nil || !strings.Contains(err.Error(), "expect") || ran {
+		t.Errorf("Expecting the missing expect to stop the step, got %v, ran %v", err, ran)
+	}
+}
+
+func TestRunStepLocked(t *testing.T) {
+	fakeLookPath(t)
+	ran := false
+	steps := []installStep{{name: "nginx", run: func(ctx context.Context) error { ran = true; return nil }}}
+	root := t.TempDir()
+	// Held by this process, which is alive
+	writeFile(t, filepath.Join(root, lockName), strconv.Itoa(os.Getpid())+"\n")
+	err := runStep(context.Background(), root, &recordingReporter{}, steps, "nginx")
+	var lErr *lockHeldError
+	if !errors.As(err, &lErr) || ran {
+		t.Errorf("Expecting the step refused while an install holds the lock, got %v, ran %v", err, ran)
+	}
+
+	os.Remove(filepath.Join(root, lockName))
+	err = runStep(context.Background(), root, &recordingReporter{}, steps, "nginx")
+	if err != nil || !ran {
+		t.Errorf("Expecting the step to run once the lock is free, got %v, ran %v", err, ran)
+	}
+	if _, err := os.Stat(filepath.Join(root, lockName)); !os.IsNotExist(err) {
+		t.Errorf("Expecting the lock removed after the step, got %v", err)
+	}
+}
+
+func TestAppReady(t *testing.T) {
+	i := config.InstallConfig{Root: t.TempDir(), Source: "django-DefectDojo"}
+	i.DB.Engine = "SQLite"
+	if err := appReady(&i); err == nil || !strings.Contains(err.Error(), "source") {
+		t.Errorf("Expecting an error for the missing source, got %v", err)
+	}
+	writeFile(t, filepath.Join(i.Root, i.Source, "manage.py"), "# manage\n")
+	if err := appReady(&i); err == nil || !strings.Contains(err.Error(), "virtualenv") {
+		t.Errorf("Expecting an error for the missing virtualenv, got %v", err)
+	}
+	writeFile(t, filepath.Join(i.Root, "bin", "activate"), "# activate\n")
+	if err := appReady(&i); err != nil {
+		t.Errorf("Expecting an existing install to be ready, got %v", err)
+	}
+
+	// Nothing listens on port 1 so the connection is refused
+	i.DB = config.DBTarget{Engine: "PostgreSQL", Host: "127.0.0.1", Port: 1, Name: "dojodb", User: "dojo", Pass: "it's a secret"}
+	if err := appReady(&i); err == nil || !strings.Contains(err.Error(), "unable to connect to the PostgreSQL database dojodb") {
+		t.Errorf("Expecting an error for an unreachable database, got %v", err)
+	}
+}
+
+func TestStepCmdSkipRootCheck(t *testing.T) {
+	captureLogs(t)
+	savedTrace, savedConf, savedContainer := Trace, conf, ContainerMode
+	t.Cleanup(func() { Trace, conf, ContainerMode = savedTrace, savedConf, savedContainer; viper.Reset() })
+	// applyConfig sets the process umask to the configured Umask
+	savedMask := setUmask(0022)
+	t.Cleanup(func() { setUmask(savedMask) })
+	runAs(t, "1000")
+
+	body := strings.Replace(sampleConfig, `Root: "/opt/dojo"`, `Root: "`+t.TempDir()+`"`+"\n  Source: \"django-DefectDojo\"", 1)
+	body = strings.Replace(body, "DB:\n", "DB:\n    Engine: \"SQLite\"\n", 1)
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		// The step doesn't exist, getting as far as running it shows the root check was skipped
+		if code := stepCmd(context.Background(), []string{"--skip-root-check", "nope"}); code == 0 {
+			t.Errorf("Expecting an unknown step to fail")
+		}
+		logs, _ := filepath.Glob(filepath.Join(logLocation, "*"))
+		if len(logs) != 1 {
+			t.Fatalf("Expecting the step to open a log with --skip-root-check, got %v", logs)
+		}
+		fi, err := os.Stat(logs[0])
+		if err != nil || fi.Mode().Perm() != 0600 {
+			t.Errorf("Expecting the step log to be 0600, got %v, %v", fi, err)
+		}
+		b, _ := ioutil.ReadFile(logs[0])
+		if !strings.Contains(string(b), "the root check is skipped per SkipRootCheck") {
+			t.Errorf("Expecting the skipped root check in the step log, got:\n%s", b)
+		}
+	})
+}
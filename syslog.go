@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// Handles sending log output to the local syslog in addition to the log file
+
+// syslogWriter - the subset of *syslog.Writer used by the installer, allows stubbing in tests
+type syslogWriter interface {
+	Debug(m string) error
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+}
+
+// syslogLevel adapts one of the leveled syslogWriter methods to an io.Writer for log.New
+type syslogLevel func(m string) error
+
+func (f syslogLevel) Write(p []byte) (int, error) {
+	err := f(strings.TrimRight(string(p), "\n"))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// levelWriters returns the writers for the Trace, Info, Warning, and Error loggers, each sending to
+// logHandler and, if sw isn't nil, to syslog at the priority matching the level
+func levelWriters(logHandler io.Writer, sw syslogWriter) (io.Writer, io.Writer, io.Writer, io.Writer) {
+	if sw == nil {
+		return logHandler, logHandler, logHandler, logHandler
+	}
+	return io.MultiWriter(logHandler, syslogLevel(sw.Debug)),
+		io.MultiWriter(logHandler, syslogLevel(sw.Info)),
+		io.MultiWriter(logHandler, syslogLevel(sw.Warning)),
+		io.MultiWriter(logHandler, syslogLevel(sw.Err))
+}
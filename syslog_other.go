@@ -0,0 +1,13 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import (
+	"errors"
+)
+
+// newSyslog always fails since there's no syslog on this platform
+func newSyslog() (syslogWriter, error) {
+	return nil, errors.New("syslog is not available on this platform")
+}
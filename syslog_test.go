@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// stubSyslog records each message along with the priority it was sent at
+type stubSyslog struct {
+	msgs map[string][]string
+}
+
+func (s *stubSyslog) add(p string, m string) error {
+	s.msgs[p] = append(s.msgs[p], m)
+	return nil
+}
+func (s *stubSyslog) Debug(m string) error   { return s.add("debug", m) }
+func (s *stubSyslog) Info(m string) error    { return s.add("info", m) }
+func (s *stubSyslog) Warning(m string) error { return s.add("warning", m) }
+func (s *stubSyslog) Err(m string) error     { return s.add("err", m) }
+
+func TestSyslogPriorities(t *testing.T) {
+	defer logSetup(ioutil.Discard, nil)
+
+	sw := &stubSyslog{msgs: make(map[string][]string)}
+	logSetup(ioutil.Discard, sw)
+	Trace.Println("trace line")
+	Info.Println("info line")
+	Warning.Println("warning line")
+	Error.Println("error line")
+
+	want := map[string]string{
+		"debug":   "trace line",
+		"info":    "info line",
+		"warning": "warning line",
+		"err":     "error line",
+	}
+	for p, m := range want {
+		if len(sw.msgs[p]) != 1 || !strings.HasSuffix(sw.msgs[p][0], m) {
+			t.Errorf("Expecting '%s' at syslog priority %s, got %v", m, p, sw.msgs[p])
+		}
+	}
+}
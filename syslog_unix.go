@@ -0,0 +1,21 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"log/syslog"
+)
+
+// dialSyslog connects to the local syslog daemon, replaced in tests
+var dialSyslog = syslog.New
+
+// newSyslog connects to the local syslog daemon using the godojo tag. The error is checked before returning
+// so a failed connection is a nil syslogWriter rather than one holding a nil *syslog.Writer
+func newSyslog() (syslogWriter, error) {
+	w, err := dialSyslog(syslog.LOG_INFO|syslog.LOG_DAEMON, "godojo")
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,28 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"log/syslog"
+	"testing"
+)
+
+func TestNewSyslogUnavailable(t *testing.T) {
+	saved := dialSyslog
+	t.Cleanup(func() { dialSyslog = saved })
+	defer logSetup(ioutil.Discard, nil)
+	dialSyslog = func(p syslog.Priority, tag string) (*syslog.Writer, error) {
+		return nil, errors.New("Unix syslog delivery error")
+	}
+
+	sw, err := newSyslog()
+	if err == nil || sw != nil {
+		t.Fatalf("Expecting a nil syslogWriter and an error without /dev/log, got %#v, %v", sw, err)
+	}
+	// Panics with a nil dereference if sw held a nil *syslog.Writer
+	logSetup(ioutil.Discard, sw)
+	Info.Println("info line")
+}
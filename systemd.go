@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// Handles writing and enabling the systemd units for DefectDojo's services
+
+// systemdDir is where DefectDojo's units are written
+var systemdDir = "/etc/systemd/system"
+
+// systemdUnit - the values which differ between DefectDojo's systemd units
+type systemdUnit struct {
+	Name        string // Unit file name without .service e.g. defectdojo-celerybeat
+	Description string
+	After       string // Units this one starts after
+	User        string
+	Group       string
+	WorkDir     string
+	ExecStart   string
+}
+
+// unitTmpl is the systemd service unit for a DefectDojo service
+var unitTmpl = template.Must(template.New("unit").Parse(`# Written by godojo
+[Unit]
+Description={{ .Description }}
+After={{ .After }}
+
+[Service]
+Type=simple
+User={{ .User }}
+Group={{ .Group }}
+WorkingDirectory={{ .WorkDir }}
+ExecStart={{ .ExecStart }}
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// renderUnit writes the systemd unit file for u to w
+func renderUnit(w io.Writer, u systemdUnit) error {
+	if u.After == "" {
+		u.After = "network.target"
+	}
+	return unitTmpl.Execute(w, u)
+}
+
+// installUnit writes the unit for u and enables and starts it, in container mode only the file is written
+func installUnit(ctx context.Context, u systemdUnit) error {
+	p := filepath.Join(systemdDir, u.Name+".service")
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("Unable to write systemd unit %s: %w", p, err)
+	}
+	err = renderUnit(f, u)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	manifest.addPath(p)
+	statusMsg("systemd.wrote", p)
+
+	if ContainerMode {
+		statusMsg("systemd.container", u.Name)
+		return nil
+	}
+	err = runCmd(ctx, "systemctl", "daemon-reload")
+	if err != nil {
+		return err
+	}
+	return runCmd(ctx, "systemctl", "enable", "--now", u.Name+".service")
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -45,11 +46,11 @@ func determineOS(tOS *targetOS) {
 	case "darwin":
 		traceMsg("OS determined to be Darwin/OS X")
 		fmt.Println("OS X/Darwin")
-		errorMsg("OS X is not YET a supported installation platform")
+		errorMsg("target.osx")
 		os.Exit(1)
 	case "windows":
 		traceMsg("OS determined to be Windows")
-		errorMsg("Windows is not a supported installation platform")
+		errorMsg("target.windows")
 		os.Exit(1)
 	}
 
@@ -111,19 +112,19 @@ func determineLinux(tOS *targetOS) {
 	if err == nil {
 		// Distro is too old, not supported
 		traceMsg("Older SuSe Linux distro isn't supported by this installer")
-		errorMsg("Older versions of SuSe Linux are not suppported, quitting")
+		errorMsg("target.suse")
 		os.Exit(1)
 	}
 	_, err = os.Stat("/etc/redhat-release")
 	if err == nil {
 		// Distro is too old, not supported
 		traceMsg("Older RedHat Linux distro isn't supported by this installer")
-		errorMsg("Older versions of Redhat Linux are not suppported, quitting")
+		errorMsg("target.redhat")
 		os.Exit(1)
 	}
 
 	traceMsg("Unable to determine the linux distro, assuming unsupported.")
-	errorMsg("Unable to determine the Linux install target, quitting")
+	errorMsg("target.unknown")
 	os.Exit(1)
 }
 
@@ -149,7 +150,7 @@ func parseLsbCmd(cmd string) (string, string, string) {
 	// Run command and gather its output
 	cmdOut, err := runCmd.CombinedOutput()
 	if err != nil {
-		errorMsg(fmt.Sprintf("Failed to run OS command, error was: %+v", err))
+		errorMsg("cmd.run", err)
 		os.Exit(1)
 	}
 
@@ -173,12 +174,12 @@ func parseLsbCmd(cmd string) (string, string, string) {
 
 	if _, ok := vals["distro"]; !ok {
 		// The distro key hasn't been set above
-		errorMsg("Unable to determine distro from lsb_release command, quitting.")
+		errorMsg("target.lsb-distro")
 		os.Exit(1)
 	}
 	if _, ok := vals["release"]; !ok {
 		// The distro key hasn't been set above
-		errorMsg("Unable to determine release from lsb_release command, quitting.")
+		errorMsg("target.lsb-rel")
 		os.Exit(1)
 	}
 
@@ -203,7 +204,7 @@ func parseEtcIss(f string) (string, string, string) {
 	// Open the file for parsing
 	file, err := os.Open(f)
 	if err != nil {
-		errorMsg(fmt.Sprintf("Unable to open file: %+v\nError was: %v", f, err))
+		errorMsg("file.open", f, err)
 		os.Exit(1)
 	}
 	defer func() {
@@ -218,7 +219,7 @@ func parseEtcIss(f string) (string, string, string) {
 	reader := bufio.NewReader(file)
 	line, err := reader.ReadString('\n')
 	if err != nil {
-		errorMsg(fmt.Sprintf("Unable to read file: %+v\nError was: %v", f, err))
+		errorMsg("file.read", f, err)
 		os.Exit(1)
 	}
 	fields := strings.Split(line, " ")
@@ -242,13 +243,13 @@ func parseEtcDeb(f string) (string, string, string) {
 	// Open the file for parsing
 	file, err := os.Open(f)
 	if err != nil {
-		errorMsg(fmt.Sprintf("Unable to open file: %+v\nError was: %v", f, err))
+		errorMsg("file.open", f, err)
 		os.Exit(1)
 	}
 	defer func() {
 		err := file.Close()
 		if err != nil {
-			errorMsg(fmt.Sprintf("Unable to close file\nError was: %v", err))
+			errorMsg("file.close", err)
 			os.Exit(1)
 		}
 	}()
@@ -257,7 +258,7 @@ func parseEtcDeb(f string) (string, string, string) {
 	reader := bufio.NewReader(file)
 	line, err := reader.ReadString('\n')
 	if err != nil {
-		errorMsg(fmt.Sprintf("Unable to read file: %+v\nError was: %v", f, err))
+		errorMsg("file.read", f, err)
 		os.Exit(1)
 	}
 	// TODO: Test this with a Debian docker
@@ -273,13 +274,13 @@ func parseFile(f string, sep string, flds map[string]string) map[string]string {
 	// Open the file for parsing
 	file, err := os.Open(f)
 	if err != nil {
-		errorMsg(fmt.Sprintf("Unable to open file: %+v\nError was: %v", f, err))
+		errorMsg("file.open", f, err)
 		os.Exit(1)
 	}
 	defer func() {
 		err := file.Close()
 		if err != nil {
-			errorMsg(fmt.Sprintf("Unable to close file\nError was: %v", err))
+			errorMsg("file.close", err)
 			os.Exit(1)
 		}
 	}()
@@ -333,33 +334,79 @@ func initBootstrap(id string, b *osCmds) {
 	}
 }
 
-func checkPythonVersion() bool {
-	// DefectDojo is now Python 3+, lets make sure that's installed
-	_, err := exec.LookPath("python3")
-	if err != nil {
-		errorMsg(fmt.Sprintf("Unable to find python binary. Error was: %+v", err))
-		os.Exit(1)
+// defaultMinPython is the oldest Python DefectDojo supports, used when MinPython isn't configured
+const defaultMinPython = "3.6"
+
+// pythonNames are the interpreters looked for on the PATH, in order of preference
+var pythonNames = []string{"python3", "python"}
+
+// findPython returns the path of the first Python interpreter found on the PATH
+func findPython() (string, error) {
+	for _, n := range pythonNames {
+		p, err := exec.LookPath(n)
+		if err == nil {
+			return p, nil
+		}
 	}
+	return "", fmt.Errorf("none of %s were found on the PATH", strings.Join(pythonNames, ", "))
+}
 
-	// Execute the python3 command with --version to get the version
-	runCmd := exec.Command("python3", "--version")
+// PythonVersion runs pythonPath with --version and returns the major and minor version it reports
+func PythonVersion(pythonPath string) (int, int, error) {
+	// Python 2 writes its version to stderr so gather both
+	out, err := exec.Command(pythonPath, "--version").CombinedOutput()
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed to run %s --version: %w", pythonPath, err)
+	}
+	return parseMajorMinor(parsePythonVersion(string(out)))
+}
 
-	// Run command and gather its output
-	cmdOut, err := runCmd.CombinedOutput()
+// parseMajorMinor splits a version like 3.6.9, 3.8.0rc1 or 3.6 into its major and minor numbers
+func parseMajorMinor(v string) (int, int, error) {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("unable to parse a Python version from %q", v)
+	}
+	major, err := strconv.Atoi(parts[0])
 	if err != nil {
-		errorMsg(fmt.Sprintf("Failed to run python3 command, error was: %+v", err))
-		os.Exit(1)
+		return 0, 0, fmt.Errorf("unable to parse a Python version from %q", v)
 	}
+	// Trim any pre-release or build suffix e.g. 3.8.0rc1 or 3.11+
+	minor := strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	m, err := strconv.Atoi(minor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse a Python version from %q", v)
+	}
+	return major, m, nil
+}
 
-	// Parse command output for the strings we need
-	lines := bytes.Split(cmdOut, []byte("\n"))
-	line := strings.Split(string(lines[0]), " ")
-	pyVer := line[1]
+// checkPython finds a Python interpreter and returns an error explaining what's needed if it's older than min
+func checkPython(min string) error {
+	wantMaj, wantMin, err := parseMajorMinor(orDefault(min, defaultMinPython))
+	if err != nil {
+		return fmt.Errorf("Invalid MinPython: %w", err)
+	}
+	py, err := findPython()
+	if err != nil {
+		return fmt.Errorf("Python %d.%d or later is required but %v. Install it from your OS packages or https://www.python.org/downloads/", wantMaj, wantMin, err)
+	}
+	maj, minor, err := PythonVersion(py)
+	if err != nil {
+		return err
+	}
+	traceMsg(fmt.Sprintf("Found Python %d.%d at %s", maj, minor, py))
+	if maj < wantMaj || (maj == wantMaj && minor < wantMin) {
+		return fmt.Errorf("Found Python %d.%d at %s but DefectDojo requires Python %d.%d or later. "+
+			"Install a newer Python from your OS packages or https://www.python.org/downloads/", maj, minor, py, wantMaj, wantMin)
+	}
+	return nil
+}
 
-	// TODO: Consider checking the minor version of Python3 as well - probably not needed (yet)
-	if strings.HasPrefix(pyVer, "3.") {
-		return true
+// parsePythonVersion pulls the version number out of the output of 'python --version' e.g. 3.6.9
+func parsePythonVersion(out string) string {
+	line := strings.Split(strings.TrimSpace(strings.Split(out, "\n")[0]), " ")
+	if len(line) < 2 {
+		return ""
 	}
-	// DefectDojo requires Python 3.x
-	return false
+	return line[1]
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMajorMinor(t *testing.T) {
+	tests := []struct {
+		out   string
+		major int
+		minor int
+	}{
+		{"Python 3.6.9\n", 3, 6},
+		{"Python 2.7.17\n", 2, 7},
+		{"Python 3.11.2+\n", 3, 11},
+		{"Python 3.8.0rc1\n", 3, 8},
+		{"Python 3.10\n", 3, 10},
+		{"  Python 3.7.3  \nextra line\n", 3, 7},
+	}
+	for _, tt := range tests {
+		major, minor, err := parseMajorMinor(parsePythonVersion(tt.out))
+		if err != nil || major != tt.major || minor != tt.minor {
+			t.Errorf("For %q expecting %d.%d, got %d.%d, %v", tt.out, tt.major, tt.minor, major, minor, err)
+		}
+	}
+	for _, bad := range []string{"", "Python\n", "Python three.six\n", "bash: python: command not found"} {
+		if _, _, err := parseMajorMinor(parsePythonVersion(bad)); err == nil {
+			t.Errorf("Expecting an error parsing %q", bad)
+		}
+	}
+}
+
+func TestPythonVersion(t *testing.T) {
+	// Python 2 reports its version on stderr
+	py := filepath.Join(t.TempDir(), "python")
+	writeFile(t, py, "#!/bin/sh\necho 'Python 2.7.17' >&2\n")
+	os.Chmod(py, 0755)
+	major, minor, err := PythonVersion(py)
+	if err != nil || major != 2 || minor != 7 {
+		t.Errorf("Expecting 2.7, got %d.%d, %v", major, minor, err)
+	}
+}
+
+func TestCheckPython(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "python"), "#!/bin/sh\necho 'Python 3.5.2'\n")
+	os.Chmod(filepath.Join(dir, "python"), 0755)
+	t.Setenv("PATH", dir)
+
+	// Falls back to python when there's no python3
+	err := checkPython("3.6")
+	if err == nil || !strings.Contains(err.Error(), "Found Python 3.5") || !strings.Contains(err.Error(), "requires Python 3.6") {
+		t.Errorf("Expecting an error naming the found and required versions, got %v", err)
+	}
+	if err := checkPython("3.5"); err != nil {
+		t.Errorf("Expecting Python 3.5 to meet a 3.5 minimum, got %v", err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if err := checkPython(""); err == nil || !strings.Contains(err.Error(), "python.org") {
+		t.Errorf("Expecting an error saying where to get Python, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles the opt-in telemetry posting an anonymized outcome of the install when Telemetry is set
+//
+// The payload is only the godojo and DefectDojo versions, the OS, architecture and distro, the database
+// engine and each step's name, status and duration - no host names, IPs, paths, users, errors or secrets
+
+// telemetryTimeout caps how long posting the telemetry can hold up the end of a run
+const telemetryTimeout = 5 * time.Second
+
+// telemetryStep - how one install step went, without its error as that can name hosts or paths
+type telemetryStep struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// telemetryPayload - the anonymized outcome of an install, everything sent is here
+type telemetryPayload struct {
+	Installer string          `json:"installer"` // Version of godojo
+	Version   string          `json:"version"`   // DefectDojo release, branch or commit installed
+	OS        string          `json:"os"`
+	Arch      string          `json:"arch"`
+	Distro    string          `json:"distro"` // e.g. ubuntu:18.04
+	DBEngine  string          `json:"db_engine"`
+	Success   bool            `json:"success"`
+	Steps     []telemetryStep `json:"steps"`
+}
+
+// newTelemetry builds the payload sent for the install described by res
+func newTelemetry(i *config.InstallConfig, res installResult, distro string) telemetryPayload {
+	p := telemetryPayload{
+		Installer: version,
+		Version:   res.Version,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Distro:    distro,
+		DBEngine:  i.DB.Engine,
+		Success:   res.Success,
+		Steps:     []telemetryStep{},
+	}
+	for _, s := range res.Steps {
+		p.Steps = append(p.Steps, telemetryStep{Name: s.Name, Status: s.Status, Duration: s.Duration})
+	}
+	return p
+}
+
+// sendTelemetry posts p as JSON to url giving up after telemetryTimeout
+// Any error is for logging only, telemetry never changes how an install ends
+func sendTelemetry(ctx context.Context, c httpDoer, url string, p telemetryPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, telemetryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("telemetry endpoint %s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// reportTelemetry sends the outcome in res if Telemetry is on, logging rather than returning any failure
+func reportTelemetry(ctx context.Context, i *config.InstallConfig, res installResult) {
+	if !i.Telemetry {
+		return
+	}
+	err := sendTelemetry(ctx, httpClient, i.TelemetryURL, newTelemetry(i, res, hostDistro("/etc/os-release")))
+	if err != nil {
+		traceMsg(fmt.Sprintf("Unable to send telemetry, error was: %+v", err))
+		return
+	}
+	traceMsg("Sent the anonymized install outcome to " + i.TelemetryURL)
+}
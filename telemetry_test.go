@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestNewTelemetryIsAnonymous(t *testing.T) {
+	host, _ := os.Hostname()
+	i := &config.InstallConfig{Root: "/opt/dojo-secret-path", Version: "1.5.3.1"}
+	i.DB.Engine = "PostgreSQL"
+	i.DB.Host = "db.internal.example.com"
+	i.DB.Pass = "Correct-Horse-42"
+	res := installResult{
+		Version: "1.5.3.1",
+		Steps: []stepResult{
+			{Name: "Database", Status: "failed", Duration: 2, Error: "unable to reach db.internal.example.com for " + host},
+		},
+		Paths:   []string{"/opt/dojo-secret-path"},
+		DBUsers: []string{"defectdojo"},
+		Error:   "unable to reach db.internal.example.com",
+	}
+	b, err := json.Marshal(newTelemetry(i, res, "ubuntu:18.04"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the documented fields are sent
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{}
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	want := "arch db_engine distro installer os steps success version"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("Expecting only the fields %s, got %s", want, got)
+	}
+	for _, leak := range []string{"db.internal.example.com", "dojo-secret-path", "Correct-Horse-42", "defectdojo", "error"} {
+		if strings.Contains(string(b), leak) {
+			t.Errorf("Expecting %q not to be in the telemetry, got %s", leak, b)
+		}
+	}
+	if host != "" && strings.Contains(string(b), host) {
+		t.Errorf("Expecting the hostname not to be in the telemetry, got %s", b)
+	}
+}
+
+func TestSendTelemetry(t *testing.T) {
+	var got telemetryPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &got)
+	}))
+	defer srv.Close()
+
+	p := telemetryPayload{Installer: version, Version: "1.5.3.1", Success: true}
+	if err := sendTelemetry(context.Background(), http.DefaultClient, srv.URL, p); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.Version != "1.5.3.1" || !got.Success {
+		t.Errorf("Expecting the payload to be posted, got %+v", got)
+	}
+}
+
+func TestReportTelemetryFailureIsIgnored(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+	httpClient = http.DefaultClient
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	i := &config.InstallConfig{Telemetry: true, TelemetryURL: srv.URL}
+	res := installResult{Success: true, Version: "1.5.3.1"}
+	err := sendTelemetry(context.Background(), httpClient, i.TelemetryURL, newTelemetry(i, res, "ubuntu:18.04"))
+	if err == nil {
+		t.Error("Expecting a 503 from the endpoint to be an error")
+	}
+	// Neither a failing nor an unreachable endpoint panics or exits, they're only logged
+	reportTelemetry(context.Background(), i, res)
+	srv.Close()
+	reportTelemetry(context.Background(), i, res)
+}
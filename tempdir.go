@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+// Handles the temp directory a release is downloaded and extracted in before it's moved into Root
+
+// defaultTempDir is the directory in Root used when TempDir isn't set so moving the release is a rename
+const defaultTempDir = ".godojo-tmp"
+
+// tempDir returns the configured TempDir or the default one in Root
+func tempDir(i *config.InstallConfig) string {
+	if i.TempDir != "" {
+		return i.TempDir
+	}
+	return filepath.Join(i.Root, defaultTempDir)
+}
+
+// checkTempDir returns an error if the configured TempDir doesn't exist or isn't writable and a warning
+// if it's on a different filesystem than Root as moving the release into place is then a copy
+func checkTempDir(i *config.InstallConfig) (string, error) {
+	if i.TempDir == "" {
+		return "", nil
+	}
+	fi, err := os.Stat(i.TempDir)
+	if err != nil || !fi.IsDir() {
+		return "", &dojoerr.ConfigError{Field: "Install.TempDir", Msg: i.TempDir + " isn't an existing directory"}
+	}
+	err = checkWritable(i.TempDir)
+	if err != nil {
+		return "", &dojoerr.ConfigError{Field: "Install.TempDir", Msg: err.Error()}
+	}
+	same, err := onSameDevice(i.TempDir, i.Root)
+	if err != nil {
+		return fmt.Sprintf("Unable to tell if TempDir %s is on the same filesystem as Root %s: %+v", i.TempDir, i.Root, err), nil
+	}
+	if !same {
+		return fmt.Sprintf("TempDir %s isn't on the same filesystem as Root %s, moving the release into place "+
+			"will be a copy instead of a rename", i.TempDir, i.Root), nil
+	}
+	return "", nil
+}
+
+// stagingDir creates a new directory in TempDir to download and extract a release in, the returned
+// func removes it along with the default TempDir if that's now empty
+func stagingDir(i *config.InstallConfig) (string, func(), error) {
+	base := tempDir(i)
+	err := os.MkdirAll(base, 0755)
+	if err != nil {
+		return "", nil, err
+	}
+	dir, err := ioutil.TempDir(base, "release-")
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, func() {
+		os.RemoveAll(dir)
+		if i.TempDir == "" {
+			// Fails harmlessly if something else is still in it
+			os.Remove(base)
+		}
+	}, nil
+}
+
+// How many times moveInto tries a rename failing for no known reason and how long it waits in between
+// e.g. for a scanner briefly holding a file open
+var (
+	renameAttempts   = 3
+	renameRetryDelay = 500 * time.Millisecond
+)
+
+// moveInto moves the staged file or directory src to dst, copying then removing src if they're on different
+// filesystems. A file replaces dst like a rename would e.g. the tarball from an earlier install, a directory
+// never does. Failures name both paths and the likely cause
+func moveInto(src string, dst string) error {
+	var err error
+	for attempt := 1; attempt <= renameAttempts; attempt++ {
+		err = rename(src, dst)
+		switch {
+		case err == nil:
+			return nil
+		case crossDevice(err):
+			if fi, serr := os.Lstat(src); serr == nil && fi.Mode().IsRegular() {
+				return replaceFile(src, dst, fi.Mode().Perm())
+			}
+			if _, serr := os.Lstat(dst); serr == nil {
+				return fmt.Errorf("Unable to move %s to %s as it already exists, remove it or set ExistingSource to overwrite or backup: %w", src, dst, err)
+			}
+			traceMsg(fmt.Sprintf("%s and %s are on different filesystems, copying instead of renaming", src, dst))
+			err = copyTree(src, dst)
+			if err != nil {
+				os.RemoveAll(dst)
+				return fmt.Errorf("Unable to copy %s to %s on another filesystem, check there's space for it: %w", src, dst, err)
+			}
+			return os.RemoveAll(src)
+		case os.IsExist(err) || dirNotEmpty(err):
+			return fmt.Errorf("Unable to move %s to %s as it already exists, remove it or set ExistingSource to overwrite or backup: %w", src, dst, err)
+		case os.IsPermission(err):
+			return fmt.Errorf("Unable to move %s to %s, permission denied - run as root or check the permissions of %s: %w",
+				src, dst, filepath.Dir(dst), err)
+		case os.IsNotExist(err):
+			return fmt.Errorf("Unable to move %s to %s, it or %s doesn't exist - was it removed during the install? %w",
+				src, dst, filepath.Dir(dst), err)
+		}
+		traceMsg(fmt.Sprintf("Attempt %d to move %s to %s failed: %+v", attempt, src, dst, err))
+		if attempt < renameAttempts {
+			time.Sleep(renameRetryDelay)
+		}
+	}
+	return fmt.Errorf("Unable to move %s to %s after %d attempts: %w", src, dst, renameAttempts, err)
+}
+
+// replaceFile copies the file src next to dst then renames it over dst so dst is never half written, then
+// removes src
+func replaceFile(src string, dst string, mode os.FileMode) error {
+	tmp := dst + ".godojo-tmp"
+	// Left by an interrupted install
+	os.Remove(tmp)
+	err := copyFile(src, tmp, mode)
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("Unable to copy %s to %s on another filesystem, check there's space for it: %w", src, dst, err)
+	}
+	return os.Remove(src)
+}
+
+// copyTree copies the file or directory src to dst keeping modes and symlinks, dst mustn't exist
+func copyTree(src string, dst string) error {
+	return filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case fi.IsDir():
+			return os.Mkdir(target, fi.Mode().Perm())
+		case fi.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		return copyFile(p, target, fi.Mode().Perm())
+	})
+}
+
+// copyFile copies the regular file src to a new file dst with mode
+func copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestReleaseTempDir(t *testing.T) {
+	savedClient := httpClient
+	defer func() { httpClient = savedClient }()
+
+	tb := fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+	})
+	tmp := t.TempDir()
+	var staged []os.FileInfo
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The download is written somewhere in TempDir while it's being served
+		staged, _ = ioutil.ReadDir(tmp)
+		w.Write(tb)
+	}))
+	defer ts.Close()
+	httpClient = &redirectDoer{ts: ts}
+
+	i := config.InstallConfig{Version: "1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true, TempDir: tmp}
+	err := getDojo(context.Background(), &i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(staged) != 1 || !strings.HasPrefix(staged[0].Name(), "release-") {
+		t.Errorf("Expecting the release to be staged in TempDir %s, found %v there", tmp, staged)
+	}
+	for _, f := range []string{"dojo-v1.5.3.1.tar.gz", "django-DefectDojo/manage.py"} {
+		if _, err := os.Stat(filepath.Join(i.Root, f)); err != nil {
+			t.Errorf("Expecting %s to be moved into Root, got %v", f, err)
+		}
+	}
+	if left, _ := ioutil.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("Expecting the staging directory to be removed, found %v", left)
+	}
+
+	// The default TempDir in Root isn't left behind
+	i = config.InstallConfig{Version: "1.5.3.1", Root: t.TempDir(), Source: "django-DefectDojo", PullSource: true}
+	if err := getDojo(context.Background(), &i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(i.Root, defaultTempDir)); !os.IsNotExist(err) {
+		t.Errorf("Expecting %s to be removed from Root, got %v", defaultTempDir, err)
+	}
+}
+
+func TestCheckTempDir(t *testing.T) {
+	saved := onSameDevice
+	defer func() { onSameDevice = saved }()
+
+	root := t.TempDir()
+	warn, err := checkTempDir(&config.InstallConfig{Root: root})
+	if warn != "" || err != nil {
+		t.Errorf("Expecting the default TempDir to need no checks, got %q, %v", warn, err)
+	}
+
+	var cErr *dojoerr.ConfigError
+	_, err = checkTempDir(&config.InstallConfig{Root: root, TempDir: filepath.Join(root, "missing")})
+	if !errors.As(err, &cErr) || cErr.Field != "Install.TempDir" {
+		t.Errorf("Expecting a ConfigError for a missing TempDir, got %v", err)
+	}
+
+	i := &config.InstallConfig{Root: root, TempDir: t.TempDir()}
+	onSameDevice = func(a string, b string) (bool, error) { return true, nil }
+	warn, err = checkTempDir(i)
+	if warn != "" || err != nil {
+		t.Errorf("Expecting no warning for TempDir on Root's filesystem, got %q, %v", warn, err)
+	}
+	onSameDevice = func(a string, b string) (bool, error) { return false, nil }
+	warn, err = checkTempDir(i)
+	if err != nil || !strings.Contains(warn, "isn't on the same filesystem as Root") {
+		t.Errorf("Expecting a warning for TempDir on another filesystem, got %q, %v", warn, err)
+	}
+}
+
+func TestMoveInto(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "stage", "django-DefectDojo")
+	writeFile(t, filepath.Join(src, "manage.py"), "# manage\n")
+	dst := filepath.Join(dir, "django-DefectDojo")
+
+	if err := moveInto(src, dst); err != nil {
+		t.Fatalf("Expecting a plain rename to succeed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "manage.py")); err != nil {
+		t.Errorf("Expecting manage.py moved into %s, got %v", dst, err)
+	}
+
+	// A source already in the way is reported with both paths and what to do about it
+	writeFile(t, filepath.Join(src, "manage.py"), "# manage\n")
+	err := moveInto(src, dst)
+	if err == nil || !strings.Contains(err.Error(), src) || !strings.Contains(err.Error(), dst) || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expecting an error naming both paths for an existing source, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Handles limiting download bandwidth so installs don't starve other services on shared links
+
+// throttledReader - limits reads from r to the rate allowed by lim
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	lim *rate.Limiter
+}
+
+// throttle returns r limited to maxKBps kilobytes per second or r itself if maxKBps is 0 or less
+func throttle(ctx context.Context, r io.Reader, maxKBps int) io.Reader {
+	return throttleWith(ctx, r, newLimiter(maxKBps))
+}
+
+// newLimiter returns a limiter for maxKBps kilobytes per second which can be shared by several
+// downloads to cap their combined rate, nil if maxKBps is 0 or less
+func newLimiter(maxKBps int) *rate.Limiter {
+	if maxKBps <= 0 {
+		return nil
+	}
+	bps := maxKBps * 1024
+	lim := rate.NewLimiter(rate.Limit(bps), bps)
+	// Start with an empty bucket so the first second isn't a full speed burst
+	lim.AllowN(time.Now(), bps)
+	return lim
+}
+
+// throttleWith returns r limited by lim or r itself if lim is nil
+func throttleWith(ctx context.Context, r io.Reader, lim *rate.Limiter) io.Reader {
+	if lim == nil {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, lim: lim}
+}
+
+// Read reads no more than the limiter's burst then waits until the bytes read are allowed
+func (t *throttledReader) Read(b []byte) (int, error) {
+	if len(b) > t.lim.Burst() {
+		b = b[:t.lim.Burst()]
+	}
+	n, err := t.r.Read(b)
+	if n > 0 {
+		werr := t.lim.WaitN(t.ctx, n)
+		if werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
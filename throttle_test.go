@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestThrottleRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping timing test in short mode")
+	}
+	// 256 KB at 256 KBps should take about a second
+	const kbps = 256
+	buf := make([]byte, kbps*1024)
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, throttle(context.Background(), bytes.NewReader(buf), kbps))
+	d := time.Since(start)
+	if err != nil || n != int64(len(buf)) {
+		t.Fatalf("Unexpected copy result: %d bytes, %v", n, err)
+	}
+
+	rate := float64(n) / 1024 / d.Seconds()
+	if rate > kbps*1.2 || rate < kbps*0.8 {
+		t.Errorf("Expecting a rate within 20%% of %d KBps, got %.1f KBps over %s", kbps, rate, d)
+	}
+}
+
+func TestThrottleUnlimited(t *testing.T) {
+	r := bytes.NewReader([]byte("dojo"))
+	if throttle(context.Background(), r, 0) != r {
+		t.Errorf("Expecting 0 to leave the reader unthrottled")
+	}
+}
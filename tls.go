@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles generating a self-signed TLS certificate for local HTTPS installs
+
+// tlsHostname returns the hostname for the certificate, defaulting to the OS hostname
+func tlsHostname(i *config.InstallConfig) string {
+	if i.TLS.Hostname != "" {
+		return i.TLS.Hostname
+	}
+	h, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	return h
+}
+
+// tlsPaths returns where the certificate and key are written
+func tlsPaths(i *config.InstallConfig) (string, string) {
+	cert, key := i.TLS.Cert, i.TLS.Key
+	if cert == "" {
+		cert = filepath.Join(i.Root, "tls", "dojo.crt")
+	}
+	if key == "" {
+		key = filepath.Join(i.Root, "tls", "dojo.key")
+	}
+	return cert, key
+}
+
+// generateSelfSigned writes a self-signed certificate for host plus localhost to certPath and its key to keyPath
+func generateSelfSigned(host string, certPath string, keyPath string) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("Unable to generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("Unable to generate certificate serial number: %w", err)
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: host, Organization: []string{"DefectDojo self-signed"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              appendUniq([]string{"localhost"}, host),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	// An IP as the hostname belongs in the IP SANs
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.DNSNames = []string{"localhost"}
+		tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("Unable to create TLS certificate: %w", err)
+	}
+
+	for _, p := range []string{certPath, keyPath} {
+		err = os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	err = ioutil.WriteFile(certPath, certPEM, 0644)
+	if err != nil {
+		return fmt.Errorf("Unable to write TLS certificate: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	err = ioutil.WriteFile(keyPath, keyPEM, 0600)
+	if err != nil {
+		return fmt.Errorf("Unable to write TLS key: %w", err)
+	}
+	// WriteFile doesn't change the mode of an existing file
+	return os.Chmod(keyPath, 0600)
+}
+
+// setupTLS generates the self-signed certificate if configured
+func setupTLS(i *config.InstallConfig) error {
+	if !i.TLS.SelfSigned {
+		return nil
+	}
+	host := tlsHostname(i)
+	cert, key := tlsPaths(i)
+	statusMsg("tls.self-signed")
+	Warning.Println("Self-signed TLS certificate configured, not for production")
+	err := generateSelfSigned(host, cert, key)
+	if err != nil {
+		return err
+	}
+	manifest.addPath(cert)
+	manifest.addPath(key)
+	statusMsg("tls.wrote", host, cert)
+	return nil
+}
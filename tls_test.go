@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestGenerateSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := filepath.Join(dir, "tls", "dojo.crt"), filepath.Join(dir, "tls", "dojo.key")
+	err := generateSelfSigned("dojo.example.com", cert, key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(cert)
+	if err != nil {
+		t.Fatalf("Unable to read certificate: %v", err)
+	}
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		t.Fatalf("Certificate isn't PEM encoded")
+	}
+	c, err := x509.ParseCertificate(blk.Bytes)
+	if err != nil {
+		t.Fatalf("Certificate doesn't parse: %v", err)
+	}
+	for _, h := range []string{"dojo.example.com", "localhost", "127.0.0.1"} {
+		if err := c.VerifyHostname(h); err != nil {
+			t.Errorf("Expecting the certificate to be valid for %s: %v", h, err)
+		}
+	}
+
+	st, err := os.Stat(key)
+	if err != nil {
+		t.Fatalf("Unable to stat key: %v", err)
+	}
+	if st.Mode().Perm() != 0600 {
+		t.Errorf("Expecting key mode 0600, got %o", st.Mode().Perm())
+	}
+}
+
+func TestRenderNginxTLS(t *testing.T) {
+	i := config.InstallConfig{Root: "/opt/dojo", Source: "django-DefectDojo"}
+	i.TLS.SelfSigned = true
+	i.TLS.Hostname = "dojo.example.com"
+	out := &bytes.Buffer{}
+	err := renderNginx(out, &i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"listen 443 ssl", "ssl_certificate /opt/dojo/tls/dojo.crt;", "ssl_certificate_key /opt/dojo/tls/dojo.key;"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expecting %q in nginx config, got:\n%s", want, out)
+		}
+	}
+}
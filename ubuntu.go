@@ -4,28 +4,27 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/mtesauro/godojo/config"
 )
 
-// Commands to bootstrap Ubuntu for the installer
-func ubuntuInitOSInst(id string, b *osCmds) {
+// Commands to bootstrap Ubuntu for the installer and install the OS packages pkgs
+func ubuntuInitOSInst(id string, pkgs []string, b *osCmds) {
 	switch id {
 	case "ubuntu:18.04":
 		b.id = "ubuntu:18.04"
 		b.cmds = []string{
 			fmt.Sprintf("curl -sS %s | apt-key add -", YarnGPG),
-			fmt.Sprintf("echo -n %s > /etc/apt/sources.list.d/yarn.list", YarnRepo),
+			fmt.Sprintf("echo -n '%s' > /etc/apt/sources.list.d/yarn.list", fmt.Sprintf(YarnRepo, HostArch())),
 			"DEBIAN_FRONTEND=noninteractive apt-get update",
-			"curl -sL https://deb.nodesource.com/setup_12.x | sudo -E bash - ",
-			"DEBIAN_FRONTEND=noninteractive apt-get install -y apt-transport-https libjpeg-dev gcc libssl-dev python3-dev python3-pip python3-virtualenv yarn build-essential expect",
+			"DEBIAN_FRONTEND=noninteractive apt-get install -y " + strings.Join(pkgs, " "),
 		}
 		b.errmsg = []string{
 			"Unable to obtain the gpg key for Yarn",
 			"Unable to add yard repo as an apt source",
 			"Unable to update apt database",
-			"Unable to install nodejs 12.x",
 			"Installing OS packages with apt failed",
 		}
 		b.hard = []bool{
@@ -33,7 +32,6 @@ func ubuntuInitOSInst(id string, b *osCmds) {
 			true,
 			true,
 			true,
-			true,
 		}
 		// Currently, only Ubuntu 18.04 is supported
 	}
@@ -133,7 +131,7 @@ func ubuntuDefaultMySQL(c map[string]string) {
 	f, err := os.Open("/etc/mysql/debian.cnf")
 	if err != nil {
 		// Exit with error code if we can't read the default creds file
-		errorMsg("Unable to read file with defautl credentials, cannot continue")
+		errorMsg("creds.read")
 		os.Exit(1)
 	}
 
@@ -155,28 +153,25 @@ func ubuntuDefaultMySQL(c map[string]string) {
 	}
 	if err = scanner.Err(); err != nil {
 		// Exit with error code if we can't scan the default creds file
-		errorMsg("Unable to scan file with defautl credentials, cannot continue")
+		errorMsg("creds.scan")
 		os.Exit(1)
 	}
 
 }
 
 func ubuntuOSPrep(id string, inst *config.InstallConfig, b *osCmds) {
-	// Setup virutalenv, setup OS User, and chown DefectDojo app root to the dojo user
+	// Setup OS User, and chown DefectDojo app root to the dojo user
+	// Each is skipped if already done so a reinstall, which leaves the user and group, can run them again
 	switch id {
 	case "ubuntu:18.04":
 		b.id = id
 		b.cmds = []string{
-			"python3 -m virtualenv --python=/usr/bin/python3 " + inst.Root,
-			inst.Root + "/bin/pip3 install -r " + inst.Root + "/django-DefectDojo/requirements.txt",
-			"mkdir " + inst.Root + "/logs",
-			"groupadd " + inst.OS.Group,
-			"useradd -s /bin/bash -m -g " + inst.OS.Group + " " + inst.OS.User,
+			"mkdir -p " + inst.Root + "/logs",
+			"getent group " + inst.OS.Group + " >/dev/null || groupadd " + inst.OS.Group,
+			"id -u " + inst.OS.User + " >/dev/null 2>&1 || useradd -s /bin/bash -m -g " + inst.OS.Group + " " + inst.OS.User,
 			"chown -R " + inst.OS.User + "." + inst.OS.Group + " " + inst.Root,
 		}
 		b.errmsg = []string{
-			"Unable to setup virtualenv for DefectDojo",
-			"Unable to install Python3 modules for DefectDojo",
 			"Unable to create a directory for logs",
 			"Unable to create a group for DefectDojo OS user",
 			"Unable to create an OS user for DefectDojo",
@@ -187,8 +182,6 @@ func ubuntuOSPrep(id string, inst *config.InstallConfig, b *osCmds) {
 			true,
 			true,
 			true,
-			true,
-			true,
 		}
 	}
 
@@ -201,37 +194,29 @@ func ubuntuSetupDDjango(id string, inst *config.InstallConfig, b *osCmds) {
 	case "ubuntu:18.04":
 		b.id = id
 		b.cmds = []string{
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py makemigrations --merge --noinput",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py makemigrations dojo",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py migrate",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py createsuperuser --noinput --username=\"" +
-				inst.Admin.User + "\" --email=\"" + inst.Admin.Email + "\"",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && " +
-				inst.Root + "/django-DefectDojo/setup/scripts/common/setup-superuser.expect " + inst.Admin.User + " " + inst.Admin.Pass,
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata product_type",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata test_type",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata development_environment",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata system_settings",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata benchmark_type",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata benchmark_category",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata benchmark_requirement",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata language_type",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata objects_review",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata regulation",
-			//"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py import_surveys",
-			//"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py loaddata initial_surveys",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py buildwatson",
-			"cd " + inst.Root + "/django-DefectDojo && source ../bin/activate && python3 manage.py installwatson",
-			"cd " + inst.Root + "/django-DefectDojo/components && yarn",
-			"cd " + inst.Root + "/django-DefectDojo/ && source ../bin/activate && python3 manage.py collectstatic --noinput",
+			manageShell(inst, "makemigrations", "--merge", "--noinput"),
+			manageShell(inst, "makemigrations", "dojo"),
+			manageShell(inst, "migrate"),
+			manageShell(inst, "loaddata", "product_type"),
+			manageShell(inst, "loaddata", "test_type"),
+			manageShell(inst, "loaddata", "development_environment"),
+			manageShell(inst, "loaddata", "system_settings"),
+			manageShell(inst, "loaddata", "benchmark_type"),
+			manageShell(inst, "loaddata", "benchmark_category"),
+			manageShell(inst, "loaddata", "benchmark_requirement"),
+			manageShell(inst, "loaddata", "language_type"),
+			manageShell(inst, "loaddata", "objects_review"),
+			manageShell(inst, "loaddata", "regulation"),
+			//manageShell(inst, "import_surveys"),
+			//manageShell(inst, "loaddata", "initial_surveys"),
+			manageShell(inst, "buildwatson"),
+			manageShell(inst, "installwatson"),
 			"chown -R " + inst.OS.User + "." + inst.OS.Group + " " + inst.Root,
 		}
 		b.errmsg = []string{
 			"Initial makemgrations failed",
 			"Failed during makemgration dojo",
 			"Failed during database migrate",
-			"Failed while creating DefectDojo superuser",
-			"Failed while setting the password for the DefectDojo superuser",
 			"Failed while the loading data for product_type",
 			"Failed while the loading data for test_type",
 			"Failed while the loading data for development_environment",
@@ -246,8 +231,6 @@ func ubuntuSetupDDjango(id string, inst *config.InstallConfig, b *osCmds) {
 			//"Failed while the loading data for initial_surveys",
 			"Failed while the running buildwatson",
 			"Failed while the running installwatson",
-			"Failed while the running yarn",
-			"Failed while the running collectstatic",
 			"Unable to change ownership of the DefectDojo directory",
 		}
 		b.hard = []bool{
@@ -264,13 +247,31 @@ func ubuntuSetupDDjango(id string, inst *config.InstallConfig, b *osCmds) {
 			true,
 			true,
 			true,
-			true,
-			true,
 			//true,
 			//true,
 			true,
 			true,
 			true,
+		}
+	}
+
+	return
+}
+
+func ubuntuCreateSuperuser(id string, inst *config.InstallConfig, b *osCmds) {
+	// Create the Django superuser DefectDojo's admin logs in as then set its password
+	switch id {
+	case "ubuntu:18.04":
+		b.id = id
+		b.cmds = []string{
+			manageShell(inst, "createsuperuser", "--noinput", "--username="+inst.Admin.User, "--email="+inst.Admin.Email),
+			venvShell(inst, filepath.Join(inst.Root, inst.Source, "setup", "scripts", "common", "setup-superuser.expect"), inst.Admin.User, inst.Admin.Pass),
+		}
+		b.errmsg = []string{
+			"Failed while creating DefectDojo superuser",
+			"Failed while setting the password for the DefectDojo superuser",
+		}
+		b.hard = []bool{
 			true,
 			true,
 		}
@@ -0,0 +1,9 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+// setUmask does nothing as there's no umask on this platform
+func setUmask(mask int) int {
+	return 0
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import "syscall"
+
+// setUmask sets the process umask to mask and returns the previous one
+func setUmask(mask int) int {
+	return syscall.Umask(mask)
+}
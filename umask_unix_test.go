@@ -0,0 +1,91 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func TestApplyUmask(t *testing.T) {
+	saved := setUmask(0)
+	defer setUmask(saved)
+
+	err := applyUmask(&config.InstallConfig{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	tests := map[string]os.FileMode{"file": 0640, "secret": 0600, "dir": 0750}
+	ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0666)
+	ioutil.WriteFile(filepath.Join(dir, "secret"), nil, 0600)
+	os.Mkdir(filepath.Join(dir, "dir"), 0777)
+	for name, want := range tests {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || fi.Mode().Perm() != want {
+			t.Errorf("Expecting %s to be created %o with the default umask, got %v, %v", name, want, fi, err)
+		}
+	}
+
+	if err := applyUmask(&config.InstallConfig{Umask: "0077"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "private"), nil, 0666)
+	if fi, err := os.Stat(filepath.Join(dir, "private")); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("Expecting a file created 0600 with Umask 0077, got %v, %v", fi, err)
+	}
+	if err := applyUmask(&config.InstallConfig{Umask: "u=rwx"}); err == nil {
+		t.Error("Expecting an error for a non-octal Umask")
+	}
+}
+
+func TestEnvProdMode(t *testing.T) {
+	saved := setUmask(0)
+	defer setUmask(saved)
+
+	c := config.DojoConfig{}
+	c.Install.Root = t.TempDir()
+	c.Install.Source = "dojo-src"
+	settings := filepath.Join(c.Install.Root, "dojo-src", "dojo", "settings")
+	if err := os.MkdirAll(settings, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A world-readable .env.prod left by an earlier install is tightened as well
+	if err := ioutil.WriteFile(filepath.Join(settings, ".env.prod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := genAndWriteEnv(&c, "sqlite:///defectdojo.db"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fi, err := os.Stat(filepath.Join(settings, ".env.prod"))
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("Expecting .env.prod to be written 0600 even with a 0 umask, got %v, %v", fi, err)
+	}
+}
+
+func TestReportFilesMode(t *testing.T) {
+	saved := setUmask(0027)
+	defer setUmask(saved)
+
+	dir := t.TempDir()
+	metrics := filepath.Join(dir, "godojo.prom")
+	if err := writeMetrics(metrics, installResult{}, time.Second, time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	result := filepath.Join(dir, "result.json")
+	if err := writeResult(result, installResult{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, f := range []string{metrics, result} {
+		fi, err := os.Stat(f)
+		if err != nil || fi.Mode().Perm() != 0644 {
+			t.Errorf("Expecting %s to be 0644 with a 0027 umask, got %v, %v", f, fi, err)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"archive/tar"
+	"archive/zip"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -10,22 +14,45 @@ import (
 	"strings"
 
 	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
 )
 
 // Untar takes a destination path and a reader; a tar reader loops over the tarfile
 // creating the file structure at 'dst' along the way, and writing any files
 // Based on https://medium.com/@skdomino/taring-untaring-files-in-go-6b07cf56bc07
 func Untar(dst string, r io.Reader) error {
+	return UntarStrip(dst, r, 0)
+}
+
+// UntarStrip is Untar dropping the first strip components of each entry's path like tar --strip-components
+// so a release's top directory can be extracted straight into dst, entries left empty are skipped
+func UntarStrip(dst string, r io.Reader, strip int) error {
+	return untar(dst, r, strip, nil)
+}
+
+// UntarHashed is UntarStrip also returning the SHA-256 of each file extracted keyed by its path in dst
+func UntarHashed(dst string, r io.Reader, strip int) (map[string]string, error) {
+	hashes := map[string]string{}
+	err := untar(dst, r, strip, hashes)
+	return hashes, err
+}
+
+// untar extracts r into dst, recording the SHA-256 of each file in hashes unless it's nil
+func untar(dst string, r io.Reader, strip int, hashes map[string]string) error {
+	err := os.MkdirAll(dst, 0755)
+	if err != nil {
+		return &dojoerr.ExtractError{Err: err}
+	}
 
 	// Setup new gzip Reader to extract tarball contents
 	gzr, err := gzip.NewReader(r)
 	if err != nil {
-		return err
+		return &dojoerr.ExtractError{Err: err}
 	}
 	defer func() {
 		err := gzr.Close()
 		if err != nil {
-			errorMsg(fmt.Sprintf("Unable to close the gzip reader\nError was %v", err))
+			errorMsg("gzip.close", err)
 			os.Exit(1)
 		}
 	}()
@@ -43,47 +70,243 @@ func Untar(dst string, r io.Reader) error {
 			return nil
 		// return any other error
 		case err != nil:
-			return err
+			return &dojoerr.ExtractError{Err: err}
 		// if the header is nil, just skip it (not sure how this happens)
 		case header == nil:
 			continue
 		}
 
 		// the target location where the dir/file should be created
-		target := filepath.Join(dst, header.Name)
+		name, ok := stripPath(header.Name, strip)
+		if !ok {
+			continue
+		}
+		target, err := safeJoin(dst, name)
+		if err != nil {
+			return &dojoerr.ExtractError{Entry: header.Name, Err: err}
+		}
 
 		// check the file type
 		switch header.Typeflag {
 		// if its a dir and it doesn't exist create it
 		case tar.TypeDir:
-			// TODO: Reformat me
-			if _, err := os.Stat(target); err != nil {
-				if err := os.MkdirAll(target, 0755); err != nil {
-					return err
-				}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return &dojoerr.ExtractError{Entry: header.Name, Err: err}
 			}
 
 		// if it's a file create it
 		case tar.TypeReg:
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			sum, err := extractFile(target, os.FileMode(header.Mode), tr, hashes != nil)
 			if err != nil {
-				return err
+				return &dojoerr.ExtractError{Entry: header.Name, Err: err}
 			}
-
-			// copy over contents
-			// TODO: Reformat me
-			if _, err := io.Copy(f, tr); err != nil {
-				return err
+			if hashes != nil {
+				hashes[filepath.ToSlash(name)] = sum
 			}
+		}
+	}
+}
 
-			// manually close here after each file operation; defering would cause each file close
-			// to wait until all operations have completed.
-			err = f.Close()
+// Unzip extracts the zip archive r of size bytes into dst like Untar does a tarball
+func Unzip(dst string, r io.ReaderAt, size int64) error {
+	return UnzipStrip(dst, r, size, 0)
+}
+
+// UnzipStrip is Unzip dropping the first strip components of each entry's path like UntarStrip
+func UnzipStrip(dst string, r io.ReaderAt, size int64, strip int) error {
+	return unzip(dst, r, size, strip, nil, nil)
+}
+
+// UnzipHashed is UnzipStrip also returning the SHA-256 of each file extracted keyed by its path in dst
+func UnzipHashed(dst string, r io.ReaderAt, size int64, strip int) (map[string]string, error) {
+	hashes := map[string]string{}
+	err := unzip(dst, r, size, strip, hashes, nil)
+	return hashes, err
+}
+
+// unzip extracts r into dst, recording the SHA-256 of each file in hashes unless it's nil
+// and passing the percentage of entries extracted to report unless it's nil
+func unzip(dst string, r io.ReaderAt, size int64, strip int, hashes map[string]string, report func(pct float64)) error {
+	err := os.MkdirAll(dst, 0755)
+	if err != nil {
+		return &dojoerr.ExtractError{Err: err}
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return &dojoerr.ExtractError{Err: err}
+	}
+	for n, zf := range zr.File {
+		if report != nil {
+			report(float64(n) / float64(len(zr.File)) * 100)
+		}
+		name, ok := stripPath(zf.Name, strip)
+		if !ok {
+			continue
+		}
+		target, err := safeJoin(dst, name)
+		if err != nil {
+			return &dojoerr.ExtractError{Entry: zf.Name, Err: err}
+		}
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(target, 0755)
 			if err != nil {
-				return err
+				return &dojoerr.ExtractError{Entry: zf.Name, Err: err}
 			}
+		case mode.IsRegular():
+			rc, err := zf.Open()
+			if err != nil {
+				return &dojoerr.ExtractError{Entry: zf.Name, Err: err}
+			}
+			sum, err := extractFile(target, mode.Perm(), rc, hashes != nil)
+			rc.Close()
+			if err != nil {
+				return &dojoerr.ExtractError{Entry: zf.Name, Err: err}
+			}
+			if hashes != nil {
+				hashes[filepath.ToSlash(name)] = sum
+			}
+		}
+	}
+	if report != nil {
+		report(100)
+	}
+	return nil
+}
+
+// zipMagic and gzipMagic start zip and gzip'd tar archives
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// archiveType returns the type of the archive at path, tar.gz or zip, from its first bytes falling back
+// to configured when it's neither so a mirror or release asset of either type extracts
+func archiveType(path string, configured string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, len(zipMagic))
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		return "zip", nil
+	case bytes.HasPrefix(head, gzipMagic):
+		return "tar.gz", nil
+	case configured != "":
+		return configured, nil
+	}
+	return "tar.gz", nil
+}
+
+// extractArchive extracts the release archive at path into dst dropping its top directory with the extractor
+// for its type, returning the SHA-256 of each file if hash is set. Progress is sent to the reporter
+func extractArchive(path string, dst string, configured string, hash bool) (map[string]string, error) {
+	kind, err := archiveType(path, configured)
+	if err != nil {
+		return nil, &dojoerr.ExtractError{Err: err}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &dojoerr.ExtractError{Err: err}
+	}
+	defer f.Close()
+
+	var hashes map[string]string
+	if hash {
+		hashes = map[string]string{}
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, &dojoerr.ExtractError{Err: err}
+	}
+	if kind == "zip" {
+		return hashes, unzip(dst, f, fi.Size(), 1, hashes, reporter.Extract)
+	}
+	// The tarball's progress is how much of it has been read
+	return hashes, untar(dst, &progressReader{r: f, total: fi.Size(), report: reporter.Extract}, 1, hashes)
+}
+
+// safeJoin returns name joined to dst or an error if name would land outside of dst e.g. ../../etc/cron.d/x
+// Every archive entry goes through it so a malicious archive can't write outside the source directory
+func safeJoin(dst string, name string) (string, error) {
+	target := filepath.Join(dst, name)
+	root := filepath.Clean(dst)
+	if target != root && !strings.HasPrefix(target, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the directory %s being extracted into", name, dst)
+	}
+	return target, nil
+}
+
+// extractFile writes r to target with mode, creating its directory if the archive didn't have an entry
+// for it, and returns its SHA-256 if hash is set
+func extractFile(target string, mode os.FileMode, r io.Reader, hash bool) (string, error) {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode)
+	if err != nil {
+		return "", err
+	}
+
+	// copy over contents, hashing them on the way if asked
+	var w io.Writer = f
+	h := sha256.New()
+	if hash {
+		w = io.MultiWriter(f, h)
+	}
+	_, err = io.Copy(w, r)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	err = f.Close()
+	if err != nil {
+		return "", err
+	}
+	if !hash {
+		return "", nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// applyPerms sets the permission bits of root and everything under it from perm, directories and files
+// with any execute bit get execute wherever perm has read so they stay traversable and runnable
+// Symlinks are left alone since chmod would follow them out of the tree
+func applyPerms(root string, perm os.FileMode) error {
+	perm = perm.Perm()
+	withExec := perm | (perm&0444)>>2
+	return filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		mode := perm &^ 0111
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			return nil
+		case fi.IsDir(), fi.Mode().Perm()&0111 != 0:
+			mode = withExec
 		}
+		return os.Chmod(p, mode)
+	})
+}
+
+// stripPath drops the first strip components of the tar entry name, false means nothing was left
+func stripPath(name string, strip int) (string, bool) {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	if len(parts) <= strip {
+		return "", false
 	}
+	rest := filepath.Join(parts[strip:]...)
+	return rest, rest != "" && rest != "."
 }
 
 // Redactatron - redacts sensitive information from being written to the logs
@@ -96,8 +319,12 @@ func Redactatron(l string, on bool) string {
 	// Redact sensitive data if it's turned on
 	if on {
 		for i := 0; i < len(sensStr); i++ {
+			// Skip unset values and the "." placeholder for keys generated at install time
+			if sensStr[i] == "" || sensStr[i] == "." {
+				continue
+			}
 			if strings.Contains(clean, sensStr[i]) {
-				clean = strings.Replace(clean, sensStr[0], r, -1)
+				clean = strings.Replace(clean, sensStr[i], r, -1)
 			}
 		}
 	}
@@ -119,4 +346,8 @@ func InitRedact(conf *config.DojoConfig) {
 	sensStr[9] = conf.Settings.Social.Auth.Google.OAUTH2.Secret
 	sensStr[10] = conf.Settings.Social.Auth.Okta.OAUTH2.Key
 	sensStr[11] = conf.Settings.Social.Auth.Okta.OAUTH2.Secret
+	sensStr[12] = conf.Install.GitHubToken
+	sensStr[13] = conf.Install.ConfigPassphrase
+	sensStr[14] = conf.Install.SSHKey
+	sensStr[15] = conf.Install.SSHKeyPass
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mtesauro/godojo/dojoerr"
+)
+
+func TestUntarStrip(t *testing.T) {
+	tb := fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+		{"django-DefectDojo-1.5.3.1/dojo/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/models.py", "# models\n"},
+	})
+	// An existing source directory is extracted into rather than failing like a rename would
+	dst := filepath.Join(t.TempDir(), "django-DefectDojo")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := UntarStrip(dst, bytes.NewReader(tb), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for f, want := range map[string]string{"manage.py": "# manage\n", "dojo/models.py": "# models\n"} {
+		b, err := ioutil.ReadFile(filepath.Join(dst, f))
+		if err != nil || string(b) != want {
+			t.Errorf("Expecting %s to hold %q, got %q, %v", f, want, b, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "django-DefectDojo-1.5.3.1")); err == nil {
+		t.Error("Expecting the top directory to be stripped")
+	}
+}
+
+// fixtureZip returns a zip holding entries of name and contents, names ending in / are directories
+func fixtureZip(t *testing.T, entries [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e[0], Method: zip.Deflate}
+		fh.SetMode(0644)
+		if e[0][len(e[0])-1] == '/' {
+			fh.SetMode(os.ModeDir | 0755)
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e[1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUnzipStrip(t *testing.T) {
+	// No directory entry for dojo/ as zips often leave them out
+	zb := fixtureZip(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+		{"django-DefectDojo-1.5.3.1/dojo/models.py", "# models\n"},
+	})
+	dst := filepath.Join(t.TempDir(), "django-DefectDojo")
+	err := UnzipStrip(dst, bytes.NewReader(zb), int64(len(zb)), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for f, want := range map[string]string{"manage.py": "# manage\n", "dojo/models.py": "# models\n"} {
+		b, err := ioutil.ReadFile(filepath.Join(dst, f))
+		if err != nil || string(b) != want {
+			t.Errorf("Expecting %s to hold %q, got %q, %v", f, want, b, err)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(dst, "manage.py"))
+	if err != nil || fi.Mode().Perm() != 0644 {
+		t.Errorf("Expecting manage.py to keep its 0644 mode, got %v, %v", fi, err)
+	}
+}
+
+func TestExtractRejectsTraversal(t *testing.T) {
+	entries := [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/../../evil.sh", "#!/bin/sh\n"},
+	}
+	zb := fixtureZip(t, entries)
+	tb := fixtureTarball(t, entries)
+	tests := []struct {
+		name    string
+		extract func(dst string) error
+	}{
+		{"zip", func(dst string) error { return UnzipStrip(dst, bytes.NewReader(zb), int64(len(zb)), 1) }},
+		{"tar", func(dst string) error { return UntarStrip(dst, bytes.NewReader(tb), 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := tt.extract(filepath.Join(dir, "stage", "src"))
+			var eErr *dojoerr.ExtractError
+			if !errors.As(err, &eErr) || eErr.Entry != entries[1][0] {
+				t.Errorf("Expecting an ExtractError for %s, got %v", entries[1][0], err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil.sh")); err == nil {
+				t.Error("Expecting nothing to be written outside of the destination")
+			}
+		})
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	entries := [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+	}
+	// The type comes from the archive's contents so a zip named .tar.gz still extracts
+	for name, archive := range map[string][]byte{"zip": fixtureZip(t, entries), "tar.gz": fixtureTarball(t, entries)} {
+		dir := t.TempDir()
+		p := filepath.Join(dir, "dojo-v1.5.3.1.tar.gz")
+		if err := ioutil.WriteFile(p, archive, 0644); err != nil {
+			t.Fatal(err)
+		}
+		kind, err := archiveType(p, "")
+		if err != nil || kind != name {
+			t.Errorf("Expecting the archive to be detected as %s, got %s, %v", name, kind, err)
+		}
+		hashes, err := extractArchive(p, filepath.Join(dir, "src"), "", true)
+		if err != nil {
+			t.Fatalf("Unexpected error extracting the %s: %v", name, err)
+		}
+		if want := sha("# manage\n"); hashes["manage.py"] != want {
+			t.Errorf("Expecting the %s's manage.py to hash to %s, got %s", name, want, hashes["manage.py"])
+		}
+	}
+}
+
+func TestStripPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		strip int
+		want  string
+		ok    bool
+	}{
+		{"top/dojo/models.py", 0, "top/dojo/models.py", true},
+		{"top/dojo/models.py", 1, "dojo/models.py", true},
+		{"top/dojo/", 1, "dojo", true},
+		{"top/", 1, "", false},
+		{"top/dojo/models.py", 3, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := stripPath(tt.name, tt.strip)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stripPath(%q, %d) = %q, %v, expecting %q, %v", tt.name, tt.strip, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestApplyPerms(t *testing.T) {
+	tb := fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/settings.py", "# world readable in the tarball\n"},
+	})
+	dst := filepath.Join(t.TempDir(), "django-DefectDojo")
+	if err := UntarStrip(dst, bytes.NewReader(tb), 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	writeFile(t, filepath.Join(dst, "setup.bash"), "#!/bin/bash\n")
+	os.Chmod(filepath.Join(dst, "setup.bash"), 0755)
+
+	err := applyPerms(dst, 0640)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for p, want := range map[string]os.FileMode{
+		"":                 0750,
+		"dojo":             0750,
+		"dojo/settings.py": 0640,
+		"setup.bash":       0750,
+	} {
+		fi, err := os.Stat(filepath.Join(dst, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != want {
+			t.Errorf("Expecting %q to be %o, got %o", p, want, fi.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/mtesauro/godojo/dojoerr"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles 'godojo validate' which checks a config the way an install would without installing anything
+// so a CI pipeline can lint dojoConfig.yml before it's deployed
+
+// validateCmd implements 'godojo validate [flags]'
+func validateCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	fs.Usage = func() {
+		fmt.Println("Usage: godojo validate [flags]")
+		fmt.Println("Checks the config file, ENV variables and flags, printing every problem found - nothing is installed and root isn't needed")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	return runValidate(os.Stdout, viper.GetViper(), fs)
+}
+
+// runValidate loads the config with v and fs then writes its warnings followed by config OK or each problem to w
+// The only network access is fetching --config-url if it's given
+func runValidate(w io.Writer, v *viper.Viper, fs *pflag.FlagSet) int {
+	c := config.DojoConfig{}
+	err := loadConfig(v, fs, &c)
+	if err != nil {
+		fmt.Fprintf(w, "%+v\n", err)
+		return exitConfig
+	}
+	InitRedact(&c)
+	warns, err := c.Validate()
+	if keys := defaultedKeys(v, &c.Install); len(keys) > 0 {
+		warns = append(warns, defaultedWarning(keys, &c.Install))
+	}
+	for _, warn := range warns {
+		fmt.Fprintln(w, "WARNING: "+Redactatron(warn, true))
+	}
+	if err != nil {
+		problems, ok := err.(dojoerr.ConfigErrors)
+		if !ok {
+			fmt.Fprintf(w, "%+v\n", Redactatron(err.Error(), true))
+			return exitConfig
+		}
+		fmt.Fprintf(w, "config has %d problem(s):\n", len(problems))
+		for _, p := range problems {
+			fmt.Fprintln(w, "  "+Redactatron(p.Error(), true))
+		}
+		return exitConfig
+	}
+	fmt.Fprintln(w, "config OK")
+	return 0
+}
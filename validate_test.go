@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRunValidate(t *testing.T) {
+	saved := sensStr
+	defer func() { sensStr = saved }()
+
+	body := `
+Install:
+  Version: "1.5.3.1"
+  Root: "/opt/dojo"
+  Source: "django-DefectDojo"
+  DB:
+    Engine: "PostgreSQL"
+    Name: "dojodb"
+    User: "dojodbusr"
+    Pass: "vee0Thoanae1daePooz0ieka"
+  Admin:
+    User: "admin"
+    Pass: "Ohseek4aiveeM3ai"
+`
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		var out bytes.Buffer
+		if code := runValidate(&out, viper.New(), installFlags()); code != 0 {
+			t.Errorf("Expecting exit code 0 for a valid config, got %d: %s", code, out.String())
+		}
+		if !strings.HasSuffix(out.String(), "config OK\n") {
+			t.Errorf("Expecting config OK, got %q", out.String())
+		}
+	})
+}
+
+func TestRunValidateListsEveryProblem(t *testing.T) {
+	saved := sensStr
+	defer func() { sensStr = saved }()
+
+	body := `
+Install:
+  Version: "latest"
+  Root: "/opt/dojo"
+  Source: "../elsewhere"
+  ChecksumURL: "ftp://mirror.example.com/1.5.3.1.sha256"
+  DB:
+    Engine: "SQLite"
+  Admin:
+    Pass: "Correct-Horse-42"
+  AppServer:
+    Type: "apache"
+`
+	inConfigDir(t, "dojoConfig.yml", body, func() {
+		var out bytes.Buffer
+		if code := runValidate(&out, viper.New(), installFlags()); code != exitConfig {
+			t.Errorf("Expecting exit code %d for an invalid config, got %d", exitConfig, code)
+		}
+		for _, field := range []string{"Install.Version", "Install.Source", "Install.ChecksumURL", "Install.AppServer.Type"} {
+			if !strings.Contains(out.String(), field) {
+				t.Errorf("Expecting %s to be listed as a problem, got:\n%s", field, out.String())
+			}
+		}
+		if !strings.Contains(out.String(), "config has 4 problem(s)") {
+			t.Errorf("Expecting 4 problems to be counted, got:\n%s", out.String())
+		}
+		if strings.Contains(out.String(), "Correct-Horse-42") {
+			t.Errorf("Expecting the admin password to be redacted, got:\n%s", out.String())
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// Handles creating, or reusing, the Python virtualenv for DefectDojo
+
+// createVenv creates a new virtualenv at path - a var so tests can avoid running virtualenv
+var createVenv = func(path string) error {
+	cmd := exec.Command("python3", "-m", "virtualenv", "--python=/usr/bin/python3", path)
+	out, err := cmd.CombinedOutput()
+	traceMsg(fmt.Sprintf("Output from creating the virtualenv was:\n%s", out))
+	return err
+}
+
+// venvPath returns the directory of the virtualenv, defaulting to the install root
+func venvPath(i *config.InstallConfig) string {
+	if i.VenvPath != "" {
+		return i.VenvPath
+	}
+	return i.Root
+}
+
+// validVenv returns nil if the virtualenv at path has a working Python 3 interpreter
+func validVenv(path string) error {
+	py := filepath.Join(path, "bin", "python")
+	// Stat follows symlinks so this also catches a venv pointing at a since deleted system Python
+	_, err := os.Stat(py)
+	if err != nil {
+		return fmt.Errorf("virtualenv interpreter %s is missing or broken: %w", py, err)
+	}
+	out, err := exec.Command(py, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("virtualenv interpreter %s failed to run: %w", py, err)
+	}
+	pyVer := parsePythonVersion(string(out))
+	if !strings.HasPrefix(pyVer, "3.") {
+		return fmt.Errorf("virtualenv interpreter %s is Python %s, DefectDojo requires Python 3", py, pyVer)
+	}
+	return nil
+}
+
+// setupVirtualenv reuses an existing valid virtualenv or creates a new one
+func setupVirtualenv(i *config.InstallConfig) error {
+	p := venvPath(i)
+	if i.ForceVenv {
+		traceMsg("ForceVenv is set, recreating the virtualenv")
+	} else {
+		err := validVenv(p)
+		if err == nil {
+			statusMsg("venv.reuse", p)
+			return nil
+		}
+		traceMsg(fmt.Sprintf("No reusable virtualenv at %+v: %+v", p, err))
+	}
+
+	statusMsg("venv.create", p)
+	err := createVenv(p)
+	if err != nil {
+		return err
+	}
+	manifest.addPath(filepath.Join(p, "bin"))
+	return nil
+}
+
+// requirementsFile returns the path of the pip requirements file to install, requirements.txt in the source by default
+func requirementsFile(i *config.InstallConfig) string {
+	return filepath.Join(i.Root, i.Source, orDefault(i.RequirementsFile, "requirements.txt"))
+}
+
+// requirementsCmd returns the pip command installing req and any extra packages into the virtualenv at venv
+func requirementsCmd(venv string, req string, extras []string) []string {
+	cmd := []string{filepath.Join(venv, "bin", "pip3"), "install", "-r", req}
+	return append(cmd, extras...)
+}
+
+// installRequirements installs DefectDojo's Python dependencies into its virtualenv
+func installRequirements(ctx context.Context, i *config.InstallConfig) error {
+	req := requirementsFile(i)
+	_, err := os.Stat(req)
+	if err != nil {
+		return fmt.Errorf("Unable to find the pip requirements file %s: %w", req, err)
+	}
+	c := requirementsCmd(venvPath(i), req, append(appServerPackages(i), i.PipExtras...))
+	err = runCmd(ctx, c[0], c[1:]...)
+	if err != nil {
+		return fmt.Errorf("Unable to install Python3 modules for DefectDojo: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+// stubCreateVenv replaces createVenv for the test, returning a pointer to the number of calls
+func stubCreateVenv(t *testing.T) *int {
+	saved := createVenv
+	t.Cleanup(func() { createVenv = saved })
+	calls := 0
+	createVenv = func(path string) error {
+		calls++
+		return nil
+	}
+	return &calls
+}
+
+func TestSetupVirtualenvReuse(t *testing.T) {
+	calls := stubCreateVenv(t)
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	err := ioutil.WriteFile(filepath.Join(root, "bin", "python"), []byte("#!/bin/sh\necho 'Python 3.6.9'\n"), 0755)
+	if err != nil {
+		t.Fatalf("Unable to write fake python: %v", err)
+	}
+
+	i := config.InstallConfig{Root: root}
+	if err := setupVirtualenv(&i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *calls != 0 {
+		t.Errorf("Expecting a valid virtualenv to be reused, was recreated %d times", *calls)
+	}
+
+	// ForceVenv always recreates
+	i.ForceVenv = true
+	if err := setupVirtualenv(&i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Expecting ForceVenv to recreate the virtualenv, got %d calls", *calls)
+	}
+}
+
+func TestSetupVirtualenvBroken(t *testing.T) {
+	calls := stubCreateVenv(t)
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	// Interpreter points at a system Python that no longer exists
+	err := os.Symlink(filepath.Join(root, "gone", "python3.6"), filepath.Join(root, "bin", "python"))
+	if err != nil {
+		t.Fatalf("Unable to symlink fake python: %v", err)
+	}
+
+	i := config.InstallConfig{Root: root}
+	if err := setupVirtualenv(&i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Expecting a broken virtualenv to be recreated, got %d calls", *calls)
+	}
+
+	// Missing interpreter entirely
+	i.VenvPath = t.TempDir()
+	if err := setupVirtualenv(&i); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("Expecting a missing virtualenv to be created, got %d calls", *calls)
+	}
+}
+
+func TestParsePythonVersion(t *testing.T) {
+	if got := parsePythonVersion("Python 3.6.9\n"); got != "3.6.9" {
+		t.Errorf("Expecting 3.6.9, got %s", got)
+	}
+}
+
+func TestInstallRequirements(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		extras []string
+		want   string
+	}{
+		{"default", "", nil, "/venv/bin/pip3 install -r ROOT/django-DefectDojo/requirements.txt"},
+		{"custom file and extras", "requirements-dev.txt", []string{"django-debug-toolbar", "ldap3==2.6"},
+			"/venv/bin/pip3 install -r ROOT/django-DefectDojo/requirements-dev.txt django-debug-toolbar ldap3==2.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := recordCmds(t)
+			root := t.TempDir()
+			i := config.InstallConfig{Root: root, Source: "django-DefectDojo", VenvPath: "/venv",
+				RequirementsFile: tt.file, PipExtras: tt.extras}
+			writeFile(t, requirementsFile(&i), "Django\n")
+			err := installRequirements(context.Background(), &i)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			want := strings.Replace(tt.want, "ROOT", root, 1)
+			if len(*ran) != 1 || (*ran)[0] != want {
+				t.Errorf("Expecting %q, got %q", want, *ran)
+			}
+		})
+	}
+}
+
+func TestInstallRequirementsMissing(t *testing.T) {
+	ran := recordCmds(t)
+	i := config.InstallConfig{Root: t.TempDir(), Source: "django-DefectDojo", RequirementsFile: "requirements-prod.txt"}
+	err := installRequirements(context.Background(), &i)
+	if err == nil || !strings.Contains(err.Error(), "requirements-prod.txt") {
+		t.Errorf("Expecting an error naming the missing requirements file, got %v", err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("Expecting pip not to be run, ran %q", *ran)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mtesauro/godojo/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Handles the manifest of source file hashes written at install and 'godojo verify' which checks it
+
+// hashManifestName is the file in Root holding the SHA-256 of each file extracted from the release
+const hashManifestName = "manifest.sha256"
+
+// hashFile returns the hex encoded SHA-256 of the file at path
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeHashManifest writes hashes to path in the sha256sum format sorted by file so it diffs cleanly
+func writeHashManifest(path string, hashes map[string]string) error {
+	files := make([]string, 0, len(hashes))
+	for f := range hashes {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "%s  %s\n", hashes[f], f)
+	}
+	return ioutil.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// readHashManifest returns the file hashes in the sha256sum formatted file at path
+func readHashManifest(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hashes := map[string]string{}
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		parts := strings.SplitN(s.Text(), "  ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d of %s isn't a hash and a file", n, path)
+		}
+		hashes[parts[1]] = parts[0]
+	}
+	return hashes, s.Err()
+}
+
+// verifyTree returns the files listed in the manifest at manifestPath which are missing from dir or whose
+// contents changed, files added to dir since like settings.py aren't reported
+func verifyTree(dir string, manifestPath string) ([]string, error) {
+	hashes, err := readHashManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	changed := []string{}
+	for f, want := range hashes {
+		got, err := hashFile(filepath.Join(dir, filepath.FromSlash(f)))
+		switch {
+		case os.IsNotExist(err):
+			changed = append(changed, f+" (missing)")
+		case err != nil:
+			return nil, err
+		case got != want:
+			changed = append(changed, f+" (modified)")
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// verifyCmd implements 'godojo verify' which reports source files changed since they were installed
+func verifyCmd(ctx context.Context, args []string) int {
+	fs := installFlags()
+	err := fs.Parse(args)
+	if err == pflag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Unable to parse the command-line flags: %+v\n", err)
+		return exitConfig
+	}
+	c := config.DojoConfig{}
+	err = loadConfig(viper.GetViper(), fs, &c)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		return exitConfig
+	}
+	return runVerify(os.Stdout, &c.Install)
+}
+
+// runVerify checks the install's source against its hash manifest writing what changed to w and returns the exit code
+func runVerify(w io.Writer, i *config.InstallConfig) int {
+	src := filepath.Join(i.Root, i.Source)
+	mf := filepath.Join(i.Root, hashManifestName)
+	changed, err := verifyTree(src, mf)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(w, "No hash manifest at %s, set HashSource before installing to record one\n", mf)
+		return exitConfig
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Unable to verify %s: %+v\n", src, err)
+		return exitFailure
+	}
+	if len(changed) == 0 {
+		fmt.Fprintf(w, "Every file in %s matches %s\n", src, mf)
+		return 0
+	}
+	fmt.Fprintf(w, "%d files in %s changed since the install:\n", len(changed), src)
+	for _, f := range changed {
+		fmt.Fprintf(w, "  %s\n", f)
+	}
+	return exitFailure
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mtesauro/godojo/config"
+)
+
+func sha(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHashManifest(t *testing.T) {
+	tb := fixtureTarball(t, [][2]string{
+		{"django-DefectDojo-1.5.3.1/", ""},
+		{"django-DefectDojo-1.5.3.1/manage.py", "# manage\n"},
+		{"django-DefectDojo-1.5.3.1/dojo/", ""},
+		{"django-DefectDojo-1.5.3.1/dojo/models.py", "# models\n"},
+	})
+	root := t.TempDir()
+	hashes, err := UntarHashed(filepath.Join(root, "django-DefectDojo"), bytes.NewReader(tb), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]string{"manage.py": sha("# manage\n"), "dojo/models.py": sha("# models\n")}
+	if !reflect.DeepEqual(hashes, want) {
+		t.Errorf("Expecting hashes of only the files %v, got %v", want, hashes)
+	}
+
+	mf := filepath.Join(root, hashManifestName)
+	if err := writeHashManifest(mf, hashes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, _ := ioutil.ReadFile(mf)
+	lines := sha("# models\n") + "  dojo/models.py\n" + sha("# manage\n") + "  manage.py\n"
+	if string(b) != lines {
+		t.Errorf("Expecting the manifest in sha256sum format sorted by file, got\n%s", b)
+	}
+	got, err := readHashManifest(mf)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expecting the manifest to read back as %v, got %v, %v", want, got, err)
+	}
+}
+
+func TestVerifyTree(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "django-DefectDojo")
+	writeFile(t, filepath.Join(src, "manage.py"), "# manage\n")
+	writeFile(t, filepath.Join(src, "dojo/models.py"), "# models\n")
+	writeFile(t, filepath.Join(src, "dojo/urls.py"), "# urls\n")
+	mf := filepath.Join(root, hashManifestName)
+	err := writeHashManifest(mf, map[string]string{
+		"manage.py":      sha("# manage\n"),
+		"dojo/models.py": sha("# models\n"),
+		"dojo/urls.py":   sha("# urls\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := verifyTree(src, mf)
+	if err != nil || len(changed) != 0 {
+		t.Errorf("Expecting an untouched tree to verify, got %v, %v", changed, err)
+	}
+
+	// settings.py is written by the install so new files aren't reported
+	writeFile(t, filepath.Join(src, "dojo/settings/settings.py"), "# settings\n")
+	writeFile(t, filepath.Join(src, "dojo/models.py"), "# models\nimport os\n")
+	if err := os.Remove(filepath.Join(src, "dojo/urls.py")); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = verifyTree(src, mf)
+	want := []string{"dojo/models.py (modified)", "dojo/urls.py (missing)"}
+	if err != nil || !reflect.DeepEqual(changed, want) {
+		t.Errorf("Expecting %v, got %v, %v", want, changed, err)
+	}
+
+	var out bytes.Buffer
+	i := &config.InstallConfig{Root: root, Source: "django-DefectDojo"}
+	if code := runVerify(&out, i); code != exitFailure || !strings.Contains(out.String(), "dojo/models.py (modified)") {
+		t.Errorf("Expecting exit %d listing the modified file, got %d and\n%s", exitFailure, code, out.String())
+	}
+	i.Root = t.TempDir()
+	out.Reset()
+	if code := runVerify(&out, i); code != exitConfig || !strings.Contains(out.String(), "HashSource") {
+		t.Errorf("Expecting exit %d pointing at HashSource without a manifest, got %d and\n%s", exitConfig, code, out.String())
+	}
+}